@@ -4,18 +4,22 @@ import "github.com/mosaicnetworks/babble/src/babble"
 
 //CLIConfig contains configuration for the Run command
 type CLIConfig struct {
-	Babble     babble.BabbleConfig `mapstructure:",squash"`
-	ProxyAddr  string              `mapstructure:"proxy-listen"`
-	ClientAddr string              `mapstructure:"client-connect"`
-	Standalone bool                `mapstructure:"standalone"`
+	Babble        babble.BabbleConfig `mapstructure:",squash"`
+	ProxyAddr     string              `mapstructure:"proxy-listen"`
+	ClientAddr    string              `mapstructure:"client-connect"`
+	ProxyProtocol string              `mapstructure:"proxy-protocol"`
+	Standalone    bool                `mapstructure:"standalone"`
+	App           string              `mapstructure:"app"`
 }
 
 //NewDefaultCLIConfig creates a CLIConfig with default values
 func NewDefaultCLIConfig() *CLIConfig {
 	return &CLIConfig{
-		Babble:     *babble.NewDefaultConfig(),
-		ProxyAddr:  "127.0.0.1:1338",
-		ClientAddr: "127.0.0.1:1339",
-		Standalone: false,
+		Babble:        *babble.NewDefaultConfig(),
+		ProxyAddr:     "127.0.0.1:1338",
+		ClientAddr:    "127.0.0.1:1339",
+		ProxyProtocol: "socket",
+		Standalone:    false,
+		App:           "",
 	}
 }
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mosaicnetworks/babble/src/babble"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphDataDir string
+	graphFrom    int
+	graphTo      int
+	graphFormat  string
+	graphOutput  string
+)
+
+//NewGraphCmd produces a GraphCmd which exports the hashgraph stored in a
+//badger database
+func NewGraphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the hashgraph from a badger database (DOT or JSON)",
+		RunE:  exportGraph,
+	}
+
+	AddGraphFlags(cmd)
+
+	return cmd
+}
+
+//AddGraphFlags adds flags to the graph command
+func AddGraphFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&graphDataDir, "datadir", config.Babble.DataDir, "Top-level directory containing the badger database")
+	cmd.Flags().IntVar(&graphFrom, "from", 0, "First round to export")
+	cmd.Flags().IntVar(&graphTo, "to", -1, "Last round to export (-1 for all)")
+	cmd.Flags().StringVar(&graphFormat, "format", "dot", "Output format: dot or json")
+	cmd.Flags().StringVarP(&graphOutput, "output", "o", "", "Output file (default stdout)")
+}
+
+func exportGraph(cmd *cobra.Command, args []string) error {
+	if graphFormat != "dot" && graphFormat != "json" {
+		return fmt.Errorf("Unknown format %s", graphFormat)
+	}
+
+	conf := babble.NewDefaultConfig()
+	conf.DataDir = graphDataDir
+
+	store, err := hashgraph.LoadBadgerStore(conf.NodeConfig.CacheSize, conf.BadgerDir())
+	if err != nil {
+		return fmt.Errorf("Loading badger database from %s: %s", conf.BadgerDir(), err)
+	}
+	defer store.Close()
+
+	graph, err := hashgraph.NewGraph(store, graphFrom, graphTo)
+	if err != nil {
+		return fmt.Errorf("Exporting graph: %s", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if graphOutput != "" {
+		f, err := os.Create(graphOutput)
+		if err != nil {
+			return fmt.Errorf("Creating output file: %s", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if graphFormat == "dot" {
+		return graph.WriteDOT(out)
+	}
+
+	data, err := graph.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
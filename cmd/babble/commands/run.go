@@ -1,8 +1,15 @@
 package commands
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/mosaicnetworks/babble/src/babble"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/abci"
 	"github.com/mosaicnetworks/babble/src/proxy/dummy"
+	"github.com/mosaicnetworks/babble/src/proxy/jsonrpc"
+	"github.com/mosaicnetworks/babble/src/proxy/kvstore"
 	aproxy "github.com/mosaicnetworks/babble/src/proxy/socket/app"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -26,26 +33,14 @@ func NewRunCmd() *cobra.Command {
 *******************************************************************************/
 
 func runBabble(cmd *cobra.Command, args []string) error {
-	if !config.Standalone {
-		p, err := aproxy.NewSocketAppProxy(
-			config.ClientAddr,
-			config.ProxyAddr,
-			config.Babble.NodeConfig.HeartbeatTimeout,
-			config.Babble.Logger,
-		)
-
-		if err != nil {
-			config.Babble.Logger.Error("Cannot initialize socket AppProxy:", err)
-			return err
-		}
-
-		config.Babble.Proxy = p
-	} else {
-		p := dummy.NewInmemDummyClient(config.Babble.Logger)
-
-		config.Babble.Proxy = p
+	p, err := newAppProxy()
+	if err != nil {
+		config.Babble.Logger.Error("Cannot initialize AppProxy:", err)
+		return err
 	}
 
+	config.Babble.Proxy = p
+
 	engine := babble.NewBabble(&config.Babble)
 
 	if err := engine.Init(); err != nil {
@@ -55,7 +50,49 @@ func runBabble(cmd *cobra.Command, args []string) error {
 
 	engine.Run()
 
-	return nil
+	return engine.Node.ExitReason()
+}
+
+//newAppProxy returns the AppProxy selected by the configuration: one of the
+//built-in Apps running in-process, or a proxy to an external App
+func newAppProxy() (proxy.AppProxy, error) {
+	switch {
+	case config.App == "kv":
+		p := kvstore.NewInmemKVStore(config.Babble.Logger)
+		//the store is queried through the HTTP service
+		http.Handle(kvstore.QueryPath, p)
+		return p, nil
+	case config.App == "dummy", config.Standalone:
+		return dummy.NewInmemDummyClient(config.Babble.Logger), nil
+	case config.App != "":
+		return nil, fmt.Errorf("Unknown app %q", config.App)
+	}
+
+	switch config.ProxyProtocol {
+	case "socket":
+		return aproxy.NewSocketAppProxy(
+			config.ClientAddr,
+			config.ProxyAddr,
+			config.Babble.NodeConfig.HeartbeatTimeout,
+			config.Babble.Logger,
+		)
+	case "jsonrpc":
+		return jsonrpc.NewJSONRPCAppProxy(
+			config.ClientAddr,
+			config.ProxyAddr,
+			config.Babble.NodeConfig.HeartbeatTimeout,
+			config.Babble.Logger,
+		)
+	case "abci":
+		return abci.NewABCIAppProxy(
+			config.ClientAddr,
+			config.ProxyAddr,
+			config.Babble.NodeConfig.HeartbeatTimeout,
+			config.Babble.Logger,
+		)
+	default:
+		return nil, fmt.Errorf("Unknown proxy protocol %q", config.ProxyProtocol)
+	}
 }
 
 /*******************************************************************************
@@ -75,8 +112,10 @@ func AddRunFlags(cmd *cobra.Command) {
 
 	// Proxy
 	cmd.Flags().Bool("standalone", config.Standalone, "Do not create a proxy")
+	cmd.Flags().String("app", config.App, "Run a built-in app in-process instead of connecting to a client: dummy, kv")
 	cmd.Flags().StringP("proxy-listen", "p", config.ProxyAddr, "Listen IP:Port for babble proxy")
 	cmd.Flags().StringP("client-connect", "c", config.ClientAddr, "IP:Port to connect to client")
+	cmd.Flags().String("proxy-protocol", config.ProxyProtocol, "Protocol spoken with the client: socket, jsonrpc (JSON-RPC 2.0 over HTTP), or abci")
 
 	// Service
 	cmd.Flags().StringP("service-listen", "s", config.Babble.ServiceAddr, "Listen IP:Port for HTTP service")
@@ -87,7 +126,19 @@ func AddRunFlags(cmd *cobra.Command) {
 
 	// Node configuration
 	cmd.Flags().Duration("heartbeat", config.Babble.NodeConfig.HeartbeatTimeout, "Time between gossips")
+	cmd.Flags().Bool("adaptive-heartbeat", config.Babble.NodeConfig.AdaptiveHeartbeat, "Adapt the heartbeat to the load, between min-heartbeat and max-heartbeat")
+	cmd.Flags().Duration("min-heartbeat", config.Babble.NodeConfig.MinHeartbeat, "Shortest adaptive heartbeat")
+	cmd.Flags().Duration("max-heartbeat", config.Babble.NodeConfig.MaxHeartbeat, "Longest adaptive heartbeat")
 	cmd.Flags().Int("sync-limit", config.Babble.NodeConfig.SyncLimit, "Max number of events for sync")
+	cmd.Flags().Int("catch-up-limit", config.Babble.NodeConfig.CatchUpLimit, "Max number of events to catch up on in sync-limit pages before fast-forwarding")
+	cmd.Flags().Bool("archive", config.Babble.NodeConfig.Archive, "Keep the whole hashgraph; never fast-forward")
+	cmd.Flags().Bool("observer", config.Babble.Observer, "Follow consensus without being a validator; the key must not be in peers.json")
+	cmd.Flags().Int("fast-forward-quorum", config.Babble.NodeConfig.FastForwardQuorum, "Number of peers that must agree on the block to fast-forward to")
+	cmd.Flags().Int("fast-forward-tries", config.Babble.NodeConfig.FastForwardTries, "Failed fast-forward attempts before giving up (0 for no limit)")
+	cmd.Flags().String("peer-selector", config.Babble.NodeConfig.PeerSelector, "random, least-recent, most-events, latency")
+	cmd.Flags().Int("fanout", config.Babble.NodeConfig.Fanout, "Number of peers to gossip with concurrently")
+	cmd.Flags().String("commit-policy", config.Babble.NodeConfig.CommitPolicy, "What to do when the app fails to commit a block: retry, halt, unhealthy")
+	cmd.Flags().Int("commit-retries", config.Babble.NodeConfig.CommitRetries, "Failed commit attempts before halting with the retry policy (0 for no limit)")
 }
 
 func loadConfig(cmd *cobra.Command, args []string) error {
@@ -113,13 +164,27 @@ func loadConfig(cmd *cobra.Command, args []string) error {
 		"babble.Store":                 config.Babble.Store,
 		"babble.LoadPeers":             config.Babble.LoadPeers,
 		"babble.LogLevel":              config.Babble.LogLevel,
+		"babble.Observer":              config.Babble.Observer,
 		"babble.Node.HeartbeatTimeout": config.Babble.NodeConfig.HeartbeatTimeout,
+		"babble.Node.Adaptive":         config.Babble.NodeConfig.AdaptiveHeartbeat,
+		"babble.Node.MinHeartbeat":     config.Babble.NodeConfig.MinHeartbeat,
+		"babble.Node.MaxHeartbeat":     config.Babble.NodeConfig.MaxHeartbeat,
 		"babble.Node.TCPTimeout":       config.Babble.NodeConfig.TCPTimeout,
 		"babble.node.CacheSize":        config.Babble.NodeConfig.CacheSize,
 		"babble.node.SyncLimit":        config.Babble.NodeConfig.SyncLimit,
+		"babble.node.CatchUpLimit":     config.Babble.NodeConfig.CatchUpLimit,
+		"babble.node.Archive":          config.Babble.NodeConfig.Archive,
+		"babble.node.FFQuorum":         config.Babble.NodeConfig.FastForwardQuorum,
+		"babble.node.FFTries":          config.Babble.NodeConfig.FastForwardTries,
+		"babble.node.PeerSelector":     config.Babble.NodeConfig.PeerSelector,
+		"babble.node.Fanout":           config.Babble.NodeConfig.Fanout,
+		"babble.node.CommitPolicy":     config.Babble.NodeConfig.CommitPolicy,
+		"babble.node.CommitRetries":    config.Babble.NodeConfig.CommitRetries,
 		"ProxyAddr":                    config.ProxyAddr,
 		"ClientAddr":                   config.ClientAddr,
+		"ProxyProtocol":                config.ProxyProtocol,
 		"Standalone":                   config.Standalone,
+		"App":                          config.App,
 	}).Debug("RUN")
 
 	return nil
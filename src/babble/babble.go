@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 
+	"github.com/mosaicnetworks/babble/src/common"
 	"github.com/mosaicnetworks/babble/src/crypto"
 	h "github.com/mosaicnetworks/babble/src/hashgraph"
 	"github.com/mosaicnetworks/babble/src/net"
@@ -133,12 +134,19 @@ func (b *Babble) initNode() error {
 	nodePub := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
 	n, ok := b.Peers.ByPubKey[nodePub]
 
-	if !ok {
+	var nodeID int
+	switch {
+	case b.Config.Observer && ok:
+		//An observer holding a validator key would never sign for it
+		return fmt.Errorf("Observer key must not be in peers.json")
+	case b.Config.Observer:
+		nodeID = common.Hash32(crypto.FromECDSAPub(&key.PublicKey))
+	case !ok:
 		return fmt.Errorf("Cannot find self pubkey in peers.json")
+	default:
+		nodeID = n.ID
 	}
 
-	nodeID := n.ID
-
 	b.Config.Logger.WithFields(logrus.Fields{
 		"participants": b.Peers,
 		"id":           nodeID,
@@ -208,6 +216,27 @@ func (b *Babble) Run() {
 	b.Node.Run(true)
 }
 
+//Pause stops the node and closes its transport, but keeps its store. Cf.
+//node.Node.Pause.
+func (b *Babble) Pause() error {
+	return b.Node.Pause()
+}
+
+//Resume opens a new transport on BindAddr and restarts a paused node
+func (b *Babble) Resume() error {
+	if err := b.initTransport(); err != nil {
+		return err
+	}
+
+	if err := b.Node.Resume(b.Transport); err != nil {
+		b.Transport.Close()
+
+		return err
+	}
+
+	return nil
+}
+
 func Keygen(datadir string) (*ecdsa.PrivateKey, error) {
 	pemKey := crypto.NewPemKey(datadir)
 
@@ -21,6 +21,7 @@ type BabbleConfig struct {
 	MaxPool     int    `mapstructure:"max-pool"`
 	Store       bool   `mapstructure:"store"`
 	LogLevel    string `mapstructure:"log"`
+	Observer    bool   `mapstructure:"observer"`
 
 	LoadPeers bool
 	Proxy     proxy.AppProxy
@@ -127,3 +127,28 @@ func TestSignatureEncoding(t *testing.T) {
 	}
 
 }
+
+func TestInvalidSignature(t *testing.T) {
+	privKey, _ := GenerateECDSAKey()
+	hash := SHA256([]byte("hello"))
+
+	for _, sig := range []string{"", "1", "1|2|3", "not|base36!"} {
+		if _, _, err := DecodeSignature(sig); err == nil {
+			t.Fatalf("DecodeSignature(%q) should fail", sig)
+		}
+	}
+
+	r, s, _ := Sign(privKey, hash)
+
+	if pub := ToECDSAPub([]byte("not a public key")); pub != nil {
+		t.Fatal("ToECDSAPub should return nil for an invalid key")
+	}
+
+	if Verify(nil, hash, r, s) {
+		t.Fatal("Verify should fail with a nil key")
+	}
+
+	if Verify(&privKey.PublicKey, hash, nil, s) {
+		t.Fatal("Verify should fail with a nil signature")
+	}
+}
@@ -18,6 +18,9 @@ func ToECDSAPub(pub []byte) *ecdsa.PublicKey {
 		return nil
 	}
 	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	if x == nil {
+		return nil
+	}
 	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
 }
 
@@ -28,11 +31,18 @@ func FromECDSAPub(pub *ecdsa.PublicKey) []byte {
 	return elliptic.Marshal(elliptic.P256(), pub.X, pub.Y)
 }
 
+//Signer signs a hash with a private key. Nodes use Sign; simulations replace it
+//with a deterministic Signer.
+type Signer func(priv *ecdsa.PrivateKey, hash []byte) (r, s *big.Int, err error)
+
 func Sign(priv *ecdsa.PrivateKey, hash []byte) (r, s *big.Int, err error) {
 	return ecdsa.Sign(rand.Reader, priv, hash)
 }
 
 func Verify(pub *ecdsa.PublicKey, hash []byte, r, s *big.Int) bool {
+	if pub == nil || pub.X == nil || pub.Y == nil || r == nil || s == nil {
+		return false
+	}
 	return ecdsa.Verify(pub, hash, r, s)
 }
 
@@ -45,7 +55,10 @@ func DecodeSignature(sig string) (r, s *big.Int, err error) {
 	if len(values) != 2 {
 		return r, s, fmt.Errorf("wrong number of values in signature: got %d, want 2", len(values))
 	}
-	r, _ = new(big.Int).SetString(values[0], 36)
-	s, _ = new(big.Int).SetString(values[1], 36)
+	r, okr := new(big.Int).SetString(values[0], 36)
+	s, oks := new(big.Int).SetString(values[1], 36)
+	if !okr || !oks {
+		return nil, nil, fmt.Errorf("invalid signature values")
+	}
 	return r, s, nil
 }
@@ -1,6 +1,8 @@
 package hashgraph
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -17,6 +19,8 @@ const (
 	topoPrefix        = "topo"
 	blockPrefix       = "block"
 	framePrefix       = "frame"
+	receiptsPrefix    = "receipts"
+	txPrefix          = "tx"
 )
 
 type BadgerStore struct {
@@ -146,6 +150,14 @@ func frameKey(index int) []byte {
 	return []byte(fmt.Sprintf("%s_%09d", framePrefix, index))
 }
 
+func receiptsKey(index int) []byte {
+	return []byte(fmt.Sprintf("%s_%09d", receiptsPrefix, index))
+}
+
+func txKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("%s_%s", txPrefix, txHash))
+}
+
 //==============================================================================
 //Implement the Store interface
 
@@ -303,6 +315,29 @@ func (s *BadgerStore) LastBlockIndex() int {
 	return s.inmemStore.LastBlockIndex()
 }
 
+func (s *BadgerStore) GetReceipt(txHash string) (Receipt, error) {
+	res, err := s.inmemStore.GetReceipt(txHash)
+	if err != nil {
+		res, err = s.dbGetReceipt(txHash)
+	}
+	return res, mapError(err, "Receipt", string(txKey(txHash)))
+}
+
+func (s *BadgerStore) GetBlockReceipts(blockIndex int) ([]Receipt, error) {
+	res, err := s.inmemStore.GetBlockReceipts(blockIndex)
+	if err != nil {
+		res, err = s.dbGetBlockReceipts(blockIndex)
+	}
+	return res, mapError(err, "Receipts", string(receiptsKey(blockIndex)))
+}
+
+func (s *BadgerStore) SetReceipts(blockIndex int, receipts []Receipt) error {
+	if err := s.inmemStore.SetReceipts(blockIndex, receipts); err != nil {
+		return err
+	}
+	return s.dbSetReceipts(blockIndex, receipts)
+}
+
 func (s *BadgerStore) GetFrame(rr int) (Frame, error) {
 	res, err := s.inmemStore.GetFrame(rr)
 	if err != nil {
@@ -355,12 +390,7 @@ func (s *BadgerStore) dbGetEvent(key string) (Event, error) {
 		return Event{}, err
 	}
 
-	event := new(Event)
-	if err := event.Unmarshal(eventBytes); err != nil {
-		return Event{}, err
-	}
-
-	return *event, nil
+	return decodeDBEvent(eventBytes)
 }
 
 func (s *BadgerStore) dbSetEvents(events []Event) error {
@@ -369,7 +399,8 @@ func (s *BadgerStore) dbSetEvents(events []Event) error {
 
 	for _, event := range events {
 		eventHex := event.Hex()
-		val, err := event.Marshal()
+		dbEv := newDBEvent(event)
+		val, err := dbEv.Marshal()
 		if err != nil {
 			return err
 		}
@@ -422,11 +453,11 @@ func (s *BadgerStore) dbTopologicalEvents() ([]Event, error) {
 				return err
 			}
 
-			event := new(Event)
-			if err := event.Unmarshal(eventBytes); err != nil {
+			event, err := decodeDBEvent(eventBytes)
+			if err != nil {
 				return err
 			}
-			res = append(res, *event)
+			res = append(res, event)
 
 			t++
 			key = topologicalEventKey(t)
@@ -650,6 +681,82 @@ func (s *BadgerStore) dbSetBlock(block Block) error {
 	return tx.Commit(nil)
 }
 
+func (s *BadgerStore) dbGetReceipt(txHash string) (Receipt, error) {
+	var receiptBytes []byte
+	key := txKey(txHash)
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		receiptBytes, err = item.Value()
+		return err
+	})
+
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	receipt := new(Receipt)
+	if err := receipt.Unmarshal(receiptBytes); err != nil {
+		return Receipt{}, err
+	}
+
+	return *receipt, nil
+}
+
+func (s *BadgerStore) dbGetBlockReceipts(blockIndex int) ([]Receipt, error) {
+	var receiptsBytes []byte
+	key := receiptsKey(blockIndex)
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		receiptsBytes, err = item.Value()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := []Receipt{}
+	if err := json.Unmarshal(receiptsBytes, &receipts); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+func (s *BadgerStore) dbSetReceipts(blockIndex int, receipts []Receipt) error {
+	tx := s.db.NewTransaction(true)
+	defer tx.Discard()
+
+	val, err := json.Marshal(receipts)
+	if err != nil {
+		return err
+	}
+
+	//insert [index] => [receipts bytes]
+	if err := tx.Set(receiptsKey(blockIndex), val); err != nil {
+		return err
+	}
+
+	//insert [tx hash] => [receipt bytes]
+	for _, r := range receipts {
+		val, err := r.Marshal()
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(txKey(r.TxHash), val); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(nil)
+}
+
 func (s *BadgerStore) dbGetFrame(index int) (Frame, error) {
 	var frameBytes []byte
 	key := frameKey(index)
@@ -692,6 +799,130 @@ func (s *BadgerStore) dbSetFrame(frame Frame) error {
 	return tx.Commit(nil)
 }
 
+//++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+//DB Event
+
+//dbEvent is the representation of an Event in the database. The regular JSON
+//encoding of an Event only contains its Body and Signature, but we also need to
+//persist the Round, LamportTimestamp, and coordinates that were computed when
+//the Event was inserted; otherwise an Event evicted from the cache could not be
+//reloaded without recomputing them.
+//
+//Databases written before dbEvent was introduced contain the regular encoding
+//of Events, which has no Version. decodeDBEvent still reads them, without the
+//computed fields, and Bootstrap, which inserts all the Events again, rewrites
+//them in the current format.
+type dbEvent struct {
+	Version          int
+	Event            Event
+	TopologicalIndex int
+	Round            *int
+	LamportTimestamp *int
+	RoundReceived    *int
+	LastAncestors    []dbIndex
+	FirstDescendants []dbIndex
+}
+
+//dbEventVersion is the Version of the current dbEvent format
+const dbEventVersion = 1
+
+type dbIndex struct {
+	ParticipantID int
+	Hash          string
+	Index         int
+}
+
+func newDBEvent(event Event) dbEvent {
+	toDBIndexes := func(o OrderedEventCoordinates) []dbIndex {
+		res := make([]dbIndex, len(o))
+		for i, idx := range o {
+			res[i] = dbIndex{
+				ParticipantID: idx.participantId,
+				Hash:          idx.event.hash,
+				Index:         idx.event.index,
+			}
+		}
+		return res
+	}
+
+	return dbEvent{
+		Version:          dbEventVersion,
+		Event:            event,
+		TopologicalIndex: event.topologicalIndex,
+		Round:            event.round,
+		LamportTimestamp: event.lamportTimestamp,
+		RoundReceived:    event.roundReceived,
+		LastAncestors:    toDBIndexes(event.lastAncestors),
+		FirstDescendants: toDBIndexes(event.firstDescendants),
+	}
+}
+
+func (dbe *dbEvent) toEvent() Event {
+	fromDBIndexes := func(d []dbIndex) OrderedEventCoordinates {
+		if len(d) == 0 {
+			return nil
+		}
+		res := make(OrderedEventCoordinates, len(d))
+		for i, idx := range d {
+			res[i] = Index{
+				participantId: idx.ParticipantID,
+				event: EventCoordinates{
+					hash:  idx.Hash,
+					index: idx.Index,
+				},
+			}
+		}
+		return res
+	}
+
+	event := dbe.Event
+	event.topologicalIndex = dbe.TopologicalIndex
+	event.round = dbe.Round
+	event.lamportTimestamp = dbe.LamportTimestamp
+	event.roundReceived = dbe.RoundReceived
+	event.lastAncestors = fromDBIndexes(dbe.LastAncestors)
+	event.firstDescendants = fromDBIndexes(dbe.FirstDescendants)
+
+	return event
+}
+
+//decodeDBEvent decodes an Event stored in the database, in the current format
+//or in the legacy encoding of Events
+func decodeDBEvent(data []byte) (Event, error) {
+	dbEv := new(dbEvent)
+	if err := dbEv.Unmarshal(data); err != nil {
+		return Event{}, err
+	}
+
+	switch dbEv.Version {
+	case dbEventVersion:
+		return dbEv.toEvent(), nil
+	case 0:
+		event := new(Event)
+		if err := event.Unmarshal(data); err != nil {
+			return Event{}, err
+		}
+		return *event, nil
+	default:
+		return Event{}, fmt.Errorf("Unknown Event format version %d", dbEv.Version)
+	}
+}
+
+func (dbe *dbEvent) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if err := enc.Encode(dbe); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (dbe *dbEvent) Unmarshal(data []byte) error {
+	b := bytes.NewBuffer(data)
+	dec := json.NewDecoder(b) //will read from b
+	return dec.Decode(dbe)
+}
+
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func isDBKeyNotFound(err error) bool {
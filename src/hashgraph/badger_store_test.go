@@ -169,6 +169,9 @@ func TestDBEventMethods(t *testing.T) {
 				k)
 			event.Sign(p.privKey)
 			event.topologicalIndex = topologicalIndex
+			event.SetRound(k)
+			event.SetLamportTimestamp(topologicalIndex)
+			event.lastAncestors.Add(p.id, EventCoordinates{hash: event.Hex(), index: k})
 			topologicalIndex++
 			topologicalEvents = append(topologicalEvents, event)
 
@@ -197,6 +200,15 @@ func TestDBEventMethods(t *testing.T) {
 			if ver, err := rev.Verify(); err != nil && !ver {
 				t.Fatalf("failed to verify signature. err: %s", err)
 			}
+			if !reflect.DeepEqual(ev.round, rev.round) {
+				t.Fatalf("events[%s][%d].round should be %v, not %v", p, k, *ev.round, rev.round)
+			}
+			if !reflect.DeepEqual(ev.lamportTimestamp, rev.lamportTimestamp) {
+				t.Fatalf("events[%s][%d].lamportTimestamp should be %v, not %v", p, k, *ev.lamportTimestamp, rev.lamportTimestamp)
+			}
+			if !reflect.DeepEqual(ev.lastAncestors, rev.lastAncestors) {
+				t.Fatalf("events[%s][%d].lastAncestors should be %#v, not %#v", p, k, ev.lastAncestors, rev.lastAncestors)
+			}
 		}
 	}
 
@@ -254,6 +266,57 @@ func TestDBEventMethods(t *testing.T) {
 	}
 }
 
+func TestDBLegacyEvent(t *testing.T) {
+	store, participants := initBadgerStore(0, t)
+	defer removeBadgerStore(store, t)
+
+	p := participants[0]
+	event := NewEvent([][]byte{[]byte("legacy")}, nil, []string{"", ""}, p.pubKey, 0)
+	event.Sign(p.privKey)
+
+	//write the Event in the encoding used before dbEvent
+	val, err := event.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := store.db.NewTransaction(true)
+	defer tx.Discard()
+	if err := tx.Set([]byte(event.Hex()), val); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rev, err := store.dbGetEvent(event.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(event.Body, rev.Body) {
+		t.Fatalf("Body should be %#v, not %#v", event.Body, rev.Body)
+	}
+	if ver, err := rev.Verify(); err != nil || !ver {
+		t.Fatalf("failed to verify signature. err: %v", err)
+	}
+
+	if rev.round != nil {
+		t.Fatalf("legacy Events have no round")
+	}
+
+	//rewriting the Event upgrades it to the current format
+	rev.SetRound(3)
+	if err := store.dbSetEvents([]Event{rev}); err != nil {
+		t.Fatal(err)
+	}
+	rev, err = store.dbGetEvent(event.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev.round == nil || *rev.round != 3 {
+		t.Fatalf("round should be 3, not %v", rev.round)
+	}
+}
+
 func TestDBRoundMethods(t *testing.T) {
 	cacheSize := 0
 	store, participants := initBadgerStore(cacheSize, t)
@@ -433,6 +496,61 @@ func TestDBFrameMethods(t *testing.T) {
 	})
 }
 
+func TestDBReceiptMethods(t *testing.T) {
+	cacheSize := 0
+	store, _ := initBadgerStore(cacheSize, t)
+	defer removeBadgerStore(store, t)
+
+	block := NewBlock(3, 1, []byte{}, [][]byte{[]byte("tx 1"), []byte("tx 2")})
+	receipts, err := NewReceipts(block, []TxResult{
+		TxResult{Code: TxCodeOK},
+		TxResult{
+			Code:   5,
+			Log:    "insufficient funds",
+			Events: []TxEvent{TxEvent{Type: "transfer", Attributes: map[string]string{"to": "alice"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Store Receipts", func(t *testing.T) {
+		if err := store.dbSetReceipts(block.Index(), receipts); err != nil {
+			t.Fatal(err)
+		}
+
+		storedReceipts, err := store.dbGetBlockReceipts(block.Index())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(storedReceipts, receipts) {
+			t.Fatalf("Receipts and StoredReceipts do not match")
+		}
+	})
+
+	t.Run("Get Receipt by TxHash", func(t *testing.T) {
+		receipt, err := store.dbGetReceipt(TxHash([]byte("tx 2")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(receipt, receipts[1]) {
+			t.Fatalf("Receipt should be %v, not %v", receipts[1], receipt)
+		}
+
+		if receipt.Result.OK() {
+			t.Fatalf("Receipt should not be OK")
+		}
+	})
+
+	t.Run("Wrong number of results", func(t *testing.T) {
+		if _, err := NewReceipts(block, []TxResult{TxResult{}}); err == nil {
+			t.Fatalf("NewReceipts should fail")
+		}
+	})
+}
+
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 //Check that the wrapper methods work
 //These methods use the inmemStore as a cache on top of the DB
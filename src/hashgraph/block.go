@@ -213,12 +213,17 @@ func (b *Block) Hex() string {
 }
 
 func (b *Block) Sign(privKey *ecdsa.PrivateKey) (bs BlockSignature, err error) {
+	return b.SignWith(privKey, crypto.Sign)
+}
+
+//SignWith signs the Block with another Signer than crypto.Sign
+func (b *Block) SignWith(privKey *ecdsa.PrivateKey, sign crypto.Signer) (bs BlockSignature, err error) {
 
 	signBytes, err := b.Body.Hash()
 	if err != nil {
 		return bs, err
 	}
-	R, S, err := crypto.Sign(privKey, signBytes)
+	R, S, err := sign(privKey, signBytes)
 	if err != nil {
 		return bs, err
 	}
@@ -179,11 +179,16 @@ func (e *Event) IsLoaded() bool {
 
 //ecdsa sig
 func (e *Event) Sign(privKey *ecdsa.PrivateKey) error {
+	return e.SignWith(privKey, crypto.Sign)
+}
+
+//SignWith signs the Event with another Signer than crypto.Sign
+func (e *Event) SignWith(privKey *ecdsa.PrivateKey, sign crypto.Signer) error {
 	signBytes, err := e.Body.Hash()
 	if err != nil {
 		return err
 	}
-	R, S, err := crypto.Sign(privKey, signBytes)
+	R, S, err := sign(privKey, signBytes)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,234 @@
+package hashgraph
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/peers"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+The fuzz targets below feed arbitrary data, as it would be received from a
+peer, to the functions that decode and insert Events. They must never panic.
+Run them with:
+
+	go test -run XXX -fuzz FuzzReadWireInfo ./src/hashgraph
+*/
+
+//fuzzKey derives a fixed ECDSA key, so that participant IDs and Event hashes
+//are the same in every fuzzing process and the corpus remains meaningful.
+func fuzzKey(i int) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(crypto.SHA256([]byte(fmt.Sprintf("fuzz%d", i))))
+	d.Mod(d, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return key
+}
+
+type fuzzFixture struct {
+	nodes         []TestNode
+	index         map[string]string
+	orderedEvents *[]Event
+	participants  *peers.Peers
+}
+
+//newFuzzFixture creates the Events of initRoundHashgraph with fixed keys
+func newFuzzFixture() *fuzzFixture {
+	participants := peers.NewPeers()
+	keys := map[string]*ecdsa.PrivateKey{}
+	for i := 0; i < n; i++ {
+		key := fuzzKey(i)
+		pubHex := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&key.PublicKey))
+		participants.AddPeer(peers.NewPeer(pubHex, ""))
+		keys[pubHex] = key
+	}
+
+	fx := &fuzzFixture{
+		index:         make(map[string]string),
+		orderedEvents: &[]Event{},
+		participants:  participants,
+	}
+	for i, peer := range participants.ToPeerSlice() {
+		fx.nodes = append(fx.nodes, NewTestNode(keys[peer.PubKeyHex], i))
+	}
+
+	for i, peer := range participants.ToPeerSlice() {
+		event := NewEvent(nil, nil, []string{rootSelfParent(peer.ID), ""}, fx.nodes[i].Pub, 0)
+		fx.nodes[i].signAndAddEvent(event, fmt.Sprintf("e%d", i), fx.index, fx.orderedEvents)
+	}
+
+	playEvents([]play{
+		play{1, 1, "e1", "e0", "e10", nil, nil},
+		play{2, 1, "e2", "", "s20", nil, nil},
+		play{0, 1, "e0", "", "s00", nil, nil},
+		play{2, 2, "s20", "e10", "e21", nil, nil},
+		play{0, 2, "s00", "e21", "e02", nil, nil},
+		play{1, 2, "e10", "", "s10", nil, nil},
+		play{1, 3, "s10", "e02", "f1", nil, nil},
+		play{1, 4, "f1", "", "s11", [][]byte{[]byte("abc")}, nil},
+	}, fx.nodes, fx.index, fx.orderedEvents)
+
+	return fx
+}
+
+//hashgraph returns a fresh Hashgraph containing the fixture's Events
+func (fx *fuzzFixture) hashgraph() *Hashgraph {
+	return createHashgraph(false, fx.orderedEvents, fx.participants, fuzzLogger())
+}
+
+//fuzzLogger does not log anything, which would slow down fuzzing
+func fuzzLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.Level = logrus.PanicLevel
+	return logger.WithField("id", "fuzz")
+}
+
+//next returns a valid Event that could be inserted on top of the fixture
+func (fx *fuzzFixture) next() Event {
+	ev := NewEvent([][]byte{[]byte("tx")},
+		nil,
+		[]string{fx.index["s11"], fx.index["e21"]},
+		fx.nodes[1].Pub,
+		5)
+	ev.Sign(fx.nodes[1].Key)
+	return ev
+}
+
+func FuzzReadWireInfo(f *testing.F) {
+	fx := newFuzzFixture()
+	h := fx.hashgraph()
+
+	for _, ev := range *fx.orderedEvents {
+		e, err := h.Store.GetEvent(ev.Hex())
+		if err != nil {
+			f.Fatal(err)
+		}
+		data, _ := json.Marshal(e.ToWire())
+		f.Add(data)
+	}
+
+	next := fx.next()
+	if err := h.setWireInfo(&next); err != nil {
+		f.Fatal(err)
+	}
+	data, _ := json.Marshal(next.ToWire())
+	f.Add(data)
+	f.Add([]byte(`{"Body":{"CreatorID":42,"SelfParentIndex":-1,"OtherParentIndex":-1}}`))
+	f.Add([]byte(`{"Body":{"CreatorID":0,"SelfParentIndex":0,"OtherParentCreatorID":7,"OtherParentIndex":3}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var we WireEvent
+		if err := json.Unmarshal(data, &we); err != nil {
+			return
+		}
+
+		h := fx.hashgraph()
+		ev, err := h.ReadWireInfo(we)
+		if err != nil {
+			return
+		}
+		h.InsertEvent(*ev, false)
+	})
+}
+
+func FuzzInsertEvent(f *testing.F) {
+	fx := newFuzzFixture()
+
+	for _, ev := range *fx.orderedEvents {
+		data, _ := ev.Marshal()
+		f.Add(data, false)
+	}
+	next := fx.next()
+	data, _ := next.Marshal()
+	f.Add(data, false)
+	f.Add(data, true)
+	f.Add([]byte(`{"Body":{"Parents":["a"],"Creator":"AQID","Index":1},"Signature":"1|1"}`), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, resign bool) {
+		var ev Event
+		if err := ev.Unmarshal(data); err != nil {
+			return
+		}
+
+		//Go past the signature check when the creator is a participant
+		if resign {
+			for _, node := range fx.nodes {
+				if string(node.Pub) == string(ev.Body.Creator) {
+					ev.Sign(node.Key)
+				}
+			}
+		}
+
+		h := fx.hashgraph()
+		if err := h.InsertEvent(ev, true); err != nil {
+			return
+		}
+		h.DivideRounds()
+		h.DecideFame()
+		h.DecideRoundReceived()
+		h.ProcessDecidedRounds()
+	})
+}
+
+func FuzzRootUnmarshal(f *testing.F) {
+	root := NewBaseRoot(1)
+	root.Others["x"] = RootEvent{Hash: "y", CreatorID: 2, Index: 3}
+	data, _ := root.Marshal()
+	f.Add(data)
+	f.Add([]byte(`{"SelfParent":null,"Others":{"":{}}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var root Root
+		if err := root.Unmarshal(data); err != nil {
+			return
+		}
+		if _, err := root.Marshal(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzFrameUnmarshal(f *testing.F) {
+	fx := newFuzzFixture()
+
+	frame := Frame{Round: 1}
+	for range fx.participants.ToPeerSlice() {
+		frame.Roots = append(frame.Roots, NewBaseRoot(0))
+	}
+	for _, ev := range *fx.orderedEvents {
+		frame.Events = append(frame.Events, ev)
+	}
+	data, _ := frame.Marshal()
+	f.Add(data)
+	f.Add([]byte(`{"Round":0,"Roots":[{},{},{},{},{}],"Events":[{"Body":{}}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var frame Frame
+		if err := frame.Unmarshal(data); err != nil {
+			return
+		}
+		hash, err := frame.Hash()
+		if err != nil {
+			return
+		}
+
+		//A Frame that made it through FastForward is used to Reset the
+		//Hashgraph
+		h := NewHashgraph(fx.participants,
+			NewInmemStore(fx.participants, cacheSize),
+			nil,
+			fuzzLogger())
+		block := NewBlock(0, frame.Round, hash, nil)
+		h.Reset(block, frame)
+	})
+}
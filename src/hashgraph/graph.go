@@ -0,0 +1,252 @@
+package hashgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	cm "github.com/mosaicnetworks/babble/src/common"
+)
+
+//GraphEvent is the exported view of an Event and of its consensus metadata, as
+//used by visualisation tools.
+type GraphEvent struct {
+	Hash             string
+	Creator          string
+	Index            int
+	SelfParent       string
+	OtherParent      string
+	Transactions     int
+	Round            int
+	Witness          bool
+	Famous           string
+	RoundReceived    *int
+	LamportTimestamp int
+}
+
+//Graph is a portion of the hashgraph, limited to the Events whose Round is
+//comprised between FromRound and ToRound (inclusive).
+type Graph struct {
+	FromRound int
+	ToRound   int
+	Events    []GraphEvent
+}
+
+//NewGraph extracts the Events of rounds [fromRound, toRound] from a Store. If
+//toRound is negative, it keeps going until it reaches a Round that is not in
+//the Store. toRound is capped to the last Round of the Store, when the Store
+//knows it. Rounds that are missing from the Store (because they were pruned,
+//or precede a Reset) are skipped.
+func NewGraph(store Store, fromRound, toRound int) (*Graph, error) {
+	if fromRound < 0 {
+		fromRound = 0
+	}
+	if last := store.LastRound(); last >= 0 && toRound > last {
+		toRound = last
+	}
+
+	graph := &Graph{
+		FromRound: fromRound,
+		ToRound:   toRound,
+		Events:    []GraphEvent{},
+	}
+
+	rounds := make(map[int]RoundInfo)
+	seen := make(map[string]bool)
+
+	for r := fromRound; toRound < 0 || r <= toRound; r++ {
+		roundInfo, err := store.GetRound(r)
+		if err != nil {
+			if !cm.Is(err, cm.KeyNotFound) {
+				return nil, err
+			}
+			if toRound < 0 {
+				graph.ToRound = r - 1
+				break
+			}
+			continue
+		}
+		rounds[r] = roundInfo
+
+		//RoundInfo.Events also contains the Events that were received in this
+		//round, so we filter on the Event's own round below.
+		hashes := make([]string, 0, len(roundInfo.Events))
+		for x := range roundInfo.Events {
+			hashes = append(hashes, x)
+		}
+		sort.Strings(hashes)
+
+		for _, x := range hashes {
+			if seen[x] {
+				continue
+			}
+
+			ev, err := store.GetEvent(x)
+			if err != nil {
+				return nil, err
+			}
+
+			if ev.round == nil ||
+				*ev.round < fromRound ||
+				(toRound >= 0 && *ev.round > toRound) {
+				continue
+			}
+			seen[x] = true
+
+			graph.Events = append(graph.Events, newGraphEvent(ev))
+		}
+	}
+
+	//Fame and witness flags are stored in the RoundInfo of the Event's Round,
+	//which may only have been loaded after the Event was seen.
+	for i, ge := range graph.Events {
+		roundInfo, ok := rounds[ge.Round]
+		if !ok {
+			continue
+		}
+		if re, ok := roundInfo.Events[ge.Hash]; ok {
+			graph.Events[i].Witness = re.Witness
+			if re.Witness {
+				graph.Events[i].Famous = re.Famous.String()
+			}
+		}
+	}
+
+	sort.Sort(byGraphOrder(graph.Events))
+
+	return graph, nil
+}
+
+func newGraphEvent(ev Event) GraphEvent {
+	ge := GraphEvent{
+		Hash:         ev.Hex(),
+		Creator:      ev.Creator(),
+		Index:        ev.Index(),
+		SelfParent:   ev.SelfParent(),
+		OtherParent:  ev.OtherParent(),
+		Transactions: len(ev.Transactions()),
+	}
+	if ev.round != nil {
+		ge.Round = *ev.round
+	}
+	if ev.lamportTimestamp != nil {
+		ge.LamportTimestamp = *ev.lamportTimestamp
+	}
+	if ev.roundReceived != nil {
+		rr := *ev.roundReceived
+		ge.RoundReceived = &rr
+	}
+	return ge
+}
+
+//Marshal returns the JSON encoding of the Graph
+func (g *Graph) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+//WriteDOT writes the Graph in Graphviz DOT format. Each participant gets its
+//own column, Events are labelled with their Round and Lamport timestamp, and
+//witnesses are highlighted according to their fame.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b bytes.Buffer
+
+	creators := []string{}
+	byCreator := make(map[string][]GraphEvent)
+	inGraph := make(map[string]bool)
+	for _, ge := range g.Events {
+		if _, ok := byCreator[ge.Creator]; !ok {
+			creators = append(creators, ge.Creator)
+		}
+		byCreator[ge.Creator] = append(byCreator[ge.Creator], ge)
+		inGraph[ge.Hash] = true
+	}
+	sort.Strings(creators)
+
+	fmt.Fprintf(&b, "digraph hashgraph {\n")
+	fmt.Fprintf(&b, "\trankdir=BT;\n")
+	fmt.Fprintf(&b, "\tlabel=\"rounds %d to %d\";\n", g.FromRound, g.ToRound)
+	fmt.Fprintf(&b, "\tnode [shape=circle, style=filled, fillcolor=white];\n")
+
+	for i, c := range creators {
+		fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "\t\tlabel=%q;\n", shortHash(c))
+		fmt.Fprintf(&b, "\t\tstyle=invis;\n")
+		for _, ge := range byCreator[c] {
+			fmt.Fprintf(&b, "\t\t%q [label=%q%s];\n",
+				ge.Hash,
+				ge.dotLabel(),
+				ge.dotStyle())
+		}
+		fmt.Fprintf(&b, "\t}\n")
+	}
+
+	for _, ge := range g.Events {
+		if inGraph[ge.SelfParent] {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", ge.Hash, ge.SelfParent)
+		}
+		if inGraph[ge.OtherParent] {
+			fmt.Fprintf(&b, "\t%q -> %q [style=dashed];\n", ge.Hash, ge.OtherParent)
+		}
+	}
+
+	fmt.Fprintf(&b, "}\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (ge GraphEvent) dotLabel() string {
+	rr := "-"
+	if ge.RoundReceived != nil {
+		rr = fmt.Sprintf("%d", *ge.RoundReceived)
+	}
+	return fmt.Sprintf("%d\nr%d rr%s\nlt%d",
+		ge.Index,
+		ge.Round,
+		rr,
+		ge.LamportTimestamp)
+}
+
+func (ge GraphEvent) dotStyle() string {
+	if !ge.Witness {
+		return ""
+	}
+	switch ge.Famous {
+	case True.String():
+		return ", shape=doublecircle, fillcolor=gold"
+	case False.String():
+		return ", shape=doublecircle, fillcolor=gray"
+	default:
+		return ", shape=doublecircle, fillcolor=lightblue"
+	}
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+//byGraphOrder sorts GraphEvents by Lamport timestamp, then by creator and
+//index, so that the output is deterministic.
+type byGraphOrder []GraphEvent
+
+func (a byGraphOrder) Len() int      { return len(a) }
+func (a byGraphOrder) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byGraphOrder) Less(i, j int) bool {
+	if a[i].LamportTimestamp != a[j].LamportTimestamp {
+		return a[i].LamportTimestamp < a[j].LamportTimestamp
+	}
+	if a[i].Creator != a[j].Creator {
+		return a[i].Creator < a[j].Creator
+	}
+	return a[i].Index < a[j].Index
+}
@@ -0,0 +1,76 @@
+package hashgraph
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	h, index := initRoundHashgraph(t)
+
+	if err := h.DivideRounds(); err != nil {
+		t.Fatal(err)
+	}
+
+	graph, err := NewGraph(h.Store, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if graph.ToRound != 1 {
+		t.Fatalf("ToRound should be 1, not %d", graph.ToRound)
+	}
+
+	if l := len(graph.Events); l != len(index) {
+		t.Fatalf("Graph should contain %d Events, not %d", len(index), l)
+	}
+
+	byHash := make(map[string]GraphEvent)
+	for _, ge := range graph.Events {
+		byHash[ge.Hash] = ge
+	}
+
+	expectedWitnesses := map[string]int{"e0": 0, "e1": 0, "e2": 0, "f1": 1}
+	for name, hash := range index {
+		ge, ok := byHash[hash]
+		if !ok {
+			t.Fatalf("Graph should contain %s", name)
+		}
+		r, isWitness := expectedWitnesses[name]
+		if ge.Witness != isWitness {
+			t.Fatalf("%s.Witness should be %v", name, isWitness)
+		}
+		if isWitness && ge.Round != r {
+			t.Fatalf("%s.Round should be %d, not %d", name, r, ge.Round)
+		}
+		if isWitness && ge.Famous != Undefined.String() {
+			t.Fatalf("%s.Famous should be Undefined, not %s", name, ge.Famous)
+		}
+	}
+
+	//only round 1
+	graph1, err := NewGraph(h.Store, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(graph1.Events); l != 2 {
+		t.Fatalf("Round 1 should contain 2 Events, not %d", l)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	out := dot.String()
+	if !strings.HasPrefix(out, "digraph hashgraph {") {
+		t.Fatalf("DOT output should start with digraph declaration: %s", out)
+	}
+	if !strings.Contains(out, fmt.Sprintf("%q -> %q;", index["e10"], index["e1"])) {
+		t.Fatalf("DOT output should contain self-parent edge e10 -> e1")
+	}
+	if !strings.Contains(out, fmt.Sprintf("%q -> %q [style=dashed];", index["e10"], index["e0"])) {
+		t.Fatalf("DOT output should contain other-parent edge e10 -> e0")
+	}
+}
@@ -14,6 +14,10 @@ import (
 	"github.com/mosaicnetworks/babble/src/peers"
 )
 
+//ErrInvalidSignature is returned by InsertEvent when an Event is not signed by
+//its creator
+var ErrInvalidSignature = errors.New("Invalid Event signature")
+
 //Hashgraph is a DAG of Events. It also contains methods to extract a consensus
 //order of Events and map them onto a blockchain.
 type Hashgraph struct {
@@ -33,12 +37,6 @@ type Hashgraph struct {
 	superMajority           int
 	trustCount              int
 
-	ancestorCache     *common.LRU
-	selfAncestorCache *common.LRU
-	stronglySeeCache  *common.LRU
-	roundCache        *common.LRU
-	timestampCache    *common.LRU
-
 	logger *logrus.Entry
 }
 
@@ -54,19 +52,13 @@ func NewHashgraph(participants *peers.Peers, store Store, commitCh chan Block, l
 	superMajority := 2*participants.Len()/3 + 1
 	trustCount := int(math.Ceil(float64(participants.Len()) / float64(3)))
 
-	cacheSize := store.CacheSize()
 	hashgraph := Hashgraph{
-		Participants:      participants,
-		Store:             store,
-		commitCh:          commitCh,
-		ancestorCache:     common.NewLRU(cacheSize, nil),
-		selfAncestorCache: common.NewLRU(cacheSize, nil),
-		stronglySeeCache:  common.NewLRU(cacheSize, nil),
-		roundCache:        common.NewLRU(cacheSize, nil),
-		timestampCache:    common.NewLRU(cacheSize, nil),
-		logger:            logger,
-		superMajority:     superMajority,
-		trustCount:        trustCount,
+		Participants:  participants,
+		Store:         store,
+		commitCh:      commitCh,
+		logger:        logger,
+		superMajority: superMajority,
+		trustCount:    trustCount,
 	}
 
 	return &hashgraph
@@ -76,20 +68,10 @@ func NewHashgraph(participants *peers.Peers, store Store, commitCh chan Block, l
 Private Methods
 *******************************************************************************/
 
-//true if y is an ancestor of x
+//true if y is an ancestor of x. Like the other relations below, it compares the
+//coordinates computed when the Events were inserted, so it is cheap enough not
+//to be cached.
 func (h *Hashgraph) ancestor(x, y string) (bool, error) {
-	if c, ok := h.ancestorCache.Get(Key{x, y}); ok {
-		return c.(bool), nil
-	}
-	a, err := h._ancestor(x, y)
-	if err != nil {
-		return false, err
-	}
-	h.ancestorCache.Add(Key{x, y}, a)
-	return a, nil
-}
-
-func (h *Hashgraph) _ancestor(x, y string) (bool, error) {
 	if x == y {
 		return true, nil
 	}
@@ -118,18 +100,6 @@ func (h *Hashgraph) _ancestor(x, y string) (bool, error) {
 
 //true if y is a self-ancestor of x
 func (h *Hashgraph) selfAncestor(x, y string) (bool, error) {
-	if c, ok := h.selfAncestorCache.Get(Key{x, y}); ok {
-		return c.(bool), nil
-	}
-	a, err := h._selfAncestor(x, y)
-	if err != nil {
-		return false, err
-	}
-	h.selfAncestorCache.Add(Key{x, y}, a)
-	return a, nil
-}
-
-func (h *Hashgraph) _selfAncestor(x, y string) (bool, error) {
 	if x == y {
 		return true, nil
 	}
@@ -158,24 +128,15 @@ func (h *Hashgraph) see(x, y string) (bool, error) {
 
 //true if x strongly sees y
 func (h *Hashgraph) stronglySee(x, y string) (bool, error) {
-	if c, ok := h.stronglySeeCache.Get(Key{x, y}); ok {
-		return c.(bool), nil
-	}
-	ss, err := h._stronglySee(x, y)
-	if err != nil {
-		return false, err
-	}
-	h.stronglySeeCache.Add(Key{x, y}, ss)
-	return ss, nil
-}
-
-func (h *Hashgraph) _stronglySee(x, y string) (bool, error) {
-
 	ex, err := h.Store.GetEvent(x)
 	if err != nil {
 		return false, err
 	}
+	return h.eventStronglySees(ex, y)
+}
 
+//true if ex strongly sees y. ex does not need to be in the Store yet.
+func (h *Hashgraph) eventStronglySees(ex Event, y string) (bool, error) {
 	ey, err := h.Store.GetEvent(y)
 	if err != nil {
 		return false, err
@@ -190,20 +151,9 @@ func (h *Hashgraph) _stronglySee(x, y string) (bool, error) {
 	return c >= h.superMajority, nil
 }
 
+//round returns the Round of x. Rounds are computed once and for all when Events
+//are inserted, so this is a simple lookup.
 func (h *Hashgraph) round(x string) (int, error) {
-	if c, ok := h.roundCache.Get(x); ok {
-		return c.(int), nil
-	}
-	r, err := h._round(x)
-	if err != nil {
-		return -1, err
-	}
-	h.roundCache.Add(x, r)
-	return r, nil
-}
-
-func (h *Hashgraph) _round(x string) (int, error) {
-
 	/*
 		x is the Root
 		Use Root.SelfParent.Round
@@ -218,6 +168,17 @@ func (h *Hashgraph) _round(x string) (int, error) {
 		return math.MinInt32, err
 	}
 
+	if ex.round == nil {
+		return math.MinInt32, fmt.Errorf("Event %s has no Round", x)
+	}
+
+	return *ex.round, nil
+}
+
+//computeRound computes the Round of an Event from the Rounds of its parents,
+//which must already be known. It relies on the Event's coordinates, and those
+//of the previous Round's witnesses, being up to date.
+func (h *Hashgraph) computeRound(ex Event) (int, error) {
 	root, err := h.Store.GetRoot(ex.Creator())
 	if err != nil {
 		return math.MinInt32, err
@@ -262,7 +223,7 @@ func (h *Hashgraph) _round(x string) (int, error) {
 
 	c := 0
 	for _, w := range h.Store.RoundWitnesses(parentRound) {
-		ss, err := h.stronglySee(x, w)
+		ss, err := h.eventStronglySees(ex, w)
 		if err != nil {
 			return math.MinInt32, err
 		}
@@ -310,19 +271,9 @@ func (h *Hashgraph) roundReceived(x string) (int, error) {
 	return res, nil
 }
 
+//lamportTimestamp returns the LamportTimestamp of x. Like Rounds, Lamport
+//timestamps are computed when Events are inserted.
 func (h *Hashgraph) lamportTimestamp(x string) (int, error) {
-	if c, ok := h.timestampCache.Get(x); ok {
-		return c.(int), nil
-	}
-	r, err := h._lamportTimestamp(x)
-	if err != nil {
-		return -1, err
-	}
-	h.timestampCache.Add(x, r)
-	return r, nil
-}
-
-func (h *Hashgraph) _lamportTimestamp(x string) (int, error) {
 	/*
 		x is the Root
 		User Root.SelfParent.LamportTimestamp
@@ -337,6 +288,16 @@ func (h *Hashgraph) _lamportTimestamp(x string) (int, error) {
 		return math.MinInt32, err
 	}
 
+	if ex.lamportTimestamp == nil {
+		return math.MinInt32, fmt.Errorf("Event %s has no LamportTimestamp", x)
+	}
+
+	return *ex.lamportTimestamp, nil
+}
+
+//computeLamportTimestamp computes the LamportTimestamp of an Event from the
+//timestamps of its parents, which must already be known.
+func (h *Hashgraph) computeLamportTimestamp(ex Event) (int, error) {
 	//We are going to need the Root later
 	root, err := h.Store.GetRoot(ex.Creator())
 	if err != nil {
@@ -364,7 +325,7 @@ func (h *Hashgraph) _lamportTimestamp(x string) (int, error) {
 				return math.MinInt32, err
 			}
 			opLT = t
-		} else if other, ok := root.Others[x]; ok && other.Hash == ex.OtherParent() {
+		} else if other, ok := root.Others[ex.Hex()]; ok && other.Hash == ex.OtherParent() {
 			//we do not know the other-parent but it is referenced  in Root.Others
 			//we use the Root's LamportTimestamp
 			opLT = other.LamportTimestamp
@@ -543,6 +504,67 @@ func (h *Hashgraph) updateAncestorFirstDescendant(event Event) error {
 	return nil
 }
 
+//computes the Round and LamportTimestamp of a newly inserted Event, writes it to
+//the Store, flags it as a witness if necessary, and records it in the
+//corresponding RoundInfo. The Event's parents are already known, so this does
+//not recurse.
+func (h *Hashgraph) setRoundAndLamportTimestamp(event *Event) error {
+	hash := event.Hex()
+
+	roundNumber, err := h.computeRound(*event)
+	if err != nil {
+		return err
+	}
+
+	spRound, err := h.round(event.SelfParent())
+	if err != nil {
+		return err
+	}
+	witness := roundNumber > spRound
+
+	lamportTimestamp, err := h.computeLamportTimestamp(*event)
+	if err != nil {
+		return err
+	}
+
+	event.SetRound(roundNumber)
+	event.SetLamportTimestamp(lamportTimestamp)
+
+	if err := h.Store.SetEvent(*event); err != nil {
+		return err
+	}
+
+	roundInfo, err := h.Store.GetRound(roundNumber)
+	if err != nil && !common.Is(err, common.KeyNotFound) {
+		return err
+	}
+	roundInfo.AddEvent(hash, witness)
+
+	//A witness that only shows up once the fame of its Round is decided can
+	//not be famous; the other witnesses were decided without it. If we left it
+	//undecided, the Round would never be considered decided again, and
+	//DecideRoundReceived would get stuck on it.
+	if witness && h.roundDecided(roundNumber) {
+		roundInfo.SetFame(hash, false)
+	}
+
+	return h.Store.SetRound(roundNumber, roundInfo)
+}
+
+//roundDecided returns true if the fame of a Round's witnesses has already been
+//decided, whether the Round has been processed or not.
+func (h *Hashgraph) roundDecided(r int) bool {
+	if h.LastConsensusRound != nil && r <= *h.LastConsensusRound {
+		return true
+	}
+	for _, pr := range h.PendingRounds {
+		if pr.Index == r {
+			return pr.Decided
+		}
+	}
+	return false
+}
+
 func (h *Hashgraph) createSelfParentRootEvent(ev Event) (RootEvent, error) {
 	sp := ev.SelfParent()
 	spLT, err := h.lamportTimestamp(sp)
@@ -694,11 +716,12 @@ func (h *Hashgraph) updatePendingRounds(decidedRounds map[int]int) {
 	}
 }
 
-//Remove processed Signatures from SigPool
+//Remove processed Signatures from SigPool. processedSignatures is indexed by
+//position in the SigPool.
 func (h *Hashgraph) removeProcessedSignatures(processedSignatures map[int]bool) {
 	newSigPool := []BlockSignature{}
-	for _, bs := range h.SigPool {
-		if _, ok := processedSignatures[bs.Index]; !ok {
+	for i, bs := range h.SigPool {
+		if _, ok := processedSignatures[i]; !ok {
 			newSigPool = append(newSigPool, bs)
 		}
 	}
@@ -717,7 +740,7 @@ func (h *Hashgraph) InsertEvent(event Event, setWireInfo bool) error {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("Invalid Event signature")
+		return ErrInvalidSignature
 	}
 
 	if err := h.checkSelfParent(event); err != nil {
@@ -741,14 +764,15 @@ func (h *Hashgraph) InsertEvent(event Event, setWireInfo bool) error {
 		return fmt.Errorf("InitEventCoordinates: %s", err)
 	}
 
-	if err := h.Store.SetEvent(event); err != nil {
-		return fmt.Errorf("SetEvent: %s", err)
-	}
-
 	if err := h.updateAncestorFirstDescendant(event); err != nil {
 		return fmt.Errorf("UpdateAncestorFirstDescendant: %s", err)
 	}
 
+	//the Event is only written once its Round and LamportTimestamp are known
+	if err := h.setRoundAndLamportTimestamp(&event); err != nil {
+		return fmt.Errorf("SetRoundAndLamportTimestamp: %s", err)
+	}
+
 	h.UndeterminedEvents = append(h.UndeterminedEvents, event.Hex())
 
 	if event.IsLoaded() {
@@ -761,87 +785,46 @@ func (h *Hashgraph) InsertEvent(event Event, setWireInfo bool) error {
 }
 
 /*
-DivideRounds assigns a Round and LamportTimestamp to Events, and flags them as
-witnesses if necessary. Pushes Rounds in the PendingRounds queue if necessary.
+DivideRounds pushes Rounds in the PendingRounds queue if necessary. The Round,
+LamportTimestamp, and witness flag of every Event are already computed by
+InsertEvent, so this only requires a constant amount of work per Event.
 */
 func (h *Hashgraph) DivideRounds() error {
 
 	for _, hash := range h.UndeterminedEvents {
 
-		ev, err := h.Store.GetEvent(hash)
+		roundNumber, err := h.round(hash)
 		if err != nil {
 			return err
 		}
 
-		updateEvent := false
-
-		/*
-		   Compute Event's round, update the corresponding Round object, and
-		   add it to the PendingRounds queue if necessary.
-		*/
-		if ev.round == nil {
-
-			roundNumber, err := h.round(hash)
-			if err != nil {
-				return err
-			}
-
-			ev.SetRound(roundNumber)
-			updateEvent = true
-
-			roundInfo, err := h.Store.GetRound(roundNumber)
-			if err != nil && !common.Is(err, common.KeyNotFound) {
-				return err
-			}
-
-			/*
-				Why the lower bound?
-				Normally, once a Round has attained consensus, it is impossible for
-				new Events from a previous Round to be inserted; the lower bound
-				appears redundant. This is the case when the hashgraph grows
-				linearly, without jumps, which is what we intend by 'Normally'.
-				But the Reset function introduces a dicontinuity  by jumping
-				straight to a specific place in the hashgraph. This technique relies
-				on a base layer of Events (the corresponding Frame's Events) for
-				other Events to be added on top, but the base layer must not be
-				reprocessed.
-			*/
-			if !roundInfo.queued &&
-				(h.LastConsensusRound == nil ||
-					roundNumber >= *h.LastConsensusRound) {
-
-				h.PendingRounds = append(h.PendingRounds, &pendingRound{roundNumber, false})
-				roundInfo.queued = true
-			}
-
-			witness, err := h.witness(hash)
-			if err != nil {
-				return err
-			}
-			roundInfo.AddEvent(hash, witness)
-
-			err = h.Store.SetRound(roundNumber, roundInfo)
-			if err != nil {
-				return err
-			}
+		roundInfo, err := h.Store.GetRound(roundNumber)
+		if err != nil {
+			return err
 		}
 
 		/*
-			Compute the Event's LamportTimestamp
+			Why the lower bound?
+			Normally, once a Round has attained consensus, it is impossible for
+			new Events from a previous Round to be inserted; the lower bound
+			appears redundant. This is the case when the hashgraph grows
+			linearly, without jumps, which is what we intend by 'Normally'.
+			But the Reset function introduces a dicontinuity  by jumping
+			straight to a specific place in the hashgraph. This technique relies
+			on a base layer of Events (the corresponding Frame's Events) for
+			other Events to be added on top, but the base layer must not be
+			reprocessed.
 		*/
-		if ev.lamportTimestamp == nil {
+		if !roundInfo.queued &&
+			(h.LastConsensusRound == nil ||
+				roundNumber >= *h.LastConsensusRound) {
 
-			lamportTimestamp, err := h.lamportTimestamp(hash)
-			if err != nil {
+			h.PendingRounds = append(h.PendingRounds, &pendingRound{roundNumber, false})
+			roundInfo.queued = true
+
+			if err := h.Store.SetRound(roundNumber, roundInfo); err != nil {
 				return err
 			}
-
-			ev.SetLamportTimestamp(lamportTimestamp)
-			updateEvent = true
-		}
-
-		if updateEvent {
-			h.Store.SetEvent(ev)
 		}
 	}
 
@@ -1037,7 +1020,8 @@ func (h *Hashgraph) DecideRoundReceived() error {
 
 //ProcessDecidedRounds takes Rounds whose witnesses are decided, computes the
 //corresponding Frames, maps them into Blocks, and commits the Blocks via the
-//commit channel
+//commit channel. It stops early if the commit channel is full; the remaining
+//Rounds stay in PendingRounds.
 func (h *Hashgraph) ProcessDecidedRounds() error {
 
 	//Defer removing processed Rounds from the PendingRounds Queue
@@ -1064,6 +1048,14 @@ func (h *Hashgraph) ProcessDecidedRounds() error {
 			continue
 		}
 
+		//Sending a Block to a full commit channel would block while the caller
+		//holds the hashgraph. Leave the Round pending instead; it is processed
+		//by a later call, once Blocks have been consumed.
+		if h.commitCh != nil && cap(h.commitCh) > 0 &&
+			len(h.commitCh) == cap(h.commitCh) {
+			break
+		}
+
 		frame, err := h.GetFrame(r.Index)
 		if err != nil {
 			return fmt.Errorf("Getting Frame %d: %v", r.Index, err)
@@ -1256,13 +1248,16 @@ func (h *Hashgraph) ProcessSigPool() error {
 			}).Warning("Verifying Block signature. Could not fetch Block")
 			continue
 		}
+		//Malformed or invalid signatures are discarded; they will never become
+		//valid and must not prevent the other signatures from being processed.
 		valid, err := block.Verify(bs)
 		if err != nil {
 			h.logger.WithFields(logrus.Fields{
 				"index": bs.Index,
 				"msg":   err,
-			}).Error("Verifying Block signature")
-			return err
+			}).Warning("Verifying Block signature")
+			processedSignatures[i] = true
+			continue
 		}
 		if !valid {
 			h.logger.WithFields(logrus.Fields{
@@ -1270,6 +1265,7 @@ func (h *Hashgraph) ProcessSigPool() error {
 				"validator": h.Participants.ByPubKey[validatorHex],
 				"block":     block,
 			}).Warning("Verifying Block signature. Invalid signature")
+			processedSignatures[i] = true
 			continue
 		}
 
@@ -1333,13 +1329,10 @@ func (h *Hashgraph) Reset(block Block, frame Frame) error {
 	h.PendingLoadedEvents = 0
 	h.topologicalIndex = 0
 
-	cacheSize := h.Store.CacheSize()
-	h.ancestorCache = common.NewLRU(cacheSize, nil)
-	h.selfAncestorCache = common.NewLRU(cacheSize, nil)
-	h.stronglySeeCache = common.NewLRU(cacheSize, nil)
-	h.roundCache = common.NewLRU(cacheSize, nil)
-
 	participants := h.Participants.ToPeerSlice()
+	if len(frame.Roots) != len(participants) {
+		return fmt.Errorf("Frame has %d Roots, expected %d", len(frame.Roots), len(participants))
+	}
 
 	//Initialize new Roots
 	rootMap := map[string]Root{}
@@ -1365,6 +1358,16 @@ func (h *Hashgraph) Reset(block Block, frame Frame) error {
 		}
 	}
 
+	//The Frame Events were received in the Block's Round. Their Rounds exist,
+	//and their witnesses are not famous, so DecideRoundReceived would receive
+	//them again in the next Round. Queue their Rounds, which puts
+	//LastConsensusRound in PendingRounds, and take them out of the undetermined
+	//Events.
+	if err := h.DivideRounds(); err != nil {
+		return err
+	}
+	h.UndeterminedEvents = []string{}
+
 	return nil
 }
 
@@ -1416,7 +1419,10 @@ func (h *Hashgraph) ReadWireInfo(wevent WireEvent) (*Event, error) {
 	otherParent := ""
 	var err error
 
-	creator := h.Participants.ById[wevent.Body.CreatorID]
+	creator, ok := h.Participants.ById[wevent.Body.CreatorID]
+	if !ok {
+		return nil, common.NewStoreErr("Participants", common.UnknownParticipant, strconv.Itoa(wevent.Body.CreatorID))
+	}
 	creatorBytes, err := hex.DecodeString(creator.PubKeyHex[2:])
 	if err != nil {
 		return nil, err
@@ -1429,7 +1435,10 @@ func (h *Hashgraph) ReadWireInfo(wevent WireEvent) (*Event, error) {
 		}
 	}
 	if wevent.Body.OtherParentIndex >= 0 {
-		otherParentCreator := h.Participants.ById[wevent.Body.OtherParentCreatorID]
+		otherParentCreator, ok := h.Participants.ById[wevent.Body.OtherParentCreatorID]
+		if !ok {
+			return nil, common.NewStoreErr("Participants", common.UnknownParticipant, strconv.Itoa(wevent.Body.OtherParentCreatorID))
+		}
 		otherParent, err = h.Store.ParticipantEvent(otherParentCreator.PubKeyHex, wevent.Body.OtherParentIndex)
 		if err != nil {
 			//PROBLEM Check if other parent can be found in the root
@@ -182,21 +182,7 @@ func initHashgraph(t *testing.T) (*Hashgraph, map[string]string) {
 		play{1, 2, "s10", "e20", "e12", nil, nil},
 	}
 
-	h, index, orderedEvents := initHashgraphFull(plays, false, n, testLogger(t))
-
-	for i, ev := range *orderedEvents {
-		if err := h.initEventCoordinates(&ev); err != nil {
-			t.Fatalf("%d: %s", i, err)
-		}
-
-		if err := h.Store.SetEvent(ev); err != nil {
-			t.Fatalf("%d: %s", i, err)
-		}
-
-		if err := h.updateAncestorFirstDescendant(ev); err != nil {
-			t.Fatalf("%d: %s", i, err)
-		}
-	}
+	h, index, _ := initHashgraphFull(plays, false, n, testLogger(t))
 
 	return h, index
 }
@@ -1519,6 +1505,178 @@ func TestProcessDecidedRounds(t *testing.T) {
 
 }
 
+func TestProcessDecidedRoundsFullCommitCh(t *testing.T) {
+	h, _ := initConsensusHashgraph(false, t)
+	h.commitCh = make(chan Block, 1)
+
+	h.DivideRounds()
+	h.DecideFame()
+	h.DecideRoundReceived()
+	if err := h.ProcessDecidedRounds(); err != nil {
+		t.Fatal(err)
+	}
+
+	//Only one Block fits in the channel; the next decided Round waits
+	if lbi := h.Store.LastBlockIndex(); lbi != 0 {
+		t.Fatalf("LastBlockIndex should be 0, not %d", lbi)
+	}
+	if len(h.PendingRounds) == 0 || !h.PendingRounds[0].Decided {
+		t.Fatal("the next decided Round should still be pending")
+	}
+
+	blocks := []Block{}
+	for len(h.commitCh) > 0 {
+		blocks = append(blocks, <-h.commitCh)
+		if err := h.ProcessDecidedRounds(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if l := len(blocks); l != 2 {
+		t.Fatalf("2 Blocks should have been committed, not %d", l)
+	}
+	for i, b := range blocks {
+		if b.Index() != i {
+			t.Fatalf("Block %d should have Index %d, not %d", i, i, b.Index())
+		}
+	}
+}
+
+/*
+Participants 0, 1 and 2 gossip in a circle, which is enough to reach consensus
+without participant 3. Participant 3's first Event, a witness of Round 0, only
+shows up once the fame of Round 0 is decided.
+
+   ...  ...  ...   |
+    |   e11   |    |
+    |  / |    |    |
+   e10   |    |    |
+    |  \ |    |    |
+    |    |  \ |    |
+    |    |   e02   |
+    |    |  / |    |
+    |   e01   |    |
+    |  / |    |    |
+   e00   |    |    |
+    |  \ |    |    |
+    |    |  \ |    |
+   w00  w01  w02  w03 <- late
+	0	 1	  2	   3
+*/
+func TestLateWitness(t *testing.T) {
+	nodes, index, orderedEvents, participants := initHashgraphNodes(4)
+
+	for i, peer := range participants.ToPeerSlice()[:3] {
+		name := fmt.Sprintf("w0%d", i)
+		event := NewEvent(nil, nil, []string{rootSelfParent(peer.ID), ""}, nodes[i].Pub, 0)
+		nodes[i].signAndAddEvent(event, name, index, orderedEvents)
+	}
+
+	plays := []play{}
+	last := []string{"w00", "w01", "w02"}
+	for k := 0; k < 30; k++ {
+		to := k % 3
+		name := fmt.Sprintf("e%d%d", k/3, to)
+		plays = append(plays, play{to, k/3 + 1, last[to], last[(to+2)%3], name, nil, nil})
+		last[to] = name
+	}
+	playEvents(plays, nodes, index, orderedEvents)
+
+	h := createHashgraph(false, orderedEvents, participants, testLogger(t))
+
+	if err := h.DivideRounds(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DecideFame(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DecideRoundReceived(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.ProcessDecidedRounds(); err != nil {
+		t.Fatal(err)
+	}
+
+	if h.LastConsensusRound == nil {
+		t.Fatal("Round 0 should be decided")
+	}
+	lastConsensusRound := *h.LastConsensusRound
+
+	late := NewEvent(nil, nil, []string{rootSelfParent(participants.ToPeerSlice()[3].ID), ""}, nodes[3].Pub, 0)
+	nodes[3].signAndAddEvent(late, "w03", index, orderedEvents)
+	if err := h.InsertEvent(nodes[3].Events[0], true); err != nil {
+		t.Fatal(err)
+	}
+
+	round0, err := h.Store.GetRound(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re, ok := round0.Events[index["w03"]]
+	if !ok || !re.Witness {
+		t.Fatal("w03 should be a witness of Round 0")
+	}
+	if re.Famous != False {
+		t.Fatalf("w03 should not be famous, not %v", re.Famous)
+	}
+	if !round0.WitnessesDecided() {
+		t.Fatal("the witnesses of Round 0 should still be decided")
+	}
+
+	//The late witness must not stop the following Rounds from being decided,
+	//and from receiving it
+	plays = []play{
+		play{3, 1, "w03", last[2], "f03", nil, nil},
+	}
+	for k := 30; k < 45; k++ {
+		to := k % 3
+		name := fmt.Sprintf("e%d%d", k/3, to)
+		other := last[(to+2)%3]
+		if k == 30 {
+			other = "f03"
+		}
+		plays = append(plays, play{to, k/3 + 1, last[to], other, name, nil, nil})
+		last[to] = name
+	}
+	*orderedEvents = []Event{}
+	playEvents(plays, nodes, index, orderedEvents)
+	for _, ev := range *orderedEvents {
+		if err := h.InsertEvent(ev, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := h.DivideRounds(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DecideFame(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DecideRoundReceived(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.ProcessDecidedRounds(); err != nil {
+		t.Fatal(err)
+	}
+
+	if *h.LastConsensusRound <= lastConsensusRound {
+		t.Fatalf("LastConsensusRound should be greater than %d, not %d",
+			lastConsensusRound, *h.LastConsensusRound)
+	}
+
+	w03, err := h.Store.GetEvent(index["w03"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w03.roundReceived == nil {
+		t.Fatal("w03 should have been received")
+	}
+	if *w03.roundReceived <= lastConsensusRound {
+		t.Fatalf("w03 should be received after Round %d, not in Round %d",
+			lastConsensusRound, *w03.roundReceived)
+	}
+}
+
 func BenchmarkConsensus(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		//we do not want to benchmark the initialization code
@@ -2412,6 +2570,10 @@ func TestFunkyHashgraphReset(t *testing.T) {
 		t.Logf("**************************************************************")
 
 		compareRoundWitnesses(h, h2, index, bi, true, t)
+
+		//The Frame Events were committed in the Block the hashgraph was Reset
+		//from; they must not be received again in the following Blocks
+		compareBlocks(h, h2, bi+1, t)
 	}
 
 }
@@ -2814,3 +2976,22 @@ func disp(index map[string]string, events []string) string {
 func create(x int) *int {
 	return &x
 }
+
+func compareBlocks(h, h2 *Hashgraph, from int, t *testing.T) {
+	if l, l2 := h.Store.LastBlockIndex(), h2.Store.LastBlockIndex(); l2 != l {
+		t.Fatalf("LastBlockIndex should be %d, not %d", l, l2)
+	}
+	for bi := from; bi <= h2.Store.LastBlockIndex(); bi++ {
+		hBlock, err := h.Store.GetBlock(bi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h2Block, err := h2.Store.GetBlock(bi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(hBlock.Body, h2Block.Body) {
+			t.Fatalf("Block %d should be %+v, not %+v", bi, hBlock.Body, h2Block.Body)
+		}
+	}
+}
@@ -14,6 +14,8 @@ type InmemStore struct {
 	roundCache             *cm.LRU
 	blockCache             *cm.LRU
 	frameCache             *cm.LRU
+	receiptCache           *cm.LRU //[block index] => []Receipt
+	txReceiptCache         *cm.LRU //[tx hash] => Receipt
 	consensusCache         *cm.RollingIndex
 	totConsensusEvents     int
 	participantEventsCache *ParticipantEventsCache
@@ -39,6 +41,8 @@ func NewInmemStore(participants *peers.Peers, cacheSize int) *InmemStore {
 		roundCache:             cm.NewLRU(cacheSize, nil),
 		blockCache:             cm.NewLRU(cacheSize, nil),
 		frameCache:             cm.NewLRU(cacheSize, nil),
+		receiptCache:           cm.NewLRU(cacheSize, nil),
+		txReceiptCache:         cm.NewLRU(cacheSize, nil),
 		consensusCache:         cm.NewRollingIndex("ConsensusCache", cacheSize),
 		participantEventsCache: NewParticipantEventsCache(cacheSize, participants),
 		rootsByParticipant:     rootsByParticipant,
@@ -251,6 +255,32 @@ func (s *InmemStore) LastBlockIndex() int {
 	return s.lastBlock
 }
 
+func (s *InmemStore) GetReceipt(txHash string) (Receipt, error) {
+	res, ok := s.txReceiptCache.Get(txHash)
+	if !ok {
+		return Receipt{}, cm.NewStoreErr("TxReceiptCache", cm.KeyNotFound, txHash)
+	}
+	return res.(Receipt), nil
+}
+
+func (s *InmemStore) GetBlockReceipts(blockIndex int) ([]Receipt, error) {
+	res, ok := s.receiptCache.Get(blockIndex)
+	if !ok {
+		return nil, cm.NewStoreErr("ReceiptCache", cm.KeyNotFound, strconv.Itoa(blockIndex))
+	}
+	return res.([]Receipt), nil
+}
+
+//SetReceipts records the Receipts of a Block. If the same transaction appears
+//in several Blocks, its latest Receipt is the one returned by GetReceipt.
+func (s *InmemStore) SetReceipts(blockIndex int, receipts []Receipt) error {
+	s.receiptCache.Add(blockIndex, receipts)
+	for _, r := range receipts {
+		s.txReceiptCache.Add(r.TxHash, r)
+	}
+	return nil
+}
+
 func (s *InmemStore) GetFrame(index int) (Frame, error) {
 	res, ok := s.frameCache.Get(index)
 	if !ok {
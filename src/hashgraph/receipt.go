@@ -0,0 +1,87 @@
+package hashgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+)
+
+//TxCodeOK is the Code of a transaction that the App applied successfully. Any
+//other Code is App-specific and denotes a failure.
+const TxCodeOK uint32 = 0
+
+//TxEvent is an event emitted by the App while applying a transaction
+type TxEvent struct {
+	Type       string
+	Attributes map[string]string
+}
+
+//TxResult is the result of a transaction, as returned by the App when it
+//commits a Block
+type TxResult struct {
+	Code   uint32
+	Log    string
+	Events []TxEvent
+}
+
+//OK returns true if the transaction was applied successfully
+func (r *TxResult) OK() bool {
+	return r.Code == TxCodeOK
+}
+
+//Receipt ties the result of a transaction to its position in the chain of
+//Blocks
+type Receipt struct {
+	TxHash     string
+	BlockIndex int
+	TxIndex    int
+	Result     TxResult
+}
+
+//TxHash is the hex encoding of the SHA256 hash of a transaction. It is the key
+//by which Receipts are looked up.
+func TxHash(tx []byte) string {
+	return fmt.Sprintf("0x%X", crypto.SHA256(tx))
+}
+
+//NewReceipts pairs the transactions of a Block with the results returned by the
+//App. There must be exactly one result per transaction.
+func NewReceipts(block Block, results []TxResult) ([]Receipt, error) {
+	txs := block.Transactions()
+	if len(results) != len(txs) {
+		return nil, fmt.Errorf("Block %d has %d transactions but %d results",
+			block.Index(), len(txs), len(results))
+	}
+
+	receipts := make([]Receipt, len(txs))
+	for i, tx := range txs {
+		receipts[i] = Receipt{
+			TxHash:     TxHash(tx),
+			BlockIndex: block.Index(),
+			TxIndex:    i,
+			Result:     results[i],
+		}
+	}
+
+	return receipts, nil
+}
+
+func (r *Receipt) Marshal() ([]byte, error) {
+	bf := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(bf)
+	if err := enc.Encode(r); err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
+
+func (r *Receipt) Unmarshal(data []byte) error {
+	b := bytes.NewBuffer(data)
+	dec := json.NewDecoder(b) //will read from b
+	if err := dec.Decode(r); err != nil {
+		return err
+	}
+	return nil
+}
@@ -25,6 +25,9 @@ type Store interface {
 	GetBlock(int) (Block, error)
 	SetBlock(Block) error
 	LastBlockIndex() int
+	GetReceipt(string) (Receipt, error)
+	GetBlockReceipts(int) ([]Receipt, error)
+	SetReceipts(int, []Receipt) error
 	GetFrame(int) (Frame, error)
 	SetFrame(Frame) error
 	Reset(map[string]Root) error
@@ -0,0 +1,35 @@
+package mobile
+
+import "github.com/mosaicnetworks/babble/src/hashgraph"
+
+//Block is a Block being committed, in a form that gomobile can bind. gomobile
+//cannot bind slices of slices, so transactions are accessed by index.
+type Block struct {
+	Index         int
+	RoundReceived int
+	FrameHash     []byte
+
+	txs [][]byte
+}
+
+func newBlock(block hashgraph.Block) *Block {
+	return &Block{
+		Index:         block.Index(),
+		RoundReceived: block.RoundReceived(),
+		FrameHash:     block.FrameHash(),
+		txs:           block.Transactions(),
+	}
+}
+
+//TxCount returns the number of transactions in the Block
+func (b *Block) TxCount() int {
+	return len(b.txs)
+}
+
+//Tx returns the transaction at position i, or nil if there is none
+func (b *Block) Tx(i int) []byte {
+	if i < 0 || i >= len(b.txs) {
+		return nil
+	}
+	return b.txs[i]
+}
@@ -14,3 +14,33 @@ type CommitHandler interface {
 type ExceptionHandler interface {
 	OnException(string)
 }
+
+//CheckTxHandler is optional. It is called before a transaction is admitted to
+//the transaction pool, and returns an empty string if the transaction is valid,
+//or the reason why it is rejected.
+type CheckTxHandler interface {
+	CheckTx([]byte) string
+}
+
+//CommitBlockHandler is optional. When it is set, it is called instead of
+//CommitHandler, with the Block and its metadata, and returns the state hash.
+type CommitBlockHandler interface {
+	OnCommitBlock(*Block) []byte
+}
+
+//StateChangeHandler is optional. It is called with the name of the new state of
+//the node, whose node.NodeState value is given in brackets: Babbling (0),
+//CatchingUp (1), Shutdown (2), Failed (3) or Paused (4). It is called
+//asynchronously, in the order of the changes.
+type StateChangeHandler interface {
+	OnStateChanged(string)
+}
+
+//SnapshotHandler is optional, but a node cannot fast-forward without it.
+//GetSnapshot returns the snapshot of the state after the given Block, or an
+//empty slice if there is none. Restore replaces the state with a snapshot and
+//returns the resulting state hash.
+type SnapshotHandler interface {
+	GetSnapshot(int) []byte
+	Restore([]byte) []byte
+}
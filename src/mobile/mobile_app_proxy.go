@@ -1,6 +1,9 @@
 package mobile
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/mosaicnetworks/babble/src/hashgraph"
 	"github.com/mosaicnetworks/babble/src/proxy/inmem"
 	"github.com/sirupsen/logrus"
@@ -14,12 +17,21 @@ This type is not exported
 type mobileAppProxy struct {
 	*inmem.InmemProxy
 
-	commitHandler    CommitHandler
-	exceptionHandler ExceptionHandler
-	logger           *logrus.Logger
+	commitHandler      CommitHandler
+	exceptionHandler   ExceptionHandler
+	checkTxHandler     CheckTxHandler
+	commitBlockHandler CommitBlockHandler
+	snapshotHandler    SnapshotHandler
+	logger             *logrus.Logger
 }
 
+//CommitHandler calls the CommitBlockHandler if there is one, and the
+//CommitHandler with the marshalled Block otherwise
 func (m *mobileAppProxy) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	if m.commitBlockHandler != nil {
+		return m.commitBlockHandler.OnCommitBlock(newBlock(block)), nil
+	}
+
 	blockBytes, err := block.Marshal()
 
 	if err != nil {
@@ -33,12 +45,41 @@ func (m *mobileAppProxy) CommitHandler(block hashgraph.Block) ([]byte, error) {
 	return stateHash, nil
 }
 
+//CheckTxHandler accepts all transactions unless a CheckTxHandler was set
+func (m *mobileAppProxy) CheckTxHandler(tx []byte) error {
+	if m.checkTxHandler == nil {
+		return nil
+	}
+
+	if reason := m.checkTxHandler.CheckTx(tx); reason != "" {
+		return errors.New(reason)
+	}
+
+	return nil
+}
+
+//SnapshotHandler returns an empty snapshot unless a SnapshotHandler was set
 func (m *mobileAppProxy) SnapshotHandler(blockIndex int) ([]byte, error) {
-	return []byte{}, nil
+	if m.snapshotHandler == nil {
+		return []byte{}, nil
+	}
+
+	snapshot := m.snapshotHandler.GetSnapshot(blockIndex)
+
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("Snapshot %d not found", blockIndex)
+	}
+
+	return snapshot, nil
 }
 
+//RestoreHandler does nothing unless a SnapshotHandler was set
 func (m *mobileAppProxy) RestoreHandler(snapshot []byte) ([]byte, error) {
-	return []byte{}, nil
+	if m.snapshotHandler == nil {
+		return []byte{}, nil
+	}
+
+	return m.snapshotHandler.Restore(snapshot), nil
 }
 
 // newMobileAppProxy create proxy
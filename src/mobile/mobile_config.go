@@ -1,5 +1,11 @@
 package mobile
 
+import (
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/babble"
+)
+
 type MobileConfig struct {
 	Heartbeat  int    //heartbeat timeout in milliseconds
 	TCPTimeout int    //TCP timeout in milliseconds
@@ -7,7 +13,7 @@ type MobileConfig struct {
 	CacheSize  int    //Number of items in LRU cache
 	SyncLimit  int    //Max Events per sync
 	StoreType  string //inmem or badger
-	StorePath  string //File containing the Store DB
+	StorePath  string //Directory containing the badger DB
 }
 
 func NewMobileConfig(heartbeat int,
@@ -40,3 +46,33 @@ func DefaultMobileConfig() *MobileConfig {
 		StorePath:  "",
 	}
 }
+
+//toBabbleConfig maps the MobileConfig onto a BabbleConfig. Unset fields keep
+//their default values.
+func (c *MobileConfig) toBabbleConfig() *babble.BabbleConfig {
+	babbleConfig := babble.NewDefaultConfig()
+
+	if c.Heartbeat > 0 {
+		babbleConfig.NodeConfig.HeartbeatTimeout = time.Duration(c.Heartbeat) * time.Millisecond
+	}
+	if c.TCPTimeout > 0 {
+		babbleConfig.NodeConfig.TCPTimeout = time.Duration(c.TCPTimeout) * time.Millisecond
+	}
+	if c.MaxPool > 0 {
+		babbleConfig.MaxPool = c.MaxPool
+	}
+	if c.CacheSize > 0 {
+		babbleConfig.NodeConfig.CacheSize = c.CacheSize
+	}
+	if c.SyncLimit > 0 {
+		babbleConfig.NodeConfig.SyncLimit = c.SyncLimit
+	}
+
+	babbleConfig.Store = c.StoreType == "badger"
+	if c.StorePath != "" {
+		//the badger DB is kept in DataDir/badger_db
+		babbleConfig.DataDir = c.StorePath
+	}
+
+	return babbleConfig
+}
@@ -1,20 +1,21 @@
 package mobile
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/mosaicnetworks/babble/src/babble"
 	"github.com/mosaicnetworks/babble/src/crypto"
 	"github.com/mosaicnetworks/babble/src/node"
 	"github.com/mosaicnetworks/babble/src/peers"
-	"github.com/mosaicnetworks/babble/src/proxy"
 	"github.com/sirupsen/logrus"
 )
 
 type Node struct {
 	nodeID int
+	engine *babble.Babble
 	node   *node.Node
-	proxy  proxy.AppProxy
+	proxy  *mobileAppProxy
 	logger *logrus.Logger
 }
 
@@ -26,7 +27,11 @@ func New(privKey string,
 	exceptionHandler ExceptionHandler,
 	config *MobileConfig) *Node {
 
-	babbleConfig := babble.NewDefaultConfig()
+	if config == nil {
+		config = DefaultMobileConfig()
+	}
+
+	babbleConfig := config.toBabbleConfig()
 
 	babbleConfig.Logger.WithFields(logrus.Fields{
 		"nodeAddr": nodeAddr,
@@ -54,7 +59,9 @@ func New(privKey string,
 		return nil
 	}
 
-	babbleConfig.Proxy = newMobileAppProxy(commitHandler, exceptionHandler, babbleConfig.Logger)
+	mobileProxy := newMobileAppProxy(commitHandler, exceptionHandler, babbleConfig.Logger)
+
+	babbleConfig.Proxy = mobileProxy
 	babbleConfig.LoadPeers = false
 
 	engine := babble.NewBabble(babbleConfig)
@@ -68,8 +75,9 @@ func New(privKey string,
 	}
 
 	return &Node{
+		engine: engine,
 		node:   engine.Node,
-		proxy:  babbleConfig.Proxy,
+		proxy:  mobileProxy,
 		nodeID: engine.Node.ID(),
 		logger: babbleConfig.Logger,
 	}
@@ -87,10 +95,73 @@ func (n *Node) Shutdown() {
 	n.node.Shutdown()
 }
 
-func (n *Node) SubmitTx(tx []byte) {
-	//have to make a copy or the tx will be garbage collected and weird stuff
-	//happens in transaction pool
-	t := make([]byte, len(tx), len(tx))
-	copy(t, tx)
-	n.proxy.SubmitCh() <- t
+//Pause stops gossip and closes the node's sockets, but keeps its state. It
+//should be called when the OS sends the app to the background.
+func (n *Node) Pause() error {
+	return n.engine.Pause()
+}
+
+//Resume reopens the node's sockets and syncs with the other peers. The node
+//goes through the CatchingUp state if it fell too far behind while it was
+//paused.
+func (n *Node) Resume() error {
+	return n.engine.Resume()
+}
+
+//SetCommitBlockHandler sets a handler which is called instead of the
+//CommitHandler, with the metadata of every Block. It should be called before
+//Run.
+func (n *Node) SetCommitBlockHandler(commitBlockHandler CommitBlockHandler) {
+	n.proxy.commitBlockHandler = commitBlockHandler
+}
+
+//SetSnapshotHandler sets the handler which produces and restores snapshots of
+//the App's state, so that the node can fast-forward. It should be called
+//before Run.
+func (n *Node) SetSnapshotHandler(snapshotHandler SnapshotHandler) {
+	n.proxy.snapshotHandler = snapshotHandler
+}
+
+//SetStateChangeHandler sets the handler which is notified when the node starts
+//babbling, catches up, fails or shuts down. It should be called before Run.
+func (n *Node) SetStateChangeHandler(stateChangeHandler StateChangeHandler) {
+	n.node.SetStateChangeHandler(func(state node.NodeState) {
+		stateChangeHandler.OnStateChanged(state.String())
+	})
+}
+
+//GetState returns the current state of the node
+func (n *Node) GetState() string {
+	return n.node.GetState().String()
+}
+
+//GetStats returns the statistics of the node as a JSON object of strings
+func (n *Node) GetStats() string {
+	stats, err := json.Marshal(n.node.GetStats())
+
+	if err != nil {
+		n.logger.WithError(err).Error("Marshalling stats")
+
+		return "{}"
+	}
+
+	return string(stats)
+}
+
+//SetCheckTxHandler sets the handler used to validate submitted transactions.
+//It should be called before Run.
+func (n *Node) SetCheckTxHandler(checkTxHandler CheckTxHandler) {
+	n.proxy.checkTxHandler = checkTxHandler
+}
+
+//SubmitTx returns an error if the transaction was rejected
+func (n *Node) SubmitTx(tx []byte) error {
+	//Nothing consumes transactions while the node is paused
+	if n.node.GetState() == node.Paused {
+		return fmt.Errorf("Node is paused")
+	}
+
+	//InmemProxy makes a copy or the tx will be garbage collected and weird
+	//stuff happens in transaction pool
+	return n.proxy.SubmitTx(tx)
 }
@@ -1,15 +1,20 @@
 package net
 
-import "github.com/mosaicnetworks/babble/src/hashgraph"
+import (
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+)
 
 type SyncRequest struct {
-	FromID int
-	Known  map[int]int
+	FromID       int
+	Known        map[int]int
+	CatchUpLimit int //Max Events the requester accepts in pages
 }
 
 type SyncResponse struct {
 	FromID    int
 	SyncLimit bool
+	More      bool //Events is only the first page of the diff
 	Events    []hashgraph.WireEvent
 	Known     map[int]int
 }
@@ -29,12 +34,27 @@ type EagerSyncResponse struct {
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 type FastForwardRequest struct {
-	FromID int
+	FromID     int
+	BlockOnly  bool //only return the Block at BlockIndex and its Manifest, to cross-check them
+	BlockIndex int
 }
 
 type FastForwardResponse struct {
 	FromID   int
 	Block    hashgraph.Block
 	Frame    hashgraph.Frame
-	Snapshot []byte
+	Manifest proxy.SnapshotManifest //the snapshot is fetched with SnapshotChunkRequests
+}
+
+//++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+
+type SnapshotChunkRequest struct {
+	FromID     int
+	BlockIndex int
+	Chunk      int
+}
+
+type SnapshotChunkResponse struct {
+	FromID int
+	Data   []byte
 }
@@ -0,0 +1,120 @@
+package net
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ErrorCode tells the requester why an RPC failed, so that it can react without
+//parsing error messages
+type ErrorCode int
+
+const (
+	//CodeInternal is used for any error that has no specific code
+	CodeInternal ErrorCode = iota
+	//CodeNotReady means that the node is not Babbling, eg. it is catching up
+	CodeNotReady
+	//CodeSyncLimit means that the requester is too far behind and needs to
+	//fast-forward
+	CodeSyncLimit
+	//CodeUnknownParticipant means that the request refers to a participant
+	//that the node does not know
+	CodeUnknownParticipant
+	//CodeInvalidSignature means that the request contains an invalid signature
+	CodeInvalidSignature
+)
+
+var errorCodes = []ErrorCode{
+	CodeInternal,
+	CodeNotReady,
+	CodeSyncLimit,
+	CodeUnknownParticipant,
+	CodeInvalidSignature,
+}
+
+func (c ErrorCode) String() string {
+	switch c {
+	case CodeInternal:
+		return "internal"
+	case CodeNotReady:
+		return "not-ready"
+	case CodeSyncLimit:
+		return "sync-limit"
+	case CodeUnknownParticipant:
+		return "unknown-participant"
+	case CodeInvalidSignature:
+		return "invalid-signature"
+	default:
+		return fmt.Sprintf("code-%d", int(c))
+	}
+}
+
+//RPCError is the error envelope sent back with RPC responses
+type RPCError struct {
+	Code    ErrorCode
+	Message string
+}
+
+//NewRPCError creates an RPCError with a formatted message
+func NewRPCError(code ErrorCode, format string, args ...interface{}) *RPCError {
+	return &RPCError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+//ToRPCError returns err as an RPCError. Errors that are not RPCErrors already
+//are given CodeInternal.
+func ToRPCError(err error) *RPCError {
+	if err == nil {
+		return nil
+	}
+	if rpcErr, ok := err.(*RPCError); ok {
+		return rpcErr
+	}
+	return &RPCError{
+		Code:    CodeInternal,
+		Message: err.Error(),
+	}
+}
+
+//Code returns the ErrorCode of err, and whether err was returned by the remote
+//node at all. Transport errors, like timeouts, have no code.
+func Code(err error) (ErrorCode, bool) {
+	if rpcErr, ok := err.(*RPCError); ok {
+		return rpcErr.Code, true
+	}
+	return CodeInternal, false
+}
+
+//encodeError returns the form in which an error travels in a response: a
+//string, empty if there is no error, and otherwise the message prefixed with
+//the code (cf RPCError.Error). This is the same encoding as before error codes
+//existed, so older nodes read it as a plain error message.
+func encodeError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return ToRPCError(err).Error()
+}
+
+//ParseRPCError recovers an RPCError from its wire form. Messages without a
+//known code prefix, like those of older nodes, are given CodeInternal.
+func ParseRPCError(s string) *RPCError {
+	for _, code := range errorCodes {
+		if prefix := code.String() + ": "; strings.HasPrefix(s, prefix) {
+			return &RPCError{
+				Code:    code,
+				Message: strings.TrimPrefix(s, prefix),
+			}
+		}
+	}
+	return &RPCError{
+		Code:    CodeInternal,
+		Message: s,
+	}
+}
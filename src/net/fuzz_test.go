@@ -0,0 +1,73 @@
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/sirupsen/logrus"
+)
+
+//FuzzHandleCommand feeds arbitrary bytes, as they would be read from an
+//inbound connection, to the NetworkTransport's command decoder.
+func FuzzHandleCommand(f *testing.F) {
+	for rpcType, cmd := range []interface{}{
+		SyncRequest{FromID: 1, Known: map[int]int{0: 1, 1: 2}},
+		EagerSyncRequest{FromID: 1, Events: []hashgraph.WireEvent{
+			hashgraph.WireEvent{
+				Body: hashgraph.WireBody{
+					Transactions:         [][]byte{[]byte("abc")},
+					SelfParentIndex:      1,
+					OtherParentCreatorID: 10,
+					OtherParentIndex:     0,
+					CreatorID:            9,
+				},
+				Signature: "1|2",
+			},
+		}},
+		FastForwardRequest{FromID: 1},
+		SnapshotChunkRequest{FromID: 1, BlockIndex: 2, Chunk: 3},
+	} {
+		var b bytes.Buffer
+		b.WriteByte(byte(rpcType))
+		json.NewEncoder(&b).Encode(cmd)
+		f.Add(b.Bytes())
+	}
+	f.Add([]byte{rpcSync, '{', '}', rpcEagerSync, 'n', 'u', 'l', 'l'})
+	f.Add([]byte{42})
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		trans := &NetworkTransport{
+			consumeCh:  make(chan RPC),
+			shutdownCh: make(chan struct{}),
+			logger:     logger,
+		}
+		defer close(trans.shutdownCh)
+
+		go func() {
+			for {
+				select {
+				case rpc := <-trans.consumeCh:
+					rpc.Respond(&SyncResponse{FromID: 0}, nil)
+				case <-trans.shutdownCh:
+					return
+				}
+			}
+		}()
+
+		r := bufio.NewReader(bytes.NewReader(data))
+		dec := json.NewDecoder(r)
+		enc := json.NewEncoder(ioutil.Discard)
+		for {
+			if err := trans.handleCommand(r, dec, enc); err != nil {
+				return
+			}
+		}
+	})
+}
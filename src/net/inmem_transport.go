@@ -103,6 +103,19 @@ func (i *InmemTransport) FastForward(target string, args *FastForwardRequest, re
 	return nil
 }
 
+// SnapshotChunk implements the Transport interface.
+func (i *InmemTransport) SnapshotChunk(target string, args *SnapshotChunkRequest, resp *SnapshotChunkResponse) error {
+	rpcResp, err := i.makeRPC(target, args, nil, i.timeout)
+	if err != nil {
+		return err
+	}
+
+	// Copy the result back
+	out := rpcResp.Response.(*SnapshotChunkResponse)
+	*resp = *out
+	return nil
+}
+
 func (i *InmemTransport) makeRPC(target string, args interface{}, r io.Reader, timeout time.Duration) (rpcResp RPCResponse, err error) {
 	i.RLock()
 	peer, ok := i.peers[target]
@@ -125,7 +138,7 @@ func (i *InmemTransport) makeRPC(target string, args interface{}, r io.Reader, t
 	select {
 	case rpcResp = <-respCh:
 		if rpcResp.Error != nil {
-			err = rpcResp.Error
+			err = ToRPCError(rpcResp.Error)
 		}
 	case <-time.After(timeout):
 		err = fmt.Errorf("command timed out")
@@ -17,6 +17,7 @@ const (
 	rpcSync uint8 = iota
 	rpcEagerSync
 	rpcFastForward
+	rpcSnapshotChunk
 
 	// DefaultTimeoutScale is the default TimeoutScale in a NetworkTransport.
 	DefaultTimeoutScale = 256 * 1024 // 256KB
@@ -42,8 +43,10 @@ This transport is very simple and lightweight. Each RPC request is
 framed by sending a byte that indicates the message type, followed
 by the json encoded request.
 
-The response is an error string followed by the response object,
-both are encoded using msgpack
+The response is an error string, empty if there is none, followed by the
+response object, both are encoded using json. Error strings start with the code
+of the error (cf RPCError); nodes that predate error codes read them as plain
+messages, and their messages are decoded with CodeInternal.
 */
 type NetworkTransport struct {
 	logger *logrus.Logger
@@ -219,6 +222,11 @@ func (n *NetworkTransport) FastForward(target string, args *FastForwardRequest,
 	return n.genericRPC(target, rpcFastForward, args, resp)
 }
 
+// SnapshotChunk implements the Transport interface.
+func (n *NetworkTransport) SnapshotChunk(target string, args *SnapshotChunkRequest, resp *SnapshotChunkResponse) error {
+	return n.genericRPC(target, rpcSnapshotChunk, args, resp)
+}
+
 // genericRPC handles a simple request/response RPC.
 func (n *NetworkTransport) genericRPC(target string, rpcType uint8, args interface{}, resp interface{}) error {
 	// Get a conn
@@ -283,9 +291,9 @@ func decodeResponse(conn *netConn, resp interface{}) (bool, error) {
 		return false, err
 	}
 
-	// Format an error if any
+	// Return the error if any
 	if rpcError != "" {
-		return true, fmt.Errorf(rpcError)
+		return true, ParseRPCError(rpcError)
 	}
 	return true, nil
 }
@@ -368,6 +376,12 @@ func (n *NetworkTransport) handleCommand(r *bufio.Reader, dec *json.Decoder, enc
 			return err
 		}
 		rpc.Command = &req
+	case rpcSnapshotChunk:
+		var req SnapshotChunkRequest
+		if err := dec.Decode(&req); err != nil {
+			return err
+		}
+		rpc.Command = &req
 	default:
 		return fmt.Errorf("unknown rpc type %d", rpcType)
 	}
@@ -383,11 +397,7 @@ func (n *NetworkTransport) handleCommand(r *bufio.Reader, dec *json.Decoder, enc
 	select {
 	case resp := <-respCh:
 		// Send the error first
-		respErr := ""
-		if resp.Error != nil {
-			respErr = resp.Error.Error()
-		}
-		if err := enc.Encode(respErr); err != nil {
+		if err := enc.Encode(encodeError(resp.Error)); err != nil {
 			return err
 		}
 
@@ -1,6 +1,7 @@
 package net
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 	"testing"
@@ -155,6 +156,54 @@ func TestNetworkTransport_EagerSync(t *testing.T) {
 	}
 }
 
+func TestNetworkTransport_Error(t *testing.T) {
+	// Transport 1 is consumer
+	trans1, err := NewTCPTransport("127.0.0.1:0", nil, 2, time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans1.Close()
+	rpcCh := trans1.Consumer()
+
+	// Respond with a coded error first, then with a plain one
+	go func() {
+		for _, respErr := range []error{
+			NewRPCError(CodeNotReady, "state is %s", "CatchingUp"),
+			fmt.Errorf("boom"),
+		} {
+			select {
+			case rpc := <-rpcCh:
+				rpc.Respond(nil, respErr)
+			case <-time.After(200 * time.Millisecond):
+				t.Fatalf("timeout")
+			}
+		}
+	}()
+
+	// Transport 2 makes outbound request
+	trans2, err := NewTCPTransport("127.0.0.1:0", nil, 2, time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer trans2.Close()
+
+	args := SyncRequest{FromID: 0}
+	var out SyncResponse
+
+	err = trans2.Sync(trans1.LocalAddr(), &args, &out)
+	if code, ok := Code(err); !ok || code != CodeNotReady {
+		t.Fatalf("error should have code %s, not %v", CodeNotReady, err)
+	}
+
+	err = trans2.Sync(trans1.LocalAddr(), &args, &out)
+	if code, ok := Code(err); !ok || code != CodeInternal {
+		t.Fatalf("error should have code %s, not %v", CodeInternal, err)
+	}
+	if err.Error() != "internal: boom" {
+		t.Fatalf("error message should be preserved, not %q", err.Error())
+	}
+}
+
 func TestNetworkTransport_PooledConn(t *testing.T) {
 	// Transport 1 is consumer
 	trans1, err := NewTCPTransport("127.0.0.1:0", nil, 2, time.Second, common.NewTestLogger(t))
@@ -249,3 +298,23 @@ func TestNetworkTransport_PooledConn(t *testing.T) {
 		t.Fatalf("Expected 2 pooled conns!")
 	}
 }
+
+func TestParseRPCError(t *testing.T) {
+	cases := []struct {
+		raw     string
+		code    ErrorCode
+		message string
+	}{
+		// Coded errors travel as strings
+		{"not-ready: state is CatchingUp", CodeNotReady, "state is CatchingUp"},
+		// Nodes that predate error codes send plain messages
+		{"boom", CodeInternal, "boom"},
+	}
+
+	for _, c := range cases {
+		rpcErr := ParseRPCError(c.raw)
+		if rpcErr.Code != c.code || rpcErr.Message != c.message {
+			t.Fatalf("%s should parse to %s %q, not %#v", c.raw, c.code, c.message, rpcErr)
+		}
+	}
+}
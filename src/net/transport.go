@@ -38,6 +38,8 @@ type Transport interface {
 
 	FastForward(target string, args *FastForwardRequest, resp *FastForwardResponse) error
 
+	SnapshotChunk(target string, args *SnapshotChunkRequest, resp *SnapshotChunkResponse) error
+
 	// Close permanently closes a transport, stopping
 	// any associated goroutines and freeing other resources.
 	Close() error
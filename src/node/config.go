@@ -1,19 +1,58 @@
 package node
 
 import (
+	"fmt"
+	"math"
+	"math/rand"
 	"testing"
 	"time"
 
 	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/crypto"
 	"github.com/sirupsen/logrus"
 )
 
+//Policies that can be used in Config.CommitPolicy, when the App fails to commit
+//a Block
+const (
+	//CommitRetry retries the Block with an exponential backoff. Later Blocks
+	//wait.
+	CommitRetry = "retry"
+	//CommitHalt shuts the node down. Node.ExitReason tells why.
+	CommitHalt = "halt"
+	//CommitUnhealthy marks the App as unhealthy. The node keeps gossiping but
+	//stops committing and signing Blocks until it fast-forwards.
+	CommitUnhealthy = "unhealthy"
+)
+
 type Config struct {
-	HeartbeatTimeout time.Duration `mapstructure:"heartbeat"`
-	TCPTimeout       time.Duration `mapstructure:"timeout"`
-	CacheSize        int           `mapstructure:"cache-size"`
-	SyncLimit        int           `mapstructure:"sync-limit"`
-	Logger           *logrus.Logger
+	HeartbeatTimeout  time.Duration `mapstructure:"heartbeat"`
+	AdaptiveHeartbeat bool          `mapstructure:"adaptive-heartbeat"`
+	MinHeartbeat      time.Duration `mapstructure:"min-heartbeat"`
+	MaxHeartbeat      time.Duration `mapstructure:"max-heartbeat"`
+	TCPTimeout        time.Duration `mapstructure:"timeout"`
+	CacheSize         int           `mapstructure:"cache-size"`
+	SyncLimit         int           `mapstructure:"sync-limit"`
+	CatchUpLimit      int           `mapstructure:"catch-up-limit"`
+	Archive           bool          `mapstructure:"archive"`
+	FastForwardQuorum int           `mapstructure:"fast-forward-quorum"`
+	FastForwardTries  int           `mapstructure:"fast-forward-tries"`
+	PeerSelector      string        `mapstructure:"peer-selector"`
+	Fanout            int           `mapstructure:"fanout"`
+	CommitPolicy      string        `mapstructure:"commit-policy"`
+	CommitRetries     int           `mapstructure:"commit-retries"`
+	Logger            *logrus.Logger
+
+	//Clock and Seed make the timers and random decisions of the node
+	//reproducible, for simulations. By default, the node uses the system
+	//clock and a time-based seed.
+	Clock Clock
+	Seed  int64
+
+	//Signer replaces crypto.Sign to sign Events and Blocks, so that
+	//simulations can use deterministic signatures. It must not be set in
+	//production.
+	Signer crypto.Signer
 }
 
 func NewConfig(heartbeat time.Duration,
@@ -23,11 +62,19 @@ func NewConfig(heartbeat time.Duration,
 	logger *logrus.Logger) *Config {
 
 	return &Config{
-		HeartbeatTimeout: heartbeat,
-		TCPTimeout:       timeout,
-		CacheSize:        cacheSize,
-		SyncLimit:        syncLimit,
-		Logger:           logger,
+		HeartbeatTimeout:  heartbeat,
+		MinHeartbeat:      heartbeat / 10,
+		MaxHeartbeat:      heartbeat * 5,
+		TCPTimeout:        timeout,
+		CacheSize:         cacheSize,
+		SyncLimit:         syncLimit,
+		CatchUpLimit:      syncLimit,
+		FastForwardQuorum: 2,
+		FastForwardTries:  10,
+		PeerSelector:      RandomSelector,
+		Fanout:            1,
+		CommitPolicy:      CommitRetry,
+		Logger:            logger,
 	}
 }
 
@@ -36,12 +83,95 @@ func DefaultConfig() *Config {
 	logger.Level = logrus.DebugLevel
 
 	return &Config{
-		HeartbeatTimeout: 1000 * time.Millisecond,
-		TCPTimeout:       1000 * time.Millisecond,
-		CacheSize:        500,
-		SyncLimit:        100,
-		Logger:           logger,
+		HeartbeatTimeout:  1000 * time.Millisecond,
+		MinHeartbeat:      100 * time.Millisecond,
+		MaxHeartbeat:      5000 * time.Millisecond,
+		TCPTimeout:        1000 * time.Millisecond,
+		CacheSize:         500,
+		SyncLimit:         100,
+		CatchUpLimit:      1000,
+		FastForwardQuorum: 2,
+		FastForwardTries:  10,
+		PeerSelector:      RandomSelector,
+		Fanout:            1,
+		CommitPolicy:      CommitRetry,
+		Logger:            logger,
+	}
+}
+
+//fanout returns the number of peers to gossip with concurrently
+func (c *Config) fanout() int {
+	if c.Fanout < 1 {
+		return 1
+	}
+	return c.Fanout
+}
+
+//Validate returns an error if the configuration has an unknown CommitPolicy
+func (c *Config) Validate() error {
+	switch c.CommitPolicy {
+	case "", CommitRetry, CommitHalt, CommitUnhealthy:
+		return nil
+	default:
+		return fmt.Errorf("Unknown CommitPolicy %q, expected %s, %s or %s",
+			c.CommitPolicy, CommitRetry, CommitHalt, CommitUnhealthy)
+	}
+}
+
+//commitPolicy returns the CommitPolicy, or CommitRetry if it is not set
+func (c *Config) commitPolicy() string {
+	if c.CommitPolicy == "" {
+		return CommitRetry
+	}
+	return c.CommitPolicy
+}
+
+//catchUpLimit is the number of Events we are prepared to receive in
+//SyncLimit-sized pages, instead of fast-forwarding. Archive nodes keep the
+//whole hashgraph and never fast-forward.
+func (c *Config) catchUpLimit() int {
+	if c.Archive {
+		return math.MaxInt32
+	}
+	return c.CatchUpLimit
+}
+
+//fastForwardQuorum is the number of peers that must agree on the Block to
+//fast-forward to. It cannot be more than the number of other peers.
+func (c *Config) fastForwardQuorum(peers int) int {
+	quorum := c.FastForwardQuorum
+	if quorum > peers-1 {
+		quorum = peers - 1
+	}
+	if quorum < 1 {
+		quorum = 1
+	}
+	return quorum
+}
+
+//clock returns the Clock, or the system clock if none is set
+func (c *Config) clock() Clock {
+	if c.Clock == nil {
+		return systemClock{}
+	}
+	return c.Clock
+}
+
+//controlTimer creates the ControlTimer that paces gossip. With
+//AdaptiveHeartbeat, the heartbeat varies between MinHeartbeat and MaxHeartbeat
+//depending on the load; otherwise it is HeartbeatTimeout plus random jitter,
+//drawn from rnd.
+func (c *Config) controlTimer(load func() HeartbeatLoad, rnd *rand.Rand) *ControlTimer {
+	if !c.AdaptiveHeartbeat || c.HeartbeatTimeout == 0 {
+		return newRandomControlTimer(c.HeartbeatTimeout, c.clock(), rnd.Int63)
+	}
+	min, max := c.MinHeartbeat, c.MaxHeartbeat
+	if min <= 0 {
+		min = time.Millisecond
 	}
+	heartbeat := NewAdaptiveHeartbeat(c.HeartbeatTimeout, min, max, load)
+	heartbeat.rand = rnd.Int63n
+	return newAdaptiveControlTimer(heartbeat, c.clock())
 }
 
 func TestConfig(t *testing.T) *Config {
@@ -19,7 +19,7 @@ type ControlTimer struct {
 func NewControlTimer(timerFactory timerFactory) *ControlTimer {
 	return &ControlTimer{
 		timerFactory: timerFactory,
-		tickCh:       make(chan struct{}),
+		tickCh:       make(chan struct{}, 1),
 		resetCh:      make(chan struct{}),
 		stopCh:       make(chan struct{}),
 		shutdownCh:   make(chan struct{}),
@@ -27,14 +27,20 @@ func NewControlTimer(timerFactory timerFactory) *ControlTimer {
 }
 
 func NewRandomControlTimer(base time.Duration) *ControlTimer {
+	return newRandomControlTimer(base, systemClock{}, rand.Int63)
+}
+
+//newRandomControlTimer is NewRandomControlTimer with the node's Clock and
+//random source
+func newRandomControlTimer(base time.Duration, clock Clock, int63 func() int64) *ControlTimer {
 
 	randomTimeout := func() <-chan time.Time {
 		minVal := base
 		if minVal == 0 {
 			return nil
 		}
-		extra := (time.Duration(rand.Int63()) % minVal)
-		return time.After(minVal + extra)
+		extra := (time.Duration(int63()) % minVal)
+		return clock.After(minVal + extra)
 	}
 	return NewControlTimer(randomTimeout)
 }
@@ -50,8 +56,14 @@ func (c *ControlTimer) Run() {
 	for {
 		select {
 		case <-timer:
-			c.tickCh <- struct{}{}
+			//Nobody listens while the node is catching up; the tick is kept
+			//until babbling resumes, instead of blocking the routines that
+			//reset the timer.
 			c.set = false
+			select {
+			case c.tickCh <- struct{}{}:
+			default:
+			}
 		case <-c.resetCh:
 			timer = setTimer()
 		case <-c.stopCh:
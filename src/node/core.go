@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/mosaicnetworks/babble/src/common"
 	"github.com/mosaicnetworks/babble/src/crypto"
 	hg "github.com/mosaicnetworks/babble/src/hashgraph"
 	"github.com/mosaicnetworks/babble/src/peers"
@@ -21,6 +23,10 @@ type Core struct {
 	hexID  string
 	hg     *hg.Hashgraph
 
+	//observer is set when the key is not among the participants. Observers
+	//follow consensus but never create Events or sign Blocks.
+	observer bool
+
 	participants *peers.Peers //[PubKey] => id
 	Head         string
 	Seq          int
@@ -28,6 +34,14 @@ type Core struct {
 	transactionPool    [][]byte
 	blockSignaturePool []hg.BlockSignature
 
+	//notifier is set by the Node to publish Notifications about Events, Rounds
+	//and Blocks
+	notifier *notifier
+
+	//signer signs Events and Blocks. It is crypto.Sign unless the Node sets
+	//Config.Signer.
+	signer crypto.Signer
+
 	logger *logrus.Entry
 }
 
@@ -52,10 +66,17 @@ func NewCore(
 		participants:       participants,
 		transactionPool:    [][]byte{},
 		blockSignaturePool: []hg.BlockSignature{},
+		signer:             crypto.Sign,
 		logger:             logEntry,
 		Head:               "",
 		Seq:                -1,
 	}
+
+	if _, ok := participants.ByPubKey[core.HexID()]; !ok {
+		logEntry.Debug("Key not in participants; running as observer")
+		core.observer = true
+	}
+
 	return core
 }
 
@@ -70,6 +91,11 @@ func (c *Core) PubKey() []byte {
 	return c.pubKey
 }
 
+//Observer returns true if the Core does not belong to a validator
+func (c *Core) Observer() bool {
+	return c.observer
+}
+
 func (c *Core) HexID() string {
 	if c.hexID == "" {
 		pubKey := c.PubKey()
@@ -80,6 +106,11 @@ func (c *Core) HexID() string {
 
 func (c *Core) SetHeadAndSeq() error {
 
+	//Observers have no Events of their own
+	if c.observer {
+		return nil
+	}
+
 	var head string
 	var seq int
 
@@ -123,12 +154,13 @@ func (c *Core) Bootstrap() error {
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func (c *Core) SignAndInsertSelfEvent(event hg.Event) error {
-	if err := event.Sign(c.key); err != nil {
+	if err := event.SignWith(c.key, c.signer); err != nil {
 		return err
 	}
 	if err := c.InsertEvent(event, true); err != nil {
 		return err
 	}
+	c.notifier.publish(Notification{Type: SelfEventCreated, Event: &event})
 	return nil
 }
 
@@ -140,6 +172,7 @@ func (c *Core) InsertEvent(event hg.Event, setWireInfo bool) error {
 		c.Head = event.Hex()
 		c.Seq = event.Index()
 	}
+	c.notifier.publish(Notification{Type: EventInserted, Event: &event})
 	return nil
 }
 
@@ -150,7 +183,10 @@ func (c *Core) KnownEvents() map[int]int {
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func (c *Core) SignBlock(block hg.Block) (hg.BlockSignature, error) {
-	sig, err := block.Sign(c.key)
+	if c.observer {
+		return hg.BlockSignature{}, fmt.Errorf("Observers do not sign Blocks")
+	}
+	sig, err := block.SignWith(c.key, c.signer)
 	if err != nil {
 		return hg.BlockSignature{}, err
 	}
@@ -160,9 +196,30 @@ func (c *Core) SignBlock(block hg.Block) (hg.BlockSignature, error) {
 	return sig, c.hg.Store.SetBlock(block)
 }
 
+//SetBlock saves a Block without signing it. Observers use it to record the
+//StateHash against which the validators' signatures are verified.
+func (c *Core) SetBlock(block hg.Block) error {
+	return c.hg.Store.SetBlock(block)
+}
+
+//SetReceipts stores the results of the transactions in a Block
+func (c *Core) SetReceipts(block hg.Block, results []hg.TxResult) error {
+	receipts, err := hg.NewReceipts(block, results)
+	if err != nil {
+		return err
+	}
+	return c.hg.Store.SetReceipts(block.Index(), receipts)
+}
+
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func (c *Core) OverSyncLimit(knownEvents map[int]int, syncLimit int) bool {
+	return c.UnknownCount(knownEvents) > syncLimit
+}
+
+//UnknownCount returns the number of Events that we know of and that are not
+//in knownEvents
+func (c *Core) UnknownCount(knownEvents map[int]int) int {
 	totUnknown := 0
 	myKnownEvents := c.KnownEvents()
 	for i, li := range myKnownEvents {
@@ -170,10 +227,7 @@ func (c *Core) OverSyncLimit(knownEvents map[int]int, syncLimit int) bool {
 			totUnknown += li - knownEvents[i]
 		}
 	}
-	if totUnknown > syncLimit {
-		return true
-	}
-	return false
+	return totUnknown
 }
 
 func (c *Core) GetAnchorBlockWithFrame() (hg.Block, hg.Frame, error) {
@@ -187,7 +241,10 @@ func (c *Core) EventDiff(known map[int]int) (events []hg.Event, err error) {
 	//compare this to our view of events and fill unknown with events that we know of
 	// and the other doesnt
 	for id, ct := range known {
-		peer := c.participants.ById[id]
+		peer, ok := c.participants.ById[id]
+		if !ok {
+			return []hg.Event{}, common.NewStoreErr("Participants", common.UnknownParticipant, strconv.Itoa(id))
+		}
 		//get participant Events with index > ct
 		participantEvents, err := c.hg.Store.ParticipantEvents(peer.PubKeyHex, ct)
 		if err != nil {
@@ -214,6 +271,10 @@ func (c *Core) Sync(unknownEvents []hg.WireEvent) error {
 		"block_signature_pool": len(c.blockSignaturePool),
 	}).Debug("Sync")
 
+	//Concurrent syncs may overlap, so some of the Events might have been
+	//inserted since the request was made. Those are skipped.
+	known := c.KnownEvents()
+
 	otherHead := ""
 	//add unknown events
 	for k, we := range unknownEvents {
@@ -223,8 +284,10 @@ func (c *Core) Sync(unknownEvents []hg.WireEvent) error {
 			return err
 
 		}
-		if err := c.InsertEvent(*ev, false); err != nil {
-			return err
+		if index, ok := known[we.Body.CreatorID]; !ok || we.Body.Index > index {
+			if err := c.InsertEvent(*ev, false); err != nil {
+				return err
+			}
 		}
 		//assume last event corresponds to other-head
 		if k == len(unknownEvents)-1 {
@@ -237,7 +300,9 @@ func (c *Core) Sync(unknownEvents []hg.WireEvent) error {
 	return c.AddSelfEvent(otherHead)
 }
 
-func (c *Core) FastForward(peer string, block hg.Block, frame hg.Frame) error {
+//CheckFastForward verifies the Block signatures and that the Frame matches the
+//Block, without modifying the Hashgraph
+func (c *Core) CheckFastForward(block hg.Block, frame hg.Frame) error {
 
 	//Check Block Signatures
 	err := c.hg.CheckBlock(block)
@@ -254,6 +319,16 @@ func (c *Core) FastForward(peer string, block hg.Block, frame hg.Frame) error {
 		return fmt.Errorf("Invalid Frame Hash")
 	}
 
+	return nil
+}
+
+func (c *Core) FastForward(peer string, block hg.Block, frame hg.Frame) error {
+
+	err := c.CheckFastForward(block, frame)
+	if err != nil {
+		return err
+	}
+
 	err = c.hg.Reset(block, frame)
 	if err != nil {
 		return err
@@ -284,6 +359,11 @@ func (c *Core) FastForward(peer string, block hg.Block, frame hg.Frame) error {
 
 func (c *Core) AddSelfEvent(otherHead string) error {
 
+	//observers only follow the validators' Events
+	if c.observer {
+		return nil
+	}
+
 	//exit if there is nothing to record
 	if otherHead == "" && len(c.transactionPool) == 0 && len(c.blockSignaturePool) == 0 {
 		c.logger.Debug("Empty transaction pool and block signature pool")
@@ -333,6 +413,18 @@ func (c *Core) ToWire(events []hg.Event) ([]hg.WireEvent, error) {
 }
 
 func (c *Core) RunConsensus() error {
+	if c.notifier != nil {
+		//the Hashgraph updates these indexes in place, so copy them
+		lastRound, anchorBlock := -1, -1
+		if c.hg.LastConsensusRound != nil {
+			lastRound = *c.hg.LastConsensusRound
+		}
+		if c.hg.AnchorBlock != nil {
+			anchorBlock = *c.hg.AnchorBlock
+		}
+		defer c.notifyConsensus(lastRound, anchorBlock)
+	}
+
 	start := time.Now()
 	err := c.hg.DivideRounds()
 	c.logger.WithField("duration", time.Since(start).Nanoseconds()).Debug("DivideRounds()")
@@ -376,6 +468,33 @@ func (c *Core) RunConsensus() error {
 	return nil
 }
 
+//notifyConsensus publishes the Rounds decided after lastRound, and the
+//AnchorBlock if it moved past anchorBlock
+func (c *Core) notifyConsensus(lastRound int, anchorBlock int) {
+	if r := c.hg.LastConsensusRound; r != nil {
+		for i := lastRound + 1; i <= *r; i++ {
+			round, err := c.hg.Store.GetRound(i)
+			if err != nil {
+				continue
+			}
+			c.notifier.publish(Notification{
+				Type: RoundDecided,
+				Round: &DecidedRound{
+					Index:           i,
+					FamousWitnesses: round.FamousWitnesses(),
+				},
+			})
+		}
+	}
+
+	if a := c.hg.AnchorBlock; a != nil && *a > anchorBlock {
+		block, err := c.hg.Store.GetBlock(*a)
+		if err == nil {
+			c.notifier.publish(Notification{Type: AnchorBlockSet, Block: &block})
+		}
+	}
+}
+
 func (c *Core) AddTransactions(txs [][]byte) {
 	c.transactionPool = append(c.transactionPool, txs...)
 }
@@ -446,8 +565,11 @@ func (c *Core) GetLastBlockIndex() int {
 	return c.hg.Store.LastBlockIndex()
 }
 
+//NeedGossip is always true for observers, which have no Events of their own
+//and must keep pulling from the validators
 func (c *Core) NeedGossip() bool {
-	return c.hg.PendingLoadedEvents > 0 ||
+	return c.observer ||
+		c.hg.PendingLoadedEvents > 0 ||
 		len(c.transactionPool) > 0 ||
 		len(c.blockSignaturePool) > 0
 }
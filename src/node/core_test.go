@@ -155,6 +155,12 @@ func TestEventDiff(t *testing.T) {
 		}
 	}
 
+	//A peer that claims to know about an unknown participant
+	knownBy1[42] = 0
+	if _, err := cores[0].EventDiff(knownBy1); err == nil {
+		t.Fatal("EventDiff should fail with an unknown participant")
+	}
+
 }
 func TestSync(t *testing.T) {
 	cores, _, index := initCores(3, t)
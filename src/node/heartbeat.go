@@ -0,0 +1,130 @@
+package node
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//HeartbeatLoad is a snapshot of the signals that drive an AdaptiveHeartbeat.
+//SyncRequests and SyncErrors are cumulative counters; the AdaptiveHeartbeat
+//only looks at how much they changed since the previous heartbeat.
+type HeartbeatLoad struct {
+	TransactionPool    int
+	UndeterminedEvents int
+	SyncRequests       int
+	SyncErrors         int
+}
+
+//AdaptiveHeartbeat computes the time until the next gossip from the current
+//load. The heartbeat is halved whenever the transaction pool or the number of
+//undetermined Events grows, and doubled when there is nothing to do. Failed
+//syncs stretch it further, up to twice its value when every sync failed. The
+//result always stays between min and max.
+type AdaptiveHeartbeat struct {
+	sync.Mutex
+
+	min, max time.Duration
+	current  time.Duration
+
+	load     func() HeartbeatLoad
+	prevLoad HeartbeatLoad
+
+	rand func(n int64) int64
+}
+
+//NewAdaptiveHeartbeat creates an AdaptiveHeartbeat that starts at base and
+//reads the load with the provided function.
+func NewAdaptiveHeartbeat(base, min, max time.Duration, load func() HeartbeatLoad) *AdaptiveHeartbeat {
+	if max < min {
+		max = min
+	}
+	return &AdaptiveHeartbeat{
+		min:     min,
+		max:     max,
+		current: clampDuration(base, min, max),
+		load:    load,
+		rand:    rand.Int63n,
+	}
+}
+
+//Next updates the heartbeat with the current load and returns it
+func (a *AdaptiveHeartbeat) Next() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+
+	load := a.load()
+	pending := load.TransactionPool + load.UndeterminedEvents
+	prevPending := a.prevLoad.TransactionPool + a.prevLoad.UndeterminedEvents
+
+	next := a.current
+	switch {
+	case pending == 0:
+		next *= 2
+	case pending > prevPending:
+		next /= 2
+	}
+
+	syncs := load.SyncRequests - a.prevLoad.SyncRequests
+	errors := load.SyncErrors - a.prevLoad.SyncErrors
+	if syncs > 0 && errors > 0 {
+		next += time.Duration(int64(next) * int64(errors) / int64(syncs))
+	}
+
+	a.current = clampDuration(next, a.min, a.max)
+	a.prevLoad = load
+
+	return a.current
+}
+
+//Current returns the last computed heartbeat
+func (a *AdaptiveHeartbeat) Current() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+	return a.current
+}
+
+//Timeout returns the next heartbeat with random jitter, like
+//NewRandomControlTimer, without exceeding max.
+func (a *AdaptiveHeartbeat) Timeout() time.Duration {
+	d := a.Next()
+	if d == 0 {
+		return 0
+	}
+	extra := d
+	if a.max-d < extra {
+		extra = a.max - d
+	}
+	if extra > 0 {
+		d += time.Duration(a.rand(int64(extra)))
+	}
+	return d
+}
+
+//NewAdaptiveControlTimer creates a ControlTimer whose timeouts are computed by
+//an AdaptiveHeartbeat
+func NewAdaptiveControlTimer(heartbeat *AdaptiveHeartbeat) *ControlTimer {
+	return newAdaptiveControlTimer(heartbeat, systemClock{})
+}
+
+//newAdaptiveControlTimer is NewAdaptiveControlTimer with the node's Clock
+func newAdaptiveControlTimer(heartbeat *AdaptiveHeartbeat, clock Clock) *ControlTimer {
+	adaptiveTimeout := func() <-chan time.Time {
+		d := heartbeat.Timeout()
+		if d == 0 {
+			return nil
+		}
+		return clock.After(d)
+	}
+	return NewControlTimer(adaptiveTimeout)
+}
+
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+)
+
+func TestAdaptiveHeartbeat(t *testing.T) {
+	load := HeartbeatLoad{}
+	min, max := 10*time.Millisecond, 400*time.Millisecond
+
+	hb := NewAdaptiveHeartbeat(100*time.Millisecond, min, max, func() HeartbeatLoad {
+		return load
+	})
+
+	steps := []struct {
+		txs, undetermined, syncs, errors int
+		expected                         time.Duration
+	}{
+		//Back off when idle, up to max
+		{0, 0, 0, 0, 200 * time.Millisecond},
+		{0, 0, 0, 0, 400 * time.Millisecond},
+		{0, 0, 0, 0, 400 * time.Millisecond},
+		//Speed up while the load grows, down to min
+		{5, 0, 0, 0, 200 * time.Millisecond},
+		{5, 10, 0, 0, 100 * time.Millisecond},
+		{5, 20, 0, 0, 50 * time.Millisecond},
+		{10, 30, 0, 0, 25 * time.Millisecond},
+		{10, 50, 0, 0, 12500 * time.Microsecond},
+		{10, 60, 0, 0, 10 * time.Millisecond},
+		//Hold while the load is steady
+		{10, 60, 0, 0, 10 * time.Millisecond},
+		//Back off when syncs fail: 4 failures out of 8 syncs
+		{10, 60, 8, 4, 15 * time.Millisecond},
+		//Only new failures count
+		{10, 60, 10, 4, 15 * time.Millisecond},
+		{10, 60, 12, 6, 30 * time.Millisecond},
+	}
+
+	for i, s := range steps {
+		load = HeartbeatLoad{s.txs, s.undetermined, s.syncs, s.errors}
+		if d := hb.Next(); d != s.expected {
+			t.Fatalf("step %d: heartbeat should be %s, not %s", i, s.expected, d)
+		}
+	}
+
+	//Jitter never exceeds max
+	load = HeartbeatLoad{}
+	for i := 0; i < 100; i++ {
+		if d := hb.Timeout(); d < min || d > max {
+			t.Fatalf("timeout %s is out of bounds", d)
+		}
+	}
+}
+
+func TestAdaptiveHeartbeatGossip(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	for _, n := range nodes {
+		n.conf.AdaptiveHeartbeat = true
+		n.controlTimer = n.conf.controlTimer(n.heartbeatLoad, n.rand)
+	}
+
+	err := gossip(nodes, 50, true, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkGossip(nodes, 0, t)
+}
@@ -1,15 +1,20 @@
 package node
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"strconv"
 
+	"github.com/mosaicnetworks/babble/src/common"
 	hg "github.com/mosaicnetworks/babble/src/hashgraph"
 	"github.com/mosaicnetworks/babble/src/net"
 	"github.com/mosaicnetworks/babble/src/peers"
@@ -31,22 +36,59 @@ type Node struct {
 	peerSelector PeerSelector
 	selectorLock sync.Mutex
 
+	//gossipSlots bounds the number of concurrent gossip routines
+	gossipSlots chan struct{}
+
 	trans net.Transport
 	netCh <-chan net.RPC
 
 	proxy    proxy.AppProxy
-	submitCh chan []byte
+	submitCh chan proxy.SubmittedTx
+
+	//checkedCh passes the transactions that the App accepted to the background
+	//routine, which adds them to the pool. It is created by startRun.
+	checkedCh chan proxy.SubmittedTx
 
 	commitCh chan hg.Block
 
 	shutdownCh chan struct{}
 
+	//lifecycleLock serializes Pause, Resume and Shutdown. runWg tracks the
+	//routines started by Run, which Pause waits for, and runGossip remembers
+	//the argument of Run for Resume.
+	lifecycleLock sync.Mutex
+	runWg         sync.WaitGroup
+	runGossip     bool
+
 	controlTimer *ControlTimer
 
+	//clock and rand are the Clock and random source used for timers, backoffs
+	//and peer selection (cf Config.Clock and Config.Seed)
+	clock Clock
+	rand  *rand.Rand
+
 	start        time.Time
 	syncRequests int
 	syncErrors   int
 
+	//fastForwardTries counts consecutive failed attempts to fast-forward
+	fastForwardTries int
+	snapshotDownload *snapshotDownload
+
+	//appUnhealthy is set, with the CommitUnhealthy policy, when the App fails
+	//to commit a Block. It is reset by a successful fast-forward.
+	appUnhealthy   int32
+	commitFailures int32
+
+	//exitReason is set before the node shuts itself down
+	exitReason     error
+	exitReasonLock sync.Mutex
+
+	//notifier publishes Notifications to Subscriptions, and stateChanges is
+	//the Subscription of the handler set by SetStateChangeHandler
+	notifier     *notifier
+	stateChanges *Subscription
+
 	needBoostrap bool
 }
 
@@ -65,23 +107,47 @@ func NewNode(conf *Config,
 	commitCh := make(chan hg.Block, 400)
 	core := NewCore(id, key, pmap, store, commitCh, conf.Logger)
 
-	peerSelector := NewRandomPeerSelector(participants, localAddr)
+	notifier := newNotifier()
+	core.notifier = notifier
+	if conf.Signer != nil {
+		core.signer = conf.Signer
+	}
 
 	node := Node{
-		id:           id,
-		conf:         conf,
-		core:         &core,
-		localAddr:    localAddr,
-		logger:       conf.Logger.WithField("this_id", id),
-		peerSelector: peerSelector,
-		trans:        trans,
-		netCh:        trans.Consumer(),
-		proxy:        proxy,
-		submitCh:     proxy.SubmitCh(),
-		commitCh:     commitCh,
-		shutdownCh:   make(chan struct{}),
-		controlTimer: NewRandomControlTimer(conf.HeartbeatTimeout),
+		id:          id,
+		conf:        conf,
+		core:        &core,
+		localAddr:   localAddr,
+		logger:      conf.Logger.WithField("this_id", id),
+		trans:       trans,
+		netCh:       trans.Consumer(),
+		proxy:       proxy,
+		submitCh:    proxy.SubmitCh(),
+		commitCh:    commitCh,
+		shutdownCh:  make(chan struct{}),
+		gossipSlots: make(chan struct{}, conf.fanout()),
+		notifier:    notifier,
+		clock:       conf.clock(),
+		rand:        newRand(conf.Seed),
+	}
+
+	node.onChange = node.stateChanged
+
+	peerSelector, err := NewPeerSelector(conf.PeerSelector,
+		participants,
+		localAddr)
+	if err != nil {
+		node.logger.WithField("error", err).Error("Using random PeerSelector")
+		peerSelector = NewRandomPeerSelector(participants, localAddr)
+	}
+	if ps, ok := peerSelector.(interface {
+		setEnvironment(func() time.Time, *rand.Rand)
+	}); ok {
+		ps.setEnvironment(node.clock.Now, node.rand)
 	}
+	node.peerSelector = peerSelector
+
+	node.controlTimer = conf.controlTimer(node.heartbeatLoad, node.rand)
 
 	node.needBoostrap = store.NeedBoostrap()
 
@@ -93,6 +159,10 @@ func NewNode(conf *Config,
 }
 
 func (n *Node) Init() error {
+	if err := n.conf.Validate(); err != nil {
+		return err
+	}
+
 	peerAddresses := []string{}
 	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
 		peerAddresses = append(peerAddresses, p.NetAddr)
@@ -111,10 +181,26 @@ func (n *Node) Init() error {
 
 func (n *Node) RunAsync(gossip bool) {
 	n.logger.Debug("runasync")
-	go n.Run(gossip)
+	n.startRun(gossip)
+	go n.run(gossip)
 }
 
 func (n *Node) Run(gossip bool) {
+	n.startRun(gossip)
+	n.run(gossip)
+}
+
+//startRun registers the routines of run before they start, so that Pause cannot
+//wait for them too early, and creates the channels they share
+func (n *Node) startRun(gossip bool) {
+	n.runWg.Add(4)
+	n.runGossip = gossip
+	n.checkedCh = make(chan proxy.SubmittedTx)
+}
+
+func (n *Node) run(gossip bool) {
+	defer n.runWg.Done()
+
 	//The ControlTimer allows the background routines to control the
 	//heartbeat timer when the node is in the Babbling state. The timer should
 	//only be running when there are uncommitted transactions in the system.
@@ -122,10 +208,35 @@ func (n *Node) Run(gossip bool) {
 
 	//Execute some background work regardless of the state of the node.
 	//Process RPC requests as well as SumbitTx and CommitBlock requests
-	go n.doBackgroundWork()
+	go func() {
+		defer n.runWg.Done()
+		n.doBackgroundWork()
+	}()
+
+	//Commit Blocks in order, in a routine of their own so that retrying a Block
+	//does not hold up RPC requests
+	go func() {
+		defer n.runWg.Done()
+		n.doCommits()
+	}()
+
+	//Check submitted transactions with the App, in a routine of their own for
+	//the same reason
+	go func() {
+		defer n.runWg.Done()
+		n.checkTransactions()
+	}()
 
 	//Execute Node State Machine
 	for {
+		//Pause and Shutdown wait for this routine after closing the
+		//shutdownCh, whatever the state says
+		select {
+		case <-n.shutdownCh:
+			return
+		default:
+		}
+
 		// Run different routines depending on node state
 		state := n.getState()
 		n.logger.WithField("state", state.String()).Debug("Run loop")
@@ -135,7 +246,9 @@ func (n *Node) Run(gossip bool) {
 			n.babble(gossip)
 		case CatchingUp:
 			n.fastForward()
-		case Shutdown:
+		case Failed:
+			<-n.shutdownCh
+		case Shutdown, Paused:
 			return
 		}
 	}
@@ -148,16 +261,26 @@ func (n *Node) doBackgroundWork() {
 			n.goFunc(func() {
 				n.logger.Debug("Processing RPC")
 				n.processRPC(rpc)
-				if n.core.NeedGossip() && !n.controlTimer.set {
+				if n.needGossip() && !n.controlTimer.set {
 					n.controlTimer.resetCh <- struct{}{}
 				}
 			})
-		case t := <-n.submitCh:
+		case t := <-n.checkedCh:
 			n.logger.Debug("Adding Transaction")
-			n.addTransaction(t)
+			n.addTransaction(t.Tx)
+			t.Respond(nil)
 			if !n.controlTimer.set {
 				n.controlTimer.resetCh <- struct{}{}
 			}
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+func (n *Node) doCommits() {
+	for {
+		select {
 		case block := <-n.commitCh:
 			n.logger.WithFields(logrus.Fields{
 				"index":          block.Index(),
@@ -167,18 +290,44 @@ func (n *Node) doBackgroundWork() {
 			if err := n.commit(block); err != nil {
 				n.logger.WithField("error", err).Error("Committing Block")
 			}
+			if len(n.commitCh) == 0 {
+				n.resumeConsensus()
+			}
 		case <-n.shutdownCh:
 			return
 		}
 	}
 }
 
+//resumeConsensus processes the decided Rounds that the Hashgraph left pending
+//because commitCh was full. Otherwise, they would wait for the next sync.
+func (n *Node) resumeConsensus() {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+
+	if pending := n.core.hg.PendingRounds; len(pending) == 0 || !pending[0].Decided {
+		return
+	}
+	if err := n.core.RunConsensus(); err != nil {
+		n.logger.WithField("error", err).Error("Resuming consensus")
+	}
+}
+
 //babble is interrupted when a gossip function, launched asychronously, changes
 //the state from Babbling to CatchingUp, or when the node is shutdown.
 //Otherwise, it periodicaly initiates gossip while there is something to gossip
 //about, or waits.
 func (n *Node) babble(gossip bool) {
-	returnCh := make(chan struct{})
+	//Several gossip routines may hit the SyncLimit concurrently; only the first
+	//signal matters, the others must not block.
+	returnCh := make(chan struct{}, 1)
+
+	//The timer may have been stopped while the node was catching up; nothing
+	//else would restart it if no new transactions come in.
+	if n.needGossip() && !n.controlTimer.set {
+		n.controlTimer.resetCh <- struct{}{}
+	}
+
 	for {
 		select {
 		case <-n.controlTimer.tickCh:
@@ -186,11 +335,10 @@ func (n *Node) babble(gossip bool) {
 				proceed, err := n.preGossip()
 				if proceed && err == nil {
 					n.logger.Debug("Time to gossip!")
-					peer := n.peerSelector.Next()
-					n.goFunc(func() { n.gossip(peer.NetAddr, returnCh) })
+					n.fanOut(returnCh)
 				}
 			}
-			if !n.core.NeedGossip() {
+			if !n.needGossip() {
 				n.controlTimer.stopCh <- struct{}{}
 			} else if !n.controlTimer.set {
 				n.controlTimer.resetCh <- struct{}{}
@@ -207,12 +355,7 @@ func (n *Node) processRPC(rpc net.RPC) {
 
 	if s := n.getState(); s != Babbling {
 		n.logger.WithField("state", s.String()).Debug("Discarding RPC Request")
-		//XXX Use a SyncResponse by default but this should be either a special
-		//ErrorResponse type or a type that corresponds to the request
-		resp := &net.SyncResponse{
-			FromID: n.id,
-		}
-		rpc.Respond(resp, fmt.Errorf("not ready: %s", s.String()))
+		rpc.Respond(nil, net.NewRPCError(net.CodeNotReady, "state is %s", s.String()))
 		return
 	}
 
@@ -223,6 +366,8 @@ func (n *Node) processRPC(rpc net.RPC) {
 		n.processEagerSyncRequest(rpc, cmd)
 	case *net.FastForwardRequest:
 		n.processFastForwardRequest(rpc, cmd)
+	case *net.SnapshotChunkRequest:
+		n.processSnapshotChunkRequest(rpc, cmd)
 	default:
 		n.logger.WithField("cmd", rpc.Command).Error("Unexpected RPC command")
 		rpc.Respond(nil, fmt.Errorf("unexpected command"))
@@ -235,18 +380,30 @@ func (n *Node) processSyncRequest(rpc net.RPC, cmd *net.SyncRequest) {
 		"known":   cmd.Known,
 	}).Debug("process SyncRequest")
 
+	if peer, ok := n.peerSelector.Peers().ById[cmd.FromID]; ok {
+		n.selectorLock.Lock()
+		n.peerSelector.UpdateKnown(peer.NetAddr, cmd.Known)
+		n.selectorLock.Unlock()
+	}
+
 	resp := &net.SyncResponse{
 		FromID: n.id,
 	}
 	var respErr error
 
-	//Check sync limit
+	//Check sync limit. Requesters that are only moderately behind receive the
+	//diff in SyncLimit-sized pages; the others have to fast-forward.
 	n.coreLock.Lock()
-	overSyncLimit := n.core.OverSyncLimit(cmd.Known, n.conf.SyncLimit)
+	unknown := n.core.UnknownCount(cmd.Known)
 	n.coreLock.Unlock()
-	if overSyncLimit {
+	if unknown > n.conf.SyncLimit && unknown > cmd.CatchUpLimit {
 		n.logger.Debug("SyncLimit")
 		resp.SyncLimit = true
+		//Older requesters, which do not send a CatchUpLimit, only understand
+		//the SyncLimit flag and would treat the error as a failed sync
+		if cmd.CatchUpLimit > 0 {
+			respErr = net.NewRPCError(net.CodeSyncLimit, "%d unknown Events", unknown)
+		}
 	} else {
 		//Compute Diff
 		start := time.Now()
@@ -257,7 +414,12 @@ func (n *Node) processSyncRequest(rpc net.RPC, cmd *net.SyncRequest) {
 		n.logger.WithField("duration", elapsed.Nanoseconds()).Debug("Diff()")
 		if err != nil {
 			n.logger.WithField("error", err).Error("Calculating Diff")
-			respErr = err
+			respErr = rpcError(err)
+		}
+		if len(eventDiff) > n.conf.SyncLimit {
+			n.logger.WithField("unknown", unknown).Debug("Paging SyncResponse")
+			eventDiff = eventDiff[:n.conf.SyncLimit]
+			resp.More = true
 		}
 
 		//Convert to WireEvents
@@ -280,6 +442,7 @@ func (n *Node) processSyncRequest(rpc net.RPC, cmd *net.SyncRequest) {
 		"events":     len(resp.Events),
 		"known":      resp.Known,
 		"sync_limit": resp.SyncLimit,
+		"more":       resp.More,
 		"error":      respErr,
 	}).Debug("Responding to SyncRequest")
 
@@ -292,20 +455,26 @@ func (n *Node) processEagerSyncRequest(rpc net.RPC, cmd *net.EagerSyncRequest) {
 		"events":  len(cmd.Events),
 	}).Debug("EagerSyncRequest")
 
-	success := true
+	resp := &net.EagerSyncResponse{
+		FromID: n.id,
+	}
+
+	//Only participants push Events
+	if _, ok := n.peerSelector.Peers().ById[cmd.FromID]; !ok {
+		rpc.Respond(resp, net.NewRPCError(net.CodeUnknownParticipant,
+			"participant %d", cmd.FromID))
+		return
+	}
+
 	n.coreLock.Lock()
 	err := n.sync(cmd.Events)
 	n.coreLock.Unlock()
 	if err != nil {
 		n.logger.WithField("error", err).Error("sync()")
-		success = false
 	}
 
-	resp := &net.EagerSyncResponse{
-		FromID:  n.id,
-		Success: success,
-	}
-	rpc.Respond(resp, err)
+	resp.Success = err == nil
+	rpc.Respond(resp, rpcError(err))
 }
 
 func (n *Node) processFastForwardRequest(rpc net.RPC, cmd *net.FastForwardRequest) {
@@ -318,6 +487,22 @@ func (n *Node) processFastForwardRequest(rpc net.RPC, cmd *net.FastForwardReques
 	}
 	var respErr error
 
+	//Another node is cross-checking a Block and its snapshot
+	if cmd.BlockOnly {
+		block, err := n.GetBlock(cmd.BlockIndex)
+		if err != nil {
+			n.logger.WithField("error", err).Debug("Getting Block")
+		} else {
+			resp.Manifest, err = n.proxy.GetSnapshotManifest(cmd.BlockIndex)
+			if err != nil {
+				n.logger.WithField("error", err).Debug("Getting Snapshot Manifest")
+			}
+		}
+		resp.Block = block
+		rpc.Respond(resp, err)
+		return
+	}
+
 	//Get latest Frame
 	n.coreLock.Lock()
 	block, frame, err := n.core.GetAnchorBlockWithFrame()
@@ -329,13 +514,13 @@ func (n *Node) processFastForwardRequest(rpc net.RPC, cmd *net.FastForwardReques
 	resp.Block = block
 	resp.Frame = frame
 
-	//Get snapshot
-	snapshot, err := n.proxy.GetSnapshot(block.Index())
+	//Get snapshot manifest; the chunks are requested separately
+	manifest, err := n.proxy.GetSnapshotManifest(block.Index())
 	if err != nil {
-		n.logger.WithField("error", err).Error("Getting Snapshot")
+		n.logger.WithField("error", err).Error("Getting Snapshot Manifest")
 		respErr = err
 	}
-	resp.Snapshot = snapshot
+	resp.Manifest = manifest
 
 	n.logger.WithFields(logrus.Fields{
 		"Events": len(resp.Frame.Events),
@@ -344,6 +529,26 @@ func (n *Node) processFastForwardRequest(rpc net.RPC, cmd *net.FastForwardReques
 	rpc.Respond(resp, respErr)
 }
 
+func (n *Node) processSnapshotChunkRequest(rpc net.RPC, cmd *net.SnapshotChunkRequest) {
+	n.logger.WithFields(logrus.Fields{
+		"from":        cmd.FromID,
+		"block_index": cmd.BlockIndex,
+		"chunk":       cmd.Chunk,
+	}).Debug("process SnapshotChunkRequest")
+
+	resp := &net.SnapshotChunkResponse{
+		FromID: n.id,
+	}
+
+	data, err := n.proxy.GetSnapshotChunk(cmd.BlockIndex, cmd.Chunk)
+	if err != nil {
+		n.logger.WithField("error", err).Error("Getting Snapshot Chunk")
+	}
+	resp.Data = data
+
+	rpc.Respond(resp, err)
+}
+
 func (n *Node) preGossip() (bool, error) {
 	n.coreLock.Lock()
 	defer n.coreLock.Unlock()
@@ -357,13 +562,45 @@ func (n *Node) preGossip() (bool, error) {
 	return true, nil
 }
 
+//fanOut starts gossip routines with up to Config.Fanout different peers. There
+//are never more than Fanout gossip routines running at the same time, and
+//never two with the same peer.
+func (n *Node) fanOut(returnCh chan struct{}) {
+	for i := 0; i < cap(n.gossipSlots); i++ {
+		select {
+		case n.gossipSlots <- struct{}{}:
+		default:
+			//All slots are taken
+			return
+		}
+
+		peer := n.nextPeer()
+		if peer == nil {
+			<-n.gossipSlots
+			return
+		}
+
+		n.goFunc(func() {
+			n.gossip(peer.NetAddr, returnCh)
+
+			n.selectorLock.Lock()
+			n.peerSelector.SetBusy(peer.NetAddr, false)
+			n.selectorLock.Unlock()
+			<-n.gossipSlots
+		})
+	}
+}
+
 //This function is usually called in a go-routine and needs to inform the
 //calling routine (usually the babble routine) when it is time to exit the
 //Babbling state and return.
 func (n *Node) gossip(peerAddr string, parentReturnCh chan struct{}) error {
 
-	//pull
-	syncLimit, otherKnownEvents, err := n.pull(peerAddr)
+	//pull, page after page if the peer is paging its response
+	syncLimit, more, otherKnownEvents, err := n.pull(peerAddr)
+	for err == nil && more && n.getState() == Babbling {
+		syncLimit, more, otherKnownEvents, err = n.pull(peerAddr)
+	}
 	if err != nil {
 		return err
 	}
@@ -371,15 +608,28 @@ func (n *Node) gossip(peerAddr string, parentReturnCh chan struct{}) error {
 	//check and handle syncLimit
 	if syncLimit {
 		n.logger.WithField("from", peerAddr).Debug("SyncLimit")
-		n.setState(CatchingUp)
-		parentReturnCh <- struct{}{}
+		if n.conf.Archive {
+			//Archive nodes must not skip any part of the hashgraph. The peer
+			//did not page its response; try another one.
+			n.logger.WithField("from", peerAddr).Error("SyncLimit on archive node")
+			return nil
+		}
+		//The node may have been paused or shut down in the meantime
+		if n.changeState(Babbling, CatchingUp) {
+			select {
+			case parentReturnCh <- struct{}{}:
+			default:
+			}
+		}
 		return nil
 	}
 
-	//push
-	err = n.push(peerAddr, otherKnownEvents)
-	if err != nil {
-		return err
+	//push, unless we are an observer and have nothing of our own to give
+	if !n.core.Observer() {
+		err = n.push(peerAddr, otherKnownEvents)
+		if err != nil {
+			return err
+		}
 	}
 
 	//update peer selector
@@ -394,30 +644,59 @@ func (n *Node) gossip(peerAddr string, parentReturnCh chan struct{}) error {
 	return nil
 }
 
-func (n *Node) pull(peerAddr string) (syncLimit bool, otherKnownEvents map[int]int, err error) {
+func (n *Node) pull(peerAddr string) (syncLimit bool, more bool, otherKnownEvents map[int]int, err error) {
 	//Compute Known
 	n.coreLock.Lock()
 	knownEvents := n.core.KnownEvents()
 	n.coreLock.Unlock()
 
 	//Send SyncRequest
-	start := time.Now()
+	start := n.clock.Now()
 	resp, err := n.requestSync(peerAddr, knownEvents)
-	elapsed := time.Since(start)
+	elapsed := n.clock.Now().Sub(start)
 	n.logger.WithField("duration", elapsed.Nanoseconds()).Debug("requestSync()")
-	if err != nil {
+	switch code, ok := net.Code(err); {
+	case ok && code == net.CodeSyncLimit:
+		n.selectorLock.Lock()
+		n.syncRequests++
+		n.peerSelector.UpdateSuccess(peerAddr, elapsed)
+		n.selectorLock.Unlock()
+		return true, false, nil, nil
+	case ok && code == net.CodeNotReady:
+		//The peer is catching up; leave it alone for a while
+		n.logger.WithFields(logrus.Fields{
+			"from":  peerAddr,
+			"error": err,
+		}).Debug("requestSync()")
+		n.selectorLock.Lock()
+		n.peerSelector.UpdateNotReady(peerAddr)
+		n.selectorLock.Unlock()
+		return false, false, nil, err
+	case err != nil:
 		n.logger.WithField("error", err).Error("requestSync()")
-		return false, nil, err
+		n.selectorLock.Lock()
+		n.syncRequests++
+		n.syncErrors++
+		n.peerSelector.UpdateFailure(peerAddr)
+		n.selectorLock.Unlock()
+		return false, false, nil, err
 	}
+
+	n.selectorLock.Lock()
+	n.syncRequests++
+	n.peerSelector.UpdateSuccess(peerAddr, elapsed)
+	n.peerSelector.UpdateKnown(peerAddr, resp.Known)
+	n.selectorLock.Unlock()
 	n.logger.WithFields(logrus.Fields{
 		"from_id":    resp.FromID,
 		"sync_limit": resp.SyncLimit,
+		"more":       resp.More,
 		"events":     len(resp.Events),
 		"known":      resp.Known,
 	}).Debug("SyncResponse")
 
 	if resp.SyncLimit {
-		return true, nil, nil
+		return true, false, nil, nil
 	}
 
 	if len(resp.Events) > 0 {
@@ -427,11 +706,11 @@ func (n *Node) pull(peerAddr string) (syncLimit bool, otherKnownEvents map[int]i
 		n.coreLock.Unlock()
 		if err != nil {
 			n.logger.WithField("error", err).Error("sync()")
-			return false, nil, err
+			return false, false, nil, err
 		}
 	}
 
-	return false, resp.Known, nil
+	return false, resp.More, resp.Known, nil
 }
 
 func (n *Node) push(peerAddr string, knownEvents map[int]int) error {
@@ -479,8 +758,19 @@ func (n *Node) push(peerAddr string, knownEvents map[int]int) error {
 	resp2, err := n.requestEagerSync(peerAddr, wireEvents)
 	elapsed = time.Since(start)
 	n.logger.WithField("duration", elapsed.Nanoseconds()).Debug("requestEagerSync()")
+	if code, ok := net.Code(err); ok && code == net.CodeNotReady {
+		//The peer started catching up since we pulled from it
+		n.logger.WithField("error", err).Debug("requestEagerSync()")
+		n.selectorLock.Lock()
+		n.peerSelector.UpdateNotReady(peerAddr)
+		n.selectorLock.Unlock()
+		return err
+	}
 	if err != nil {
 		n.logger.WithField("error", err).Error("requestEagerSync()")
+		n.selectorLock.Lock()
+		n.peerSelector.UpdateFailure(peerAddr)
+		n.selectorLock.Unlock()
 		return err
 	}
 	n.logger.WithFields(logrus.Fields{
@@ -491,60 +781,297 @@ func (n *Node) push(peerAddr string, knownEvents map[int]int) error {
 	return nil
 }
 
+//fastForward resets the Hashgraph and the App from a recent anchor Block. The
+//Block must be confirmed by Config.FastForwardQuorum peers, and the snapshot
+//must restore the Block's StateHash. Failed attempts are retried with an
+//exponential backoff; after Config.FastForwardTries of them, the node enters
+//the Failed state.
 func (n *Node) fastForward() error {
 	n.logger.Debug("IN CATCHING-UP STATE")
 
 	//wait until sync routines finish
 	n.waitRoutines()
 
-	//fastForwardRequest
-	peer := n.peerSelector.Next()
-	start := time.Now()
-	resp, err := n.requestFastForward(peer.NetAddr)
-	elapsed := time.Since(start)
-	n.logger.WithField("duration", elapsed.Nanoseconds()).Debug("requestFastForward()")
+	if n.fastForwardTries > 0 {
+		backoff := maxBackoff
+		if n.fastForwardTries < 8 {
+			backoff = minBackoff << uint(n.fastForwardTries-1)
+		}
+		select {
+		case <-n.clock.After(backoff):
+		case <-n.shutdownCh:
+			return nil
+		}
+	}
+	n.fastForwardTries++
+
+	err := n.tryFastForward()
 	if err != nil {
-		n.logger.WithField("error", err).Error("requestFastForward()")
+		n.logger.WithFields(logrus.Fields{
+			"error": err,
+			"tries": n.fastForwardTries,
+		}).Error("Fast Forwarding")
+		if max := n.conf.FastForwardTries; max > 0 && n.fastForwardTries >= max &&
+			n.changeState(CatchingUp, Failed) {
+			n.logger.Error("Giving up on Fast-Forward")
+		}
 		return err
 	}
+
+	n.fastForwardTries = 0
+
+	//The App was restored from a verified snapshot
+	atomic.StoreInt32(&n.appUnhealthy, 0)
+
+	n.logger.Debug("Fast-Forward OK")
+
+	if n.changeState(CatchingUp, Babbling) {
+		n.setStarting(true)
+	}
+
+	return nil
+}
+
+//tryFastForward asks peers, healthy ones first, for their anchor Block until
+//enough of them agree on it and on its snapshot Manifest. The Hashgraph is only
+//reset once the restored snapshot yields the StateHash of the Block.
+func (n *Node) tryFastForward() error {
+	quorum := n.conf.fastForwardQuorum(n.peerSelector.Peers().Len())
+
+	var (
+		resp         net.FastForwardResponse
+		from         *peers.Peer
+		blockHash    []byte
+		manifestHash []byte
+		agreed       []*peers.Peer
+	)
+
+	for _, peer := range n.fastForwardPeers() {
+		if from == nil {
+			//Get a Block, Frame and Snapshot
+			r, err := n.requestFastForward(peer.NetAddr, false, 0)
+			if err == nil {
+				n.coreLock.Lock()
+				err = n.core.CheckFastForward(r.Block, r.Frame)
+				n.coreLock.Unlock()
+			}
+			if err == nil {
+				blockHash, err = r.Block.Body.Hash()
+			}
+			if err == nil {
+				manifestHash, err = r.Manifest.Hash()
+			}
+			if err != nil {
+				n.logger.WithFields(logrus.Fields{
+					"peer":  peer.NetAddr,
+					"error": err,
+				}).Error("requestFastForward()")
+				n.selectorLock.Lock()
+				n.peerSelector.UpdateFailure(peer.NetAddr)
+				n.selectorLock.Unlock()
+				continue
+			}
+			resp, from, agreed = r, peer, []*peers.Peer{peer}
+		} else {
+			//Cross-check the Block and the snapshot with another peer
+			r, err := n.requestFastForward(peer.NetAddr, true, resp.Block.Index())
+			if err != nil {
+				n.logger.WithFields(logrus.Fields{
+					"peer":  peer.NetAddr,
+					"error": err,
+				}).Debug("Cross-checking Block")
+				continue
+			}
+			hash, err := r.Block.Body.Hash()
+			if err != nil || !bytes.Equal(hash, blockHash) {
+				n.logger.WithFields(logrus.Fields{
+					"peer":        peer.NetAddr,
+					"block_index": resp.Block.Index(),
+				}).Error("Peers disagree on Block")
+				continue
+			}
+			hash, err = r.Manifest.Hash()
+			if err != nil || !bytes.Equal(hash, manifestHash) {
+				n.logger.WithFields(logrus.Fields{
+					"peer":        peer.NetAddr,
+					"block_index": resp.Block.Index(),
+				}).Error("Peers disagree on Snapshot Manifest")
+				continue
+			}
+			agreed = append(agreed, peer)
+		}
+
+		if len(agreed) >= quorum {
+			break
+		}
+	}
+
+	if from == nil {
+		return fmt.Errorf("No valid FastForwardResponse")
+	}
+	if len(agreed) < quorum {
+		return fmt.Errorf("Only %d peers agree on Block %d, need %d",
+			len(agreed), resp.Block.Index(), quorum)
+	}
+	if resp.Manifest.BlockIndex != resp.Block.Index() {
+		return fmt.Errorf("Snapshot Manifest is for Block %d, not %d",
+			resp.Manifest.BlockIndex, resp.Block.Index())
+	}
+
 	n.logger.WithFields(logrus.Fields{
 		"from_id":              resp.FromID,
 		"block_index":          resp.Block.Index(),
 		"block_round_received": resp.Block.RoundReceived(),
 		"frame_events":         len(resp.Frame.Events),
 		"frame_roots":          resp.Frame.Roots,
-		"snapshot":             resp.Snapshot,
+		"snapshot_size":        resp.Manifest.Size,
+		"snapshot_chunks":      len(resp.Manifest.Chunks),
+		"agree":                len(agreed),
 	}).Debug("FastForwardResponse")
 
+	//download the snapshot and pass it to the app, before touching the
+	//hashgraph
+	err := n.downloadSnapshot(resp.Manifest, agreed)
+	if err != nil {
+		n.logger.WithField("error", err).Error("Downloading Snapshot")
+		return err
+	}
+
+	//update app from snapshot, and check that it leads to the agreed state
+	stateHash, err := n.proxy.RestoreChunks(resp.Manifest)
+	n.snapshotDownload = nil
+	if err != nil {
+		n.logger.WithField("error", err).Error("Restoring App from Snapshot")
+		return err
+	}
+	if !bytes.Equal(stateHash, resp.Block.StateHash()) {
+		n.selectorLock.Lock()
+		n.peerSelector.UpdateFailure(from.NetAddr)
+		n.selectorLock.Unlock()
+		return fmt.Errorf("Snapshot from %s restores StateHash %X, not %X",
+			from.NetAddr, stateHash, resp.Block.StateHash())
+	}
+
 	//prepare core. ie: fresh hashgraph
 	n.coreLock.Lock()
-	err = n.core.FastForward(peer.PubKeyHex, resp.Block, resp.Frame)
+	err = n.core.FastForward(from.PubKeyHex, resp.Block, resp.Frame)
 	n.coreLock.Unlock()
 	if err != nil {
 		n.logger.WithField("error", err).Error("Fast Forwarding Hashgraph")
 		return err
 	}
 
-	//update app from snapshot
-	err = n.proxy.Restore(resp.Snapshot)
+	return nil
+}
+
+//snapshotDownload records how far the app got in restoring a snapshot, so that
+//an interrupted download can be resumed
+type snapshotDownload struct {
+	manifestHash []byte
+	next         int //first chunk that the app has not accepted yet
+}
+
+//downloadSnapshot fetches the chunks of a snapshot from several peers, checks
+//them against the manifest, and passes them to the app in order. If the
+//previous download was for the same snapshot, it resumes where it stopped.
+func (n *Node) downloadSnapshot(manifest proxy.SnapshotManifest, sources []*peers.Peer) error {
+	manifestHash, err := manifest.Hash()
 	if err != nil {
-		n.logger.WithField("error", err).Error("Restoring App from Snapshot")
 		return err
 	}
 
-	n.logger.Debug("Fast-Forward OK")
+	if n.snapshotDownload == nil ||
+		!bytes.Equal(n.snapshotDownload.manifestHash, manifestHash) {
+		n.snapshotDownload = &snapshotDownload{manifestHash: manifestHash}
+	} else {
+		n.logger.WithField("chunk", n.snapshotDownload.next).Debug("Resuming Snapshot download")
+	}
 
-	n.setState(Babbling)
-	n.setStarting(true)
+	for n.snapshotDownload.next < len(manifest.Chunks) {
+		chunk := n.snapshotDownload.next
+
+		data, err := n.fetchSnapshotChunk(manifest, chunk, sources)
+		if err != nil {
+			return err
+		}
+
+		if err := n.proxy.RestoreChunk(manifest, chunk, data); err != nil {
+			return err
+		}
+		n.snapshotDownload.next++
+	}
 
 	return nil
 }
 
+//fetchSnapshotChunk spreads the chunk requests over the sources, and tries the
+//next source when one fails
+func (n *Node) fetchSnapshotChunk(manifest proxy.SnapshotManifest, chunk int, sources []*peers.Peer) ([]byte, error) {
+	for i := range sources {
+		peer := sources[(chunk+i)%len(sources)]
+
+		args := net.SnapshotChunkRequest{
+			FromID:     n.id,
+			BlockIndex: manifest.BlockIndex,
+			Chunk:      chunk,
+		}
+		var out net.SnapshotChunkResponse
+		err := n.trans.SnapshotChunk(peer.NetAddr, &args, &out)
+		if err == nil {
+			err = manifest.CheckChunk(chunk, out.Data)
+		}
+		if err != nil {
+			n.logger.WithFields(logrus.Fields{
+				"peer":  peer.NetAddr,
+				"chunk": chunk,
+				"error": err,
+			}).Error("Fetching Snapshot chunk")
+			n.selectorLock.Lock()
+			n.peerSelector.UpdateFailure(peer.NetAddr)
+			n.selectorLock.Unlock()
+			continue
+		}
+
+		return out.Data, nil
+	}
+
+	return nil, fmt.Errorf("Could not fetch Snapshot chunk %d", chunk)
+}
+
+//fastForwardPeers returns the other peers in random order, those that are not
+//backed off first
+func (n *Node) fastForwardPeers() []*peers.Peer {
+	n.selectorLock.Lock()
+	health := n.peerSelector.Health()
+	n.selectorLock.Unlock()
+
+	healthy := make(map[string]bool, len(health))
+	for _, h := range health {
+		healthy[h.NetAddr] = h.Healthy
+	}
+
+	candidates := []*peers.Peer{}
+	for _, p := range n.peerSelector.Peers().ToPeerSlice() {
+		if p.NetAddr != n.localAddr {
+			candidates = append(candidates, p)
+		}
+	}
+	n.rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return healthy[candidates[i].NetAddr] && !healthy[candidates[j].NetAddr]
+	})
+
+	return candidates
+}
+
 func (n *Node) requestSync(target string, known map[int]int) (net.SyncResponse, error) {
 
 	args := net.SyncRequest{
-		FromID: n.id,
-		Known:  known,
+		FromID:       n.id,
+		Known:        known,
+		CatchUpLimit: n.conf.catchUpLimit(),
 	}
 
 	var out net.SyncResponse
@@ -565,13 +1092,17 @@ func (n *Node) requestEagerSync(target string, events []hg.WireEvent) (net.Eager
 	return out, err
 }
 
-func (n *Node) requestFastForward(target string) (net.FastForwardResponse, error) {
+func (n *Node) requestFastForward(target string, blockOnly bool, blockIndex int) (net.FastForwardResponse, error) {
 	n.logger.WithFields(logrus.Fields{
-		"target": target,
+		"target":      target,
+		"block_only":  blockOnly,
+		"block_index": blockIndex,
 	}).Debug("RequestFastForward()")
 
 	args := net.FastForwardRequest{
-		FromID: n.id,
+		FromID:     n.id,
+		BlockOnly:  blockOnly,
+		BlockIndex: blockIndex,
 	}
 
 	var out net.FastForwardResponse
@@ -602,23 +1133,47 @@ func (n *Node) sync(events []hg.WireEvent) error {
 	return nil
 }
 
-func (n *Node) commit(block hg.Block) error {
+//rpcError gives an error the code that tells the requester why its request
+//failed. Errors without a specific code are left as they are; the transport
+//sends them as internal errors.
+func rpcError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case err == hg.ErrInvalidSignature:
+		return net.NewRPCError(net.CodeInvalidSignature, "%s", err)
+	case common.Is(err, common.UnknownParticipant):
+		return net.NewRPCError(net.CodeUnknownParticipant, "%s", err)
+	}
+	return err
+}
 
-	stateHash, err := n.proxy.CommitBlock(block)
-	n.logger.WithFields(logrus.Fields{
-		"block":      block.Index(),
-		"state_hash": fmt.Sprintf("%X", stateHash),
-		"err":        err,
-	}).Debug("CommitBlock Response")
+func (n *Node) commit(block hg.Block) error {
+	if atomic.LoadInt32(&n.appUnhealthy) == 1 {
+		n.logger.WithField("block", block.Index()).Debug("App unhealthy, skipping Block")
+		return nil
+	}
 
-	//XXX what do we do in case of error. Retry? This has to do with the
-	//Babble <-> App interface. Think about it.
+	resp, err := n.commitToApp(block)
+	if err != nil {
+		return err
+	}
 
-	//There is no point in using the stateHash if we know it is wrong
-	if err == nil {
-		block.Body.StateHash = stateHash
-		n.coreLock.Lock()
-		defer n.coreLock.Unlock()
+	//Only sign the Block once the App has committed it; the stateHash would be
+	//wrong otherwise
+	block.Body.StateHash = resp.StateHash
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	if len(resp.Results) > 0 {
+		if err := n.core.SetReceipts(block, resp.Results); err != nil {
+			n.logger.WithField("error", err).Error("Saving Receipts")
+		}
+	}
+	if n.core.Observer() {
+		if err := n.core.SetBlock(block); err != nil {
+			return err
+		}
+	} else {
 		sig, err := n.core.SignBlock(block)
 		if err != nil {
 			return err
@@ -626,35 +1181,317 @@ func (n *Node) commit(block hg.Block) error {
 		n.core.AddBlockSignature(sig)
 	}
 
-	return err
+	n.notifier.publish(Notification{Type: BlockCommitted, Block: &block})
+
+	return nil
+}
+
+//commitToApp sends a Block to the App and applies the CommitPolicy if the App
+//fails to commit it. Blocks are committed one at a time, so later Blocks wait
+//while a Block is being retried, and once commitCh is full, the Hashgraph stops
+//producing Blocks until there is room. Returning the wrong number of
+//transaction results counts as a failure.
+func (n *Node) commitToApp(block hg.Block) (proxy.CommitResponse, error) {
+	for tries := 1; ; tries++ {
+		resp, err := n.proxy.CommitBlock(block)
+		n.logger.WithFields(logrus.Fields{
+			"block":      block.Index(),
+			"state_hash": fmt.Sprintf("%X", resp.StateHash),
+			"results":    len(resp.Results),
+			"err":        err,
+		}).Debug("CommitBlock Response")
+		if err == nil && len(resp.Results) > 0 && len(resp.Results) != len(block.Transactions()) {
+			err = fmt.Errorf("App returned %d results for %d transactions",
+				len(resp.Results), len(block.Transactions()))
+		}
+		if err == nil {
+			return resp, nil
+		}
+
+		atomic.AddInt32(&n.commitFailures, 1)
+		n.logger.WithFields(logrus.Fields{
+			"block":  block.Index(),
+			"tries":  tries,
+			"policy": n.conf.commitPolicy(),
+			"error":  err,
+		}).Error("App failed to commit Block")
+
+		switch n.conf.commitPolicy() {
+		case CommitHalt:
+			n.halt(fmt.Errorf("App failed to commit Block %d: %v", block.Index(), err))
+			return proxy.CommitResponse{}, err
+		case CommitUnhealthy:
+			atomic.StoreInt32(&n.appUnhealthy, 1)
+			return proxy.CommitResponse{}, err
+		}
+
+		if max := n.conf.CommitRetries; max > 0 && tries > max {
+			n.halt(fmt.Errorf("App failed to commit Block %d after %d tries: %v",
+				block.Index(), tries, err))
+			return proxy.CommitResponse{}, err
+		}
+
+		backoff := maxBackoff
+		if tries < 8 {
+			backoff = minBackoff << uint(tries-1)
+		}
+		select {
+		case <-n.clock.After(backoff):
+		case <-n.shutdownCh:
+			return proxy.CommitResponse{}, err
+		}
+	}
+}
+
+//halt shuts the node down from one of its own routines. Shutdown waits for the
+//gossip routines, which may be waiting for Blocks to be committed, so it runs
+//in a routine of its own.
+func (n *Node) halt(reason error) {
+	n.logger.WithField("reason", reason).Error("Halting")
+	n.exitReasonLock.Lock()
+	if n.exitReason == nil {
+		n.exitReason = reason
+	}
+	n.exitReasonLock.Unlock()
+	go n.Shutdown()
+}
+
+//checkTransactions passes the submitted transactions that the App accepts to
+//the background routine, in the order in which they were submitted. CheckTx
+//may be a round trip to the App, so it must not run in the routine that
+//processes RPC requests. Rejected transactions are answered here.
+func (n *Node) checkTransactions() {
+	for {
+		select {
+		case t := <-n.submitCh:
+			if err := n.checkTransaction(t.Tx); err != nil {
+				t.Respond(err)
+				continue
+			}
+			select {
+			case n.checkedCh <- t:
+			case <-n.shutdownCh:
+				t.Respond(fmt.Errorf("Node is shutting down"))
+				return
+			}
+		case <-n.shutdownCh:
+			return
+		}
+	}
+}
+
+//checkTransaction returns the error, if any, that is sent back to the submitter
+//of a transaction which cannot be added to the pool
+func (n *Node) checkTransaction(tx []byte) error {
+	//Observers cannot put transactions in Events
+	if n.core.Observer() {
+		n.logger.Error("Observer discarding transaction")
+		return fmt.Errorf("Observers do not accept transactions")
+	}
+
+	if err := n.proxy.CheckTx(tx); err != nil {
+		n.logger.WithField("error", err).Debug("Rejecting transaction")
+		return err
+	}
+
+	return nil
 }
 
+//addTransaction adds a transaction that the App accepted to the pool
 func (n *Node) addTransaction(tx []byte) {
 	n.coreLock.Lock()
 	defer n.coreLock.Unlock()
 	n.core.AddTransactions([][]byte{tx})
 }
 
+//nextPeer asks the PeerSelector for the next peer to gossip with, and marks it
+//busy. The Events known by this node are read first, because the coreLock must
+//not be taken while the selectorLock is held.
+func (n *Node) nextPeer() *peers.Peer {
+	known := n.knownEvents()
+
+	n.selectorLock.Lock()
+	defer n.selectorLock.Unlock()
+
+	n.peerSelector.UpdateKnown(n.localAddr, known)
+	peer := n.peerSelector.Next()
+	if peer != nil {
+		n.peerSelector.SetBusy(peer.NetAddr, true)
+	}
+	return peer
+}
+
+func (n *Node) needGossip() bool {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.NeedGossip()
+}
+
+//heartbeatLoad is used by the AdaptiveHeartbeat to pace gossip
+func (n *Node) heartbeatLoad() HeartbeatLoad {
+	var load HeartbeatLoad
+
+	n.coreLock.Lock()
+	load.TransactionPool = len(n.core.transactionPool)
+	load.UndeterminedEvents = len(n.core.GetUndeterminedEvents())
+	n.coreLock.Unlock()
+
+	n.selectorLock.Lock()
+	load.SyncRequests = n.syncRequests
+	load.SyncErrors = n.syncErrors
+	n.selectorLock.Unlock()
+
+	return load
+}
+
+//knownEvents is passed to the PeerSelector to compare our Events with those of
+//other peers
+func (n *Node) knownEvents() map[int]int {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.KnownEvents()
+}
+
 func (n *Node) Shutdown() {
-	if n.getState() != Shutdown {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+
+	if state := n.getState(); state != Shutdown {
 		n.logger.Debug("Shutdown")
 
 		//Exit any non-shutdown state immediately
 		n.setState(Shutdown)
 
-		//Stop and wait for concurrent operations
-		close(n.shutdownCh)
-		n.waitRoutines()
-
-		//For some reason this needs to be called after closing the shutdownCh
-		//Not entirely sure why...
-		n.controlTimer.Shutdown()
+		//A paused node has already stopped its routines and closed its
+		//transport
+		if state != Paused {
+			n.stop(false)
+		}
 
-		//transport and store should only be closed once all concurrent operations
-		//are finished otherwise they will panic trying to use close objects
-		n.trans.Close()
+		//the store should only be closed once all concurrent operations are
+		//finished otherwise they will panic trying to use a closed object
 		n.core.hg.Store.Close()
+
+		//The StateChangeHandler still receives the changes that are buffered,
+		//Shutdown included
+		if n.stateChanges != nil {
+			n.stateChanges.Unsubscribe()
+			n.stateChanges = nil
+		}
+	}
+}
+
+//Pause stops gossip and closes the transport, but keeps the store and the
+//state of the hashgraph, so that the node can Resume where it left off. It is
+//meant for mobile apps that the OS suspends.
+func (n *Node) Pause() error {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+
+	if state := n.getState(); state == Shutdown || state == Paused {
+		return fmt.Errorf("Cannot pause a node that is %s", state)
+	}
+
+	n.logger.Debug("Pause")
+
+	n.setState(Paused)
+	n.stop(true)
+
+	return nil
+}
+
+//Resume restarts a paused node with a new transport, usually bound to the same
+//address as the one closed by Pause. The node syncs with its peers, and
+//fast-forwards if it fell too far behind.
+func (n *Node) Resume(trans net.Transport) error {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+
+	if state := n.getState(); state != Paused {
+		return fmt.Errorf("Cannot resume a node that is %s", state)
 	}
+
+	n.logger.Debug("Resume")
+
+	n.trans = trans
+	n.netCh = trans.Consumer()
+	n.shutdownCh = make(chan struct{})
+	n.controlTimer = n.conf.controlTimer(n.heartbeatLoad, n.rand)
+	n.fastForwardTries = 0
+
+	n.setStarting(true)
+	n.setState(Babbling)
+	n.RunAsync(n.runGossip)
+
+	return nil
+}
+
+//stop closes the shutdownCh, waits for the routines of the node to return, and
+//closes the transport. With waitRun, it also waits for the routines started by
+//Run, so that they can be started again.
+func (n *Node) stop(waitRun bool) {
+	//Stop and wait for concurrent operations
+	close(n.shutdownCh)
+	n.waitRoutines()
+	if waitRun {
+		n.runWg.Wait()
+	}
+
+	//For some reason this needs to be called after closing the shutdownCh
+	//Not entirely sure why...
+	n.controlTimer.Shutdown()
+
+	//transport should only be closed once all concurrent operations are
+	//finished otherwise they will panic trying to use a closed object
+	n.trans.Close()
+}
+
+//stateChangeBuffer is the number of state changes that are kept for a
+//StateChangeHandler that is still busy with an earlier one
+const stateChangeBuffer = 100
+
+//SetStateChangeHandler registers a function which is called with the new state
+//every time the node goes from one state to another. It is called from a
+//goroutine of its own, in the order of the changes, so that a slow handler does
+//not hold up the node; a handler that falls too far behind misses changes. The
+//last call is for Shutdown. It should be set before Run.
+func (n *Node) SetStateChangeHandler(handler func(NodeState)) {
+	n.lifecycleLock.Lock()
+	defer n.lifecycleLock.Unlock()
+
+	if n.stateChanges != nil {
+		n.stateChanges.Unsubscribe()
+		n.stateChanges = nil
+	}
+	if handler == nil || n.getState() == Shutdown {
+		return
+	}
+
+	sub := n.Subscribe(stateChangeBuffer, StateChanged)
+	go func() {
+		for nt := range sub.Notifications() {
+			handler(nt.State)
+		}
+	}()
+	n.stateChanges = sub
+}
+
+//stateChanged notifies the Subscriptions, including the StateChangeHandler
+func (n *Node) stateChanged(state NodeState) {
+	n.notifier.publish(Notification{Type: StateChanged, State: state})
+}
+
+//GetState returns the current state of the node
+func (n *Node) GetState() NodeState {
+	return n.getState()
+}
+
+//ExitReason returns the reason why the node shut itself down, or nil if it did
+//not
+func (n *Node) ExitReason() error {
+	n.exitReasonLock.Lock()
+	defer n.exitReasonLock.Unlock()
+	return n.exitReason
 }
 
 func (n *Node) GetStats() map[string]string {
@@ -690,6 +1527,17 @@ func (n *Node) GetStats() map[string]string {
 		"round_events":           strconv.Itoa(n.core.GetLastCommitedRoundEventsCount()),
 		"id":                     strconv.Itoa(n.id),
 		"state":                  n.getState().String(),
+		"observer":               strconv.FormatBool(n.core.Observer()),
+		"app_healthy":            strconv.FormatBool(atomic.LoadInt32(&n.appUnhealthy) == 0),
+		"commit_failures":        strconv.Itoa(int(atomic.LoadInt32(&n.commitFailures))),
+	}
+	if p, ok := n.proxy.(proxy.ConnectedAppProxy); ok {
+		status := p.ConnectionStatus()
+		s["app_connected"] = strconv.FormatBool(status.Connected)
+		s["app_reconnects"] = strconv.Itoa(status.Reconnects)
+		if status.LastError != "" {
+			s["app_last_error"] = status.LastError
+		}
 	}
 	return s
 }
@@ -710,10 +1558,20 @@ func (n *Node) logStats() {
 		"round_events":           stats["round_events"],
 		"id":                     stats["id"],
 		"state":                  stats["state"],
+		"observer":               stats["observer"],
 	}).Debug("Stats")
 }
 
+//GetPeers returns the health of every peer, as seen by this node
+func (n *Node) GetPeers() []PeerHealth {
+	n.selectorLock.Lock()
+	defer n.selectorLock.Unlock()
+	return n.peerSelector.Health()
+}
+
 func (n *Node) SyncRate() float64 {
+	n.selectorLock.Lock()
+	defer n.selectorLock.Unlock()
 	var syncErrorRate float64
 	if n.syncRequests != 0 {
 		syncErrorRate = float64(n.syncErrors) / float64(n.syncRequests)
@@ -725,6 +1583,62 @@ func (n *Node) GetBlock(blockIndex int) (hg.Block, error) {
 	return n.core.hg.Store.GetBlock(blockIndex)
 }
 
+//GetReceipt returns the latest Receipt of a transaction, by hash (cf
+//hashgraph.TxHash). Receipts are only recorded if the App reports transaction
+//results.
+func (n *Node) GetReceipt(txHash string) (hg.Receipt, error) {
+	return n.core.hg.Store.GetReceipt(txHash)
+}
+
+//GetBlockReceipts returns the Receipts of the transactions in a Block
+func (n *Node) GetBlockReceipts(blockIndex int) ([]hg.Receipt, error) {
+	return n.core.hg.Store.GetBlockReceipts(blockIndex)
+}
+
+//MaxGraphRounds is the maximum number of Rounds that GetGraph exports at once
+const MaxGraphRounds = 100
+
+//ErrInvalidGraphRange is returned by GetGraph when the Rounds it is asked for
+//are not a valid range
+var ErrInvalidGraphRange = fmt.Errorf("Invalid Round range: bounds must be positive, "+
+	"from must not exceed to, and the range must not exceed %d Rounds", MaxGraphRounds)
+
+//GetGraph returns the portion of the hashgraph comprised between two Rounds.
+//toRound is capped to the last Round. The range is checked, and limited to
+//MaxGraphRounds, before anything is read, because the hashgraph is locked
+//while the Graph is built.
+func (n *Node) GetGraph(fromRound, toRound int) (*hg.Graph, error) {
+	if fromRound < 0 || toRound < fromRound || toRound-fromRound >= MaxGraphRounds {
+		return nil, ErrInvalidGraphRange
+	}
+
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+
+	if last := n.core.hg.Store.LastRound(); toRound > last {
+		toRound = last
+	}
+	if toRound < fromRound {
+		return &hg.Graph{
+			FromRound: fromRound,
+			ToRound:   toRound,
+			Events:    []hg.GraphEvent{},
+		}, nil
+	}
+
+	return hg.NewGraph(n.core.hg.Store, fromRound, toRound)
+}
+
+//GetLastRound returns the index of the last Round created by the hashgraph
+func (n *Node) GetLastRound() int {
+	return n.core.hg.Store.LastRound()
+}
+
 func (n *Node) ID() int {
 	return n.id
 }
+
+//Observer returns true if the node follows consensus without being a validator
+func (n *Node) Observer() bool {
+	return n.core.Observer()
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -15,7 +16,9 @@ import (
 	hg "github.com/mosaicnetworks/babble/src/hashgraph"
 	"github.com/mosaicnetworks/babble/src/net"
 	peers_ "github.com/mosaicnetworks/babble/src/peers"
+	"github.com/mosaicnetworks/babble/src/proxy"
 	dummy "github.com/mosaicnetworks/babble/src/proxy/dummy"
+	"github.com/mosaicnetworks/babble/src/proxy/kvstore"
 	"github.com/sirupsen/logrus"
 )
 
@@ -251,7 +254,9 @@ func TestAddTransaction(t *testing.T) {
 	//Submit a Tx to node0
 
 	message := "Hello World!"
-	peer0Proxy.SubmitTx([]byte(message))
+	if err := peer0Proxy.SubmitTx([]byte(message)); err != nil {
+		t.Fatal(err)
+	}
 
 	//simulate a SyncRequest from node0 to node1
 
@@ -289,6 +294,46 @@ func TestAddTransaction(t *testing.T) {
 	node1.Shutdown()
 }
 
+func TestAddTransactionRejected(t *testing.T) {
+	keys, p := initPeers(2)
+	config := TestConfig(t)
+
+	peers := p.ToPeerSlice()
+
+	peer0Trans, err := net.NewTCPTransport(peers[0].NetAddr, nil, 2, time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer peer0Trans.Close()
+
+	//The dummy App rejects empty transactions
+	peer0Proxy := dummy.NewInmemDummyClient(common.NewTestLogger(t))
+
+	node0 := NewNode(config, peers[0].ID, keys[0], p,
+		hg.NewInmemStore(p, config.CacheSize),
+		peer0Trans,
+		peer0Proxy)
+	node0.Init()
+
+	node0.RunAsync(false)
+	defer node0.Shutdown()
+
+	err = peer0Proxy.SubmitTx([]byte{})
+	if err == nil || err.Error() != "Empty transaction" {
+		t.Fatalf("Empty transaction should be rejected, not %v", err)
+	}
+
+	if err := peer0Proxy.SubmitTx([]byte("valid")); err != nil {
+		t.Fatal(err)
+	}
+
+	node0.coreLock.Lock()
+	defer node0.coreLock.Unlock()
+	if l := len(node0.core.transactionPool); l != 1 {
+		t.Fatalf("transactionPool should have 1 element, not %d", l)
+	}
+}
+
 func initNodes(keys []*ecdsa.PrivateKey,
 	peers *peers_.Peers,
 	cacheSize,
@@ -297,6 +342,21 @@ func initNodes(keys []*ecdsa.PrivateKey,
 	logger *logrus.Logger,
 	t testing.TB) []*Node {
 
+	return initAppNodes(keys, peers, cacheSize, syncLimit, storeType,
+		func() proxy.AppProxy { return dummy.NewInmemDummyClient(logger) },
+		logger, t)
+}
+
+//initAppNodes is like initNodes, but every node gets its own App from newApp
+func initAppNodes(keys []*ecdsa.PrivateKey,
+	peers *peers_.Peers,
+	cacheSize,
+	syncLimit int,
+	storeType string,
+	newApp func() proxy.AppProxy,
+	logger *logrus.Logger,
+	t testing.TB) []*Node {
+
 	nodes := []*Node{}
 
 	for _, k := range keys {
@@ -328,7 +388,7 @@ func initNodes(keys []*ecdsa.PrivateKey,
 		case "inmem":
 			store = hg.NewInmemStore(peers, conf.CacheSize)
 		}
-		prox := dummy.NewInmemDummyClient(logger)
+		prox := newApp()
 		node := NewNode(conf,
 			id,
 			k,
@@ -436,6 +496,47 @@ func TestGossip(t *testing.T) {
 	checkGossip(nodes, 0, t)
 }
 
+func TestReceipts(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+
+	err := gossip(nodes, 10, true, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The dummy App reports a result for every transaction
+	for _, n := range nodes {
+		block, err := n.GetBlock(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receipts, err := n.GetBlockReceipts(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(receipts) != len(block.Transactions()) {
+			t.Fatalf("Node %d: Block 0 should have %d receipts, not %d",
+				n.id, len(block.Transactions()), len(receipts))
+		}
+
+		for i, tx := range block.Transactions() {
+			receipt, err := n.GetReceipt(hg.TxHash(tx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.BlockIndex != 0 || receipt.TxIndex != i || !receipt.Result.OK() {
+				t.Fatalf("Node %d: wrong receipt for transaction %d: %+v", n.id, i, receipt)
+			}
+		}
+	}
+}
+
 func TestMissingNodeGossip(t *testing.T) {
 
 	logger := common.NewTestLogger(t)
@@ -457,7 +558,7 @@ func TestSyncLimit(t *testing.T) {
 	logger := common.NewTestLogger(t)
 
 	keys, peers := initPeers(4)
-	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	nodes := initNodes(keys, peers, 1000, 50, "inmem", logger, t)
 
 	err := gossip(nodes, 10, false, 3*time.Second)
 	if err != nil {
@@ -472,25 +573,26 @@ func TestSyncLimit(t *testing.T) {
 	}
 
 	args := net.SyncRequest{
-		FromID: nodes[0].id,
-		Known:  node0KnownEvents,
-	}
-	expectedResp := net.SyncResponse{
-		FromID:    nodes[1].id,
-		SyncLimit: true,
+		FromID:       nodes[0].id,
+		Known:        node0KnownEvents,
+		CatchUpLimit: 50,
 	}
 
+	//The requester is told to fast-forward by a sync-limit error
 	var out net.SyncResponse
-	if err := nodes[0].trans.Sync(nodes[1].localAddr, &args, &out); err != nil {
-		t.Fatalf("err: %v", err)
+	err = nodes[0].trans.Sync(nodes[1].localAddr, &args, &out)
+	if code, ok := net.Code(err); !ok || code != net.CodeSyncLimit {
+		t.Fatalf("Sync should fail with code %s, not %v", net.CodeSyncLimit, err)
 	}
 
-	// Verify the response
-	if expectedResp.FromID != out.FromID {
-		t.Fatalf("SyncResponse.FromID should be %d, not %d", expectedResp.FromID, out.FromID)
+	//Requesters that predate error codes only get the SyncLimit flag
+	args.CatchUpLimit = 0
+	out = net.SyncResponse{}
+	if err := nodes[0].trans.Sync(nodes[1].localAddr, &args, &out); err != nil {
+		t.Fatalf("Sync should not fail for older requesters: %v", err)
 	}
-	if expectedResp.SyncLimit != true {
-		t.Fatal("SyncResponse.SyncLimit should be true")
+	if !out.SyncLimit {
+		t.Fatal("SyncLimit should be set")
 	}
 }
 
@@ -499,7 +601,9 @@ func TestFastForward(t *testing.T) {
 	logger := common.NewTestLogger(t)
 
 	keys, peers := initPeers(4)
-	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	nodes := initAppNodes(keys, peers, 1000, 1000, "inmem",
+		func() proxy.AppProxy { return kvstore.NewInmemKVStore(logger) },
+		logger, t)
 	defer shutdownNodes(nodes)
 
 	target := 50
@@ -528,6 +632,343 @@ func TestFastForward(t *testing.T) {
 	if !reflect.DeepEqual(sBlock.Body, expectedBlock.Body) {
 		t.Fatalf("Blocks defer")
 	}
+
+	//node0 must have rebuilt the store exactly as it was after that Block
+	snapshot, err := nodes[1].proxy.GetSnapshot(lbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedStore := kvstore.NewState(logger)
+	if _, err := expectedStore.RestoreHandler(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	store := nodes[0].proxy.(*kvstore.InmemKVStore).State()
+	if len(store.Entries()) == 0 {
+		t.Fatalf("node0 should have restored a non-empty store")
+	}
+	if !reflect.DeepEqual(store.Entries(), expectedStore.Entries()) {
+		t.Fatalf("node0 store should be %v, not %v", expectedStore.Entries(), store.Entries())
+	}
+	if !reflect.DeepEqual(store.StateHash(), sBlock.StateHash()) {
+		t.Fatalf("node0 StateHash should be %X, not %X", sBlock.StateHash(), store.StateHash())
+	}
+}
+
+//TestFastForwardPaused checks that a fast-forward which completes after the
+//node was paused leaves it Paused
+func TestFastForwardPaused(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	err := gossip(nodes[1:], 10, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node0 := nodes[0]
+	node0.setState(Paused)
+
+	if err := node0.fastForward(); err != nil {
+		t.Fatal(err)
+	}
+	if s := node0.getState(); s != Paused {
+		t.Fatalf("node0 should still be Paused, not %s", s)
+	}
+
+	//Let Shutdown close the transport, which Pause would have closed
+	node0.setState(Babbling)
+}
+
+func TestFastForwardQuorum(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	err := gossip(nodes[1:], 10, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//A single peer cannot be trusted on its own
+	shutdownNodes(nodes[2:])
+
+	node0 := nodes[0]
+	node0.conf.FastForwardTries = 2
+	node0.setState(CatchingUp)
+
+	for i := 0; i < 2; i++ {
+		if err := node0.fastForward(); err == nil {
+			t.Fatal("fastForward should fail without a quorum")
+		}
+	}
+
+	if s := node0.getState(); s != Failed {
+		t.Fatalf("node0 should be Failed, not %s", s)
+	}
+	if lbi := node0.core.GetLastBlockIndex(); lbi != -1 {
+		t.Fatalf("node0 should not have fast-forwarded, but it is at Block %d", lbi)
+	}
+}
+
+//wrongRestoreProxy returns a StateHash that does not match the snapshot
+type wrongRestoreProxy struct {
+	*dummy.InmemDummyClient
+}
+
+func (p wrongRestoreProxy) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	p.InmemDummyClient.RestoreChunks(manifest)
+	return []byte("wrong"), nil
+}
+
+func TestFastForwardStateHash(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	err := gossip(nodes[1:], 10, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node0 := nodes[0]
+	node0.proxy = wrongRestoreProxy{node0.proxy.(*dummy.InmemDummyClient)}
+
+	if err := node0.fastForward(); err == nil {
+		t.Fatal("fastForward should fail when the snapshot does not match the StateHash")
+	}
+	if lbi := node0.core.GetLastBlockIndex(); lbi != -1 {
+		t.Fatalf("the Hashgraph should not have been reset, but it is at Block %d", lbi)
+	}
+
+	unhealthy := 0
+	for _, h := range node0.GetPeers() {
+		if !h.Healthy {
+			unhealthy++
+		}
+	}
+	if unhealthy != 1 {
+		t.Fatalf("the peer that sent the snapshot should be backed off")
+	}
+}
+
+//otherManifestProxy describes its snapshots with Metadata of its own
+type otherManifestProxy struct {
+	*dummy.InmemDummyClient
+	metadata string
+}
+
+func (p otherManifestProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	manifest, err := p.InmemDummyClient.GetSnapshotManifest(blockIndex)
+	manifest.Metadata = []byte(p.metadata)
+	return manifest, err
+}
+
+func TestFastForwardManifest(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	//The peers agree on the Block, but no two of them on the snapshot
+	for _, n := range nodes[1:] {
+		n.proxy = otherManifestProxy{n.proxy.(*dummy.InmemDummyClient), strconv.Itoa(n.id)}
+	}
+
+	err := gossip(nodes[1:], 10, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node0 := nodes[0]
+	if err := node0.fastForward(); err == nil {
+		t.Fatal("fastForward should fail when the peers disagree on the snapshot")
+	}
+	if lbi := node0.core.GetLastBlockIndex(); lbi != -1 {
+		t.Fatalf("the Hashgraph should not have been reset, but it is at Block %d", lbi)
+	}
+}
+
+//chunkyProxy splits snapshots into 4-byte chunks, and can simulate an
+//interruption while restoring them
+type chunkyProxy struct {
+	*dummy.InmemDummyClient
+	failChunk int
+	restored  []int
+}
+
+func (p *chunkyProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	snapshot, err := p.GetSnapshot(blockIndex)
+	if err != nil {
+		return proxy.SnapshotManifest{}, err
+	}
+	return proxy.NewSnapshotManifest(blockIndex, snapshot, 4), nil
+}
+
+func (p *chunkyProxy) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	snapshot, err := p.GetSnapshot(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+	return proxy.SplitSnapshot(snapshot, 4)[chunk], nil
+}
+
+func (p *chunkyProxy) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	if chunk == p.failChunk {
+		p.failChunk = -1
+		return fmt.Errorf("interrupted")
+	}
+	p.restored = append(p.restored, chunk)
+	return p.InmemDummyClient.RestoreChunk(manifest, chunk, data)
+}
+
+func TestSnapshotDownload(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+	for _, n := range nodes {
+		n.proxy = &chunkyProxy{InmemDummyClient: n.proxy.(*dummy.InmemDummyClient), failChunk: -1}
+	}
+
+	err := gossip(nodes[1:], 10, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := nodes[1].GetBlock(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := nodes[1].proxy.GetSnapshotManifest(block.Index())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 8 {
+		t.Fatalf("the snapshot should have 8 chunks, not %d", len(manifest.Chunks))
+	}
+
+	node0 := nodes[0]
+	proxy0 := node0.proxy.(*chunkyProxy)
+	proxy0.failChunk = 5
+	sources := []*peers_.Peer{
+		peers.ByPubKey[nodes[1].core.HexID()],
+		peers.ByPubKey[nodes[2].core.HexID()],
+	}
+
+	//The download is interrupted, then resumed where it stopped
+	if err := node0.downloadSnapshot(manifest, sources); err == nil {
+		t.Fatal("downloadSnapshot should fail")
+	}
+	if err := node0.downloadSnapshot(manifest, sources); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(proxy0.restored, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Fatalf("chunks should be restored once and in order, not %v", proxy0.restored)
+	}
+
+	stateHash, err := node0.proxy.RestoreChunks(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateHash, block.StateHash()) {
+		t.Fatalf("StateHash should be %X, not %X", block.StateHash(), stateHash)
+	}
+}
+
+//flakyProxy fails to commit the first Blocks it is given
+type flakyProxy struct {
+	*dummy.InmemDummyClient
+	failures int
+}
+
+func (p *flakyProxy) CommitBlock(block hg.Block) (proxy.CommitResponse, error) {
+	if p.failures > 0 {
+		p.failures--
+		return proxy.CommitResponse{}, fmt.Errorf("app unavailable")
+	}
+	return p.InmemDummyClient.CommitBlock(block)
+}
+
+func TestCommitPolicy(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(3)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+	for _, n := range nodes {
+		n.proxy = &flakyProxy{InmemDummyClient: n.proxy.(*dummy.InmemDummyClient), failures: 1}
+	}
+
+	block := hg.NewBlock(0, 1, []byte("framehash"), [][]byte{[]byte("tx")})
+
+	//With the retry policy, the Block is committed once the App recovers
+	if _, err := nodes[0].commitToApp(block); err != nil {
+		t.Fatal(err)
+	}
+	if txs := nodes[0].proxy.(*flakyProxy).GetCommittedTransactions(); len(txs) != 1 {
+		t.Fatalf("the Block should be committed once, not %d times", len(txs))
+	}
+
+	//With the unhealthy policy, no more Blocks are committed
+	nodes[1].conf.CommitPolicy = CommitUnhealthy
+	if err := nodes[1].commit(block); err == nil {
+		t.Fatal("commit should fail")
+	}
+	if err := nodes[1].commit(block); err != nil {
+		t.Fatal(err)
+	}
+	if txs := nodes[1].proxy.(*flakyProxy).GetCommittedTransactions(); len(txs) != 0 {
+		t.Fatalf("an unhealthy App should not be given Blocks")
+	}
+	if h := nodes[1].GetStats()["app_healthy"]; h != "false" {
+		t.Fatalf("app_healthy should be false, not %s", h)
+	}
+
+	//With the halt policy, the node shuts down
+	nodes[2].conf.CommitPolicy = CommitHalt
+	if err := nodes[2].commit(block); err == nil {
+		t.Fatal("commit should fail")
+	}
+	timeout := time.After(time.Second)
+	for nodes[2].getState() != Shutdown {
+		select {
+		case <-timeout:
+			t.Fatal("node should shut down")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if nodes[2].ExitReason() == nil {
+		t.Fatal("ExitReason should tell why the node halted")
+	}
+}
+
+func TestUnknownCommitPolicy(t *testing.T) {
+
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(2)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	nodes[0].conf.CommitPolicy = "hlat"
+	if err := nodes[0].Init(); err == nil {
+		t.Fatal("Init should reject an unknown CommitPolicy")
+	}
 }
 
 func TestCatchUp(t *testing.T) {
@@ -580,6 +1021,47 @@ func TestCatchUp(t *testing.T) {
 	checkGossip(nodes, *start, t)
 }
 
+func TestCatchUpPaging(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+
+	//Initialize the first 3 nodes only
+	normalNodes := initNodes(keys[0:3], peers, 1000, 100, "inmem", logger, t)
+	defer shutdownNodes(normalNodes)
+
+	target := 50
+
+	err := gossip(normalNodes, target, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGossip(normalNodes, 0, t)
+
+	//node4 is far behind but refuses to fast-forward, so it has to page
+	//through the whole hashgraph
+	node4 := initNodes(keys[3:], peers, 1000, 100, "inmem", logger, t)[0]
+	node4.conf.Archive = true
+
+	if unknown := normalNodes[0].core.UnknownCount(node4.core.KnownEvents()); unknown <= 100 {
+		t.Fatalf("node4 should be more than 100 Events behind, not %d", unknown)
+	}
+
+	node4.RunAsync(true)
+	defer node4.Shutdown()
+
+	nodes := append(normalNodes, node4)
+	err = bombardAndWait(nodes, target+20, 6*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if start := node4.core.hg.FirstConsensusRound; start == nil || *start != 0 {
+		t.Fatalf("node4 should have the whole hashgraph, starting at round 0")
+	}
+	checkGossip(nodes, 0, t)
+}
+
 func TestFastSync(t *testing.T) {
 	logger := common.NewTestLogger(t)
 
@@ -640,6 +1122,81 @@ func TestFastSync(t *testing.T) {
 	checkGossip(nodes, *start, t)
 }
 
+func TestObserver(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(3)
+	nodes := initNodes(keys, peers, 1000, 400, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	//The observer's key is not in peers
+	key, _ := crypto.GenerateECDSAKey()
+	conf := NewConfig(5*time.Millisecond, time.Second, 1000, 400, logger)
+	addr := fmt.Sprintf("127.0.0.1:%d", ip)
+	ip++
+	trans, err := net.NewTCPTransport(addr, nil, 2, time.Second, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := NewNode(conf,
+		common.Hash32(crypto.FromECDSAPub(&key.PublicKey)),
+		key,
+		peers,
+		hg.NewInmemStore(peers, conf.CacheSize),
+		trans,
+		dummy.NewInmemDummyClient(logger))
+	if err := observer.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if !observer.Observer() {
+		t.Fatal("Node should be an observer")
+	}
+
+	observer.RunAsync(true)
+	defer observer.Shutdown()
+
+	target := 20
+	err = gossip(nodes, target, false, 6*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Wait for the observer to commit the target Block
+	timeout := time.After(6 * time.Second)
+	for observer.core.GetLastBlockIndex() < target {
+		select {
+		case <-timeout:
+			t.Fatalf("Timeout waiting for observer to reach Block %d", target)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	for i := 0; i <= target; i++ {
+		block, err := nodes[0].GetBlock(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		oBlock, err := observer.GetBlock(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(block.Body.Transactions, oBlock.Body.Transactions) {
+			t.Fatalf("Block %d differs on observer", i)
+		}
+		if _, ok := block.Signatures[observer.core.HexID()]; ok {
+			t.Fatalf("Observer signed Block %d", i)
+		}
+	}
+
+	//The observer never created any Event
+	if _, ok := nodes[0].core.KnownEvents()[observer.id]; ok {
+		t.Fatal("Validators should not know any Event from the observer")
+	}
+	if observer.core.Head != "" {
+		t.Fatalf("Observer should have no head, not %s", observer.core.Head)
+	}
+}
+
 func TestShutdown(t *testing.T) {
 	logger := common.NewTestLogger(t)
 
@@ -657,6 +1214,283 @@ func TestShutdown(t *testing.T) {
 	nodes[1].Shutdown()
 }
 
+func TestGetGraph(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+
+	err := gossip(nodes, 3, true, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := nodes[0].GetLastRound()
+
+	//to is capped to the last Round
+	graph, err := nodes[0].GetGraph(0, last+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.ToRound != last {
+		t.Fatalf("ToRound should be %d, not %d", last, graph.ToRound)
+	}
+	if len(graph.Events) == 0 {
+		t.Fatal("Graph should contain Events")
+	}
+
+	for _, r := range [][2]int{{-1, 2}, {0, -1}, {3, 2}, {0, MaxGraphRounds}} {
+		if _, err := nodes[0].GetGraph(r[0], r[1]); err != ErrInvalidGraphRange {
+			t.Fatalf("GetGraph(%d, %d) should fail with ErrInvalidGraphRange, not %v", r[0], r[1], err)
+		}
+	}
+
+	//Rounds beyond the last one are empty
+	graph, err = nodes[0].GetGraph(last+1, last+5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Events) != 0 {
+		t.Fatalf("Graph should be empty, not contain %d Events", len(graph.Events))
+	}
+}
+
+//slowCheckProxy does not answer CheckTx until release is closed
+type slowCheckProxy struct {
+	*dummy.InmemDummyClient
+	release chan struct{}
+}
+
+func (p slowCheckProxy) CheckTx(tx []byte) error {
+	<-p.release
+	return p.InmemDummyClient.CheckTx(tx)
+}
+
+//TestSlowCheckTx checks that a slow App does not hold up RPC requests while it
+//checks a transaction
+func TestSlowCheckTx(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	release := make(chan struct{})
+	keys, peers := initPeers(2)
+	nodes := initAppNodes(keys, peers, 1000, 1000, "inmem",
+		func() proxy.AppProxy {
+			return slowCheckProxy{dummy.NewInmemDummyClient(logger), release}
+		},
+		logger, t)
+	defer shutdownNodes(nodes)
+
+	runNodes(nodes, false)
+
+	node0 := nodes[0]
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- node0.proxy.(slowCheckProxy).SubmitTx([]byte("tx"))
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := nodes[1].requestSync(node0.localAddr, map[int]int{}); err != nil {
+		t.Fatalf("node0 should answer a SyncRequest while the App checks a transaction: %v", err)
+	}
+
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The transaction should have been accepted")
+	}
+}
+
+func TestStateChangeHandler(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(2)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	changes := make(chan NodeState, 10)
+	nodes[0].SetStateChangeHandler(func(s NodeState) {
+		changes <- s
+	})
+
+	nodes[0].setState(CatchingUp)
+	nodes[0].setState(CatchingUp)
+	nodes[0].Shutdown()
+
+	//The handler is called asynchronously
+	states := []NodeState{}
+	timeout := time.After(time.Second)
+	for len(states) < 2 {
+		select {
+		case s := <-changes:
+			states = append(states, s)
+		case <-timeout:
+			t.Fatalf("Only received state changes %v", states)
+		}
+	}
+
+	expected := []NodeState{CatchingUp, Shutdown}
+	if !reflect.DeepEqual(states, expected) {
+		t.Fatalf("State changes should be %v, not %v", expected, states)
+	}
+
+	select {
+	case s := <-changes:
+		t.Fatalf("Unexpected state change %s", s)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+
+	all := nodes[0].Subscribe(100000)
+	blocks := nodes[0].Subscribe(100000, BlockCommitted)
+
+	err := gossip(nodes, 10, true, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all.Unsubscribe()
+	blocks.Unsubscribe()
+
+	if all.Dropped() > 0 {
+		t.Fatalf("%d Notifications were dropped", all.Dropped())
+	}
+
+	counts := make(map[NotificationType]int)
+	for n := range all.Notifications() {
+		counts[n.Type]++
+		switch n.Type {
+		case SelfEventCreated, EventInserted:
+			if n.Event == nil {
+				t.Fatalf("%s Notification without Event", n.Type)
+			}
+		case RoundDecided:
+			if n.Round == nil || len(n.Round.FamousWitnesses) == 0 {
+				t.Fatalf("RoundDecided Notification without famous witnesses")
+			}
+		case BlockCommitted, AnchorBlockSet:
+			if n.Block == nil {
+				t.Fatalf("%s Notification without Block", n.Type)
+			}
+		}
+	}
+
+	for _, typ := range []NotificationType{SelfEventCreated, EventInserted,
+		RoundDecided, BlockCommitted, AnchorBlockSet, StateChanged} {
+		if counts[typ] == 0 {
+			t.Fatalf("No %s Notification", typ)
+		}
+	}
+
+	index := 0
+	for n := range blocks.Notifications() {
+		if n.Type != BlockCommitted {
+			t.Fatalf("Subscription to BlockCommitted received %s", n.Type)
+		}
+		if n.Block.Index() != index {
+			t.Fatalf("Block %d should be committed, not %d", index, n.Block.Index())
+		}
+		index++
+	}
+	if index != counts[BlockCommitted] {
+		t.Fatalf("Both Subscriptions should receive %d Blocks, not %d", counts[BlockCommitted], index)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	target := 10
+	err := gossip(nodes, target, false, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node0 := nodes[0]
+	if err := node0.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := node0.Pause(); err == nil {
+		t.Fatal("Pausing a paused node should fail")
+	}
+	pausedAt := node0.core.GetLastBlockIndex()
+
+	//the other nodes carry on without node0
+	err = bombardAndWait(nodes[1:], pausedAt+10, 6*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbi := node0.core.GetLastBlockIndex(); lbi != pausedAt {
+		t.Fatalf("Paused node0 should have stayed at Block %d, not %d", pausedAt, lbi)
+	}
+
+	trans, err := net.NewTCPTransport(node0.localAddr, nil, 2, time.Second, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := node0.Resume(trans); err != nil {
+		t.Fatal(err)
+	}
+
+	//node0 catches up and takes part in consensus again
+	err = bombardAndWait(nodes, pausedAt+20, 6*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkGossip(nodes, 0, t)
+}
+
+//TestQuickPauseResume pauses nodes right after they were resumed, before their
+//routines had a chance to start
+func TestQuickPauseResume(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	keys, peers := initPeers(4)
+	nodes := initNodes(keys, peers, 1000, 1000, "inmem", logger, t)
+	defer shutdownNodes(nodes)
+
+	for _, n := range nodes {
+		n.RunAsync(true)
+	}
+
+	node0 := nodes[0]
+	for i := 0; i < 3; i++ {
+		if err := node0.Pause(); err != nil {
+			t.Fatal(err)
+		}
+
+		trans, err := net.NewTCPTransport(node0.localAddr, nil, 2, time.Second, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := node0.Resume(trans); err != nil {
+			t.Fatal(err)
+		}
+		if !node0.isStarting() {
+			t.Fatal("A resumed node should be starting")
+		}
+	}
+
+	err := bombardAndWait(nodes, 5, 6*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestBootstrapAllNodes(t *testing.T) {
 	logger := common.NewTestLogger(t)
 
@@ -780,7 +1614,11 @@ func makeRandomTransactions(nodes []*Node, quit chan struct{}) {
 			default:
 				n := rand.Intn(len(nodes))
 				node := nodes[n]
-				submitTransaction(node, []byte(fmt.Sprintf("node%d transaction %d", n, seq[n])))
+				//valid kvstore transactions, which other Apps treat as
+				//opaque bytes. Keys are reused to overwrite values.
+				submitTransaction(node, kvstore.NewSetTx(
+					fmt.Sprintf("node%d-%d", n, seq[n]%10),
+					fmt.Sprintf("transaction %d", seq[n])))
 				seq[n] = seq[n] + 1
 				time.Sleep(3 * time.Millisecond)
 			}
@@ -789,12 +1627,13 @@ func makeRandomTransactions(nodes []*Node, quit chan struct{}) {
 }
 
 func submitTransaction(n *Node, tx []byte) error {
-	prox, ok := n.proxy.(*dummy.InmemDummyClient)
+	prox, ok := n.proxy.(interface {
+		SubmitTx(tx []byte) error
+	})
 	if !ok {
 		return fmt.Errorf("Error casting to InmemProp")
 	}
-	prox.SubmitTx([]byte(tx))
-	return nil
+	return prox.SubmitTx([]byte(tx))
 }
 
 func BenchmarkGossip(b *testing.B) {
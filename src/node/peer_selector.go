@@ -1,55 +1,462 @@
 package node
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
+	"time"
 
 	"github.com/mosaicnetworks/babble/src/peers"
 )
 
+//Names of the PeerSelector strategies that can be used in Config.PeerSelector
+const (
+	RandomSelector      = "random"
+	LeastRecentSelector = "least-recent"
+	MostEventsSelector  = "most-events"
+	LatencySelector     = "latency"
+)
+
+const (
+	//latencySmoothing is the weight of a new measurement in the moving average
+	//of a peer's round-trip time
+	latencySmoothing = 0.2
+
+	//A peer that fails to respond is excluded from selection for minBackoff.
+	//The delay doubles with every consecutive failure, up to maxBackoff.
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 1 * time.Minute
+
+	//A peer that is not ready, because it is catching up, is excluded from
+	//selection for notReadyBackoff. It does not count as a failure.
+	notReadyBackoff = 2 * time.Second
+)
+
 type PeerSelector interface {
 	Peers() *peers.Peers
+	//UpdateLast is called after a successful gossip with a peer
 	UpdateLast(peer string)
+	//UpdateKnown records the last Event index, per participant, that a peer
+	//knows of, as advertised in a SyncRequest or SyncResponse. The node also
+	//records its own, under its local address, before calling Next.
+	UpdateKnown(peer string, known map[int]int)
+	//UpdateSuccess records a successful SyncRequest and its round-trip time
+	UpdateSuccess(peer string, rtt time.Duration)
+	//UpdateFailure records a failed request. The peer will not be returned by
+	//Next until its backoff expires, unless all other peers are failing too.
+	UpdateFailure(peer string)
+	//UpdateNotReady records that a peer refused a request because it is not
+	//Babbling. The peer is left alone for a while, but this does not count as
+	//a failure.
+	UpdateNotReady(peer string)
+	//SetBusy marks a peer that we are currently gossiping with. Busy peers are
+	//not returned by Next.
+	SetBusy(peer string, busy bool)
+	//Health returns the health of every peer but ourselves
+	Health() []PeerHealth
+	//Next returns the next peer to gossip with, or nil if all peers are busy
 	Next() *peers.Peer
 }
 
+//PeerHealth summarises our interactions with a peer
+type PeerHealth struct {
+	ID                  int
+	NetAddr             string
+	PubKeyHex           string
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+	Latency             time.Duration //moving average of sync round-trip times
+	LastSuccess         time.Time
+	BackoffUntil        time.Time
+	Healthy             bool //false while the peer is backed off
+}
+
+//NewPeerSelector creates a PeerSelector from its name
+func NewPeerSelector(name string,
+	participants *peers.Peers,
+	localAddr string) (PeerSelector, error) {
+
+	switch name {
+	case "", RandomSelector:
+		return NewRandomPeerSelector(participants, localAddr), nil
+	case LeastRecentSelector:
+		return NewLeastRecentPeerSelector(participants, localAddr), nil
+	case MostEventsSelector:
+		return NewMostEventsPeerSelector(participants, localAddr), nil
+	case LatencySelector:
+		return NewLatencyPeerSelector(participants, localAddr), nil
+	default:
+		return nil, fmt.Errorf("Unknown PeerSelector %q", name)
+	}
+}
+
 //+++++++++++++++++++++++++++++++++++++++
-//RANDOM
+//BASE
 
-type RandomPeerSelector struct {
+//peerStats is what we know about a peer from previous syncs
+type peerStats struct {
+	lastSelected        int         //sequence number of the last time it was selected
+	known               map[int]int //Events known by the peer
+	rtt                 time.Duration
+	successes           int
+	failures            int
+	consecutiveFailures int
+	lastSuccess         time.Time
+	backoffUntil        time.Time
+}
+
+//basePeerSelector keeps track of peerStats and excludes failing peers. It does
+//not implement Next.
+type basePeerSelector struct {
 	peers     *peers.Peers
 	localAddr string
 	last      string
+	seq       int
+	stats     map[string]*peerStats
+	busy      map[string]bool
+	now       func() time.Time
+	rand      *rand.Rand
 }
 
-func NewRandomPeerSelector(participants *peers.Peers, localAddr string) *RandomPeerSelector {
-	return &RandomPeerSelector{
-		localAddr: localAddr,
+func newBasePeerSelector(participants *peers.Peers, localAddr string) basePeerSelector {
+	return basePeerSelector{
 		peers:     participants,
+		localAddr: localAddr,
+		stats:     make(map[string]*peerStats),
+		busy:      make(map[string]bool),
+		now:       time.Now,
+		rand:      newRand(0),
 	}
 }
 
-func (ps *RandomPeerSelector) Peers() *peers.Peers {
+//setEnvironment makes the PeerSelector use the Clock and random source of the
+//node
+func (ps *basePeerSelector) setEnvironment(now func() time.Time, rnd *rand.Rand) {
+	ps.now = now
+	ps.rand = rnd
+}
+
+func (ps *basePeerSelector) Peers() *peers.Peers {
 	return ps.peers
 }
 
-func (ps *RandomPeerSelector) UpdateLast(peer string) {
+func (ps *basePeerSelector) UpdateLast(peer string) {
 	ps.last = peer
 }
 
-func (ps *RandomPeerSelector) Next() *peers.Peer {
+func (ps *basePeerSelector) UpdateKnown(peer string, known map[int]int) {
+	if known == nil {
+		return
+	}
+	ps.get(peer).known = known
+}
+
+func (ps *basePeerSelector) UpdateSuccess(peer string, rtt time.Duration) {
+	s := ps.get(peer)
+	s.successes++
+	s.consecutiveFailures = 0
+	s.lastSuccess = ps.now()
+	s.backoffUntil = time.Time{}
+
+	if s.rtt == 0 {
+		s.rtt = rtt
+		return
+	}
+	s.rtt = time.Duration((1-latencySmoothing)*float64(s.rtt) + latencySmoothing*float64(rtt))
+}
+
+func (ps *basePeerSelector) UpdateFailure(peer string) {
+	s := ps.get(peer)
+	s.failures++
+	s.consecutiveFailures++
+
+	backoff := maxBackoff
+	if s.consecutiveFailures < 32 {
+		if b := minBackoff << uint(s.consecutiveFailures-1); b < maxBackoff {
+			backoff = b
+		}
+	}
+	s.backoffUntil = ps.now().Add(backoff)
+}
+
+func (ps *basePeerSelector) UpdateNotReady(peer string) {
+	s := ps.get(peer)
+	if until := ps.now().Add(notReadyBackoff); until.After(s.backoffUntil) {
+		s.backoffUntil = until
+	}
+}
+
+func (ps *basePeerSelector) SetBusy(peer string, busy bool) {
+	if busy {
+		ps.busy[peer] = true
+	} else {
+		delete(ps.busy, peer)
+	}
+}
+
+func (ps *basePeerSelector) Health() []PeerHealth {
+	now := ps.now()
+	res := []PeerHealth{}
+	for _, p := range ps.peers.ToPeerSlice() {
+		if p.NetAddr == ps.localAddr {
+			continue
+		}
+		s := ps.get(p.NetAddr)
+		res = append(res, PeerHealth{
+			ID:                  p.ID,
+			NetAddr:             p.NetAddr,
+			PubKeyHex:           p.PubKeyHex,
+			Successes:           s.successes,
+			Failures:            s.failures,
+			ConsecutiveFailures: s.consecutiveFailures,
+			Latency:             s.rtt,
+			LastSuccess:         s.lastSuccess,
+			BackoffUntil:        s.backoffUntil,
+			Healthy:             !now.Before(s.backoffUntil),
+		})
+	}
+	return res
+}
+
+func (ps *basePeerSelector) get(peer string) *peerStats {
+	s, ok := ps.stats[peer]
+	if !ok {
+		s = &peerStats{}
+		ps.stats[peer] = s
+	}
+	return s
+}
+
+//selectable returns all the peers but ourselves, those that are busy, and
+//those that are backed off. If all the peers that are not busy are backed off,
+//it returns the one whose backoff expires first. If there is a choice, it also
+//excludes the last peer we gossiped with.
+func (ps *basePeerSelector) selectable() []*peers.Peer {
 	selectablePeers := ps.peers.ToPeerSlice()
 
 	if len(selectablePeers) > 1 {
 		_, selectablePeers = peers.ExcludePeer(selectablePeers, ps.localAddr)
 
+		idle := []*peers.Peer{}
+		for _, p := range selectablePeers {
+			if !ps.busy[p.NetAddr] {
+				idle = append(idle, p)
+			}
+		}
+
+		selectablePeers = ps.healthy(idle)
+
 		if len(selectablePeers) > 1 {
 			_, selectablePeers = peers.ExcludePeer(selectablePeers, ps.last)
 		}
 	}
 
-	i := rand.Intn(len(selectablePeers))
+	return selectablePeers
+}
 
-	peer := selectablePeers[i]
+func (ps *basePeerSelector) healthy(candidates []*peers.Peer) []*peers.Peer {
+	now := ps.now()
+	res := []*peers.Peer{}
+	var first *peers.Peer
+	var firstExpiry time.Time
+	for _, p := range candidates {
+		until := ps.get(p.NetAddr).backoffUntil
+		if !now.Before(until) {
+			res = append(res, p)
+			continue
+		}
+		if first == nil || until.Before(firstExpiry) {
+			first, firstExpiry = p, until
+		}
+	}
+	if len(res) == 0 && first != nil {
+		res = append(res, first)
+	}
+	return res
+}
 
+//selected records that a peer was returned by Next
+func (ps *basePeerSelector) selected(peer *peers.Peer) *peers.Peer {
+	ps.seq++
+	ps.get(peer.NetAddr).lastSelected = ps.seq
 	return peer
 }
+
+//+++++++++++++++++++++++++++++++++++++++
+//RANDOM
+
+type RandomPeerSelector struct {
+	basePeerSelector
+}
+
+func NewRandomPeerSelector(participants *peers.Peers, localAddr string) *RandomPeerSelector {
+	return &RandomPeerSelector{
+		basePeerSelector: newBasePeerSelector(participants, localAddr),
+	}
+}
+
+func (ps *RandomPeerSelector) Next() *peers.Peer {
+	selectablePeers := ps.selectable()
+	if len(selectablePeers) == 0 {
+		return nil
+	}
+
+	i := ps.rand.Intn(len(selectablePeers))
+
+	return ps.selected(selectablePeers[i])
+}
+
+//+++++++++++++++++++++++++++++++++++++++
+//LEAST RECENT
+
+//LeastRecentPeerSelector picks the peer that was selected the longest time ago,
+//so that no peer is left out for many heartbeats. Peers that were never
+//selected come first, and ties are broken randomly.
+type LeastRecentPeerSelector struct {
+	basePeerSelector
+}
+
+func NewLeastRecentPeerSelector(participants *peers.Peers, localAddr string) *LeastRecentPeerSelector {
+	return &LeastRecentPeerSelector{
+		basePeerSelector: newBasePeerSelector(participants, localAddr),
+	}
+}
+
+func (ps *LeastRecentPeerSelector) Next() *peers.Peer {
+	candidates := []*peers.Peer{}
+	oldest := math.MaxInt64
+	for _, p := range ps.selectable() {
+		seq := ps.get(p.NetAddr).lastSelected
+		if seq < oldest {
+			oldest = seq
+			candidates = candidates[:0]
+		}
+		if seq == oldest {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return ps.selected(candidates[ps.rand.Intn(len(candidates))])
+}
+
+//+++++++++++++++++++++++++++++++++++++++
+//MOST EVENTS
+
+//MostEventsPeerSelector picks the peer that knows the most Events which we do
+//not have, judging from the last Known map it advertised and from the one that
+//was recorded for the local address. Peers we have not heard from yet come
+//first, and ties go to the least recently selected peer. The Known map of a
+//peer is forgotten once it is selected, so that a peer which stops responding
+//does not keep getting selected.
+type MostEventsPeerSelector struct {
+	basePeerSelector
+}
+
+func NewMostEventsPeerSelector(participants *peers.Peers, localAddr string) *MostEventsPeerSelector {
+	return &MostEventsPeerSelector{
+		basePeerSelector: newBasePeerSelector(participants, localAddr),
+	}
+}
+
+func (ps *MostEventsPeerSelector) Next() *peers.Peer {
+	local := ps.get(ps.localAddr).known
+
+	var best *peers.Peer
+	most, oldest := -1, 0
+	for _, p := range ps.selectable() {
+		lacking := ps.lacking(p, local)
+		seq := ps.get(p.NetAddr).lastSelected
+		if lacking > most || (lacking == most && seq < oldest) {
+			best, most, oldest = p, lacking, seq
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	ps.get(best.NetAddr).known = nil
+
+	return ps.selected(best)
+}
+
+//lacking counts the Events known by a peer that are not in local
+func (ps *MostEventsPeerSelector) lacking(peer *peers.Peer, local map[int]int) int {
+	stats := ps.get(peer.NetAddr)
+	if stats.known == nil {
+		if stats.lastSelected == 0 {
+			return math.MaxInt32
+		}
+		return 0
+	}
+
+	res := 0
+	for id, index := range stats.known {
+		localIndex, ok := local[id]
+		if !ok {
+			localIndex = -1
+		}
+		if index > localIndex {
+			res += index - localIndex
+		}
+	}
+	return res
+}
+
+//+++++++++++++++++++++++++++++++++++++++
+//LATENCY
+
+//LatencyPeerSelector picks peers randomly, with a probability inversely
+//proportional to their average sync round-trip time. Peers whose latency was
+//never measured are given the weight of the fastest peer.
+type LatencyPeerSelector struct {
+	basePeerSelector
+}
+
+func NewLatencyPeerSelector(participants *peers.Peers, localAddr string) *LatencyPeerSelector {
+	return &LatencyPeerSelector{
+		basePeerSelector: newBasePeerSelector(participants, localAddr),
+	}
+}
+
+func (ps *LatencyPeerSelector) Next() *peers.Peer {
+	selectablePeers := ps.selectable()
+	if len(selectablePeers) == 0 {
+		return nil
+	}
+
+	fastest := time.Duration(0)
+	for _, p := range selectablePeers {
+		rtt := ps.get(p.NetAddr).rtt
+		if rtt > 0 && (fastest == 0 || rtt < fastest) {
+			fastest = rtt
+		}
+	}
+	if fastest == 0 {
+		fastest = time.Millisecond
+	}
+
+	weights := make([]float64, len(selectablePeers))
+	total := 0.0
+	for i, p := range selectablePeers {
+		rtt := ps.get(p.NetAddr).rtt
+		if rtt <= 0 {
+			rtt = fastest
+		}
+		weights[i] = 1 / rtt.Seconds()
+		total += weights[i]
+	}
+
+	r := ps.rand.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return ps.selected(selectablePeers[i])
+		}
+		r -= w
+	}
+
+	return ps.selected(selectablePeers[len(selectablePeers)-1])
+}
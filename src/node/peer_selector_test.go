@@ -0,0 +1,248 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPeerSelector(t *testing.T) {
+	_, participants := initPeers(3)
+
+	for _, name := range []string{"", RandomSelector, LeastRecentSelector, MostEventsSelector, LatencySelector} {
+		if _, err := NewPeerSelector(name, participants, ""); err != nil {
+			t.Fatalf("NewPeerSelector(%q): %s", name, err)
+		}
+	}
+
+	if _, err := NewPeerSelector("fastest", participants, ""); err == nil {
+		t.Fatal("NewPeerSelector should fail with an unknown name")
+	}
+}
+
+func TestRandomPeerSelector(t *testing.T) {
+	_, participants := initPeers(3)
+	peers := participants.ToPeerSlice()
+	local, last := peers[0].NetAddr, peers[1].NetAddr
+
+	ps := NewRandomPeerSelector(participants, local)
+	ps.UpdateLast(last)
+
+	for i := 0; i < 20; i++ {
+		if p := ps.Next(); p.NetAddr != peers[2].NetAddr {
+			t.Fatalf("Next should return %s, not %s", peers[2].NetAddr, p.NetAddr)
+		}
+	}
+
+	//Selections are recorded like with the other PeerSelectors
+	if seq := ps.get(peers[2].NetAddr).lastSelected; seq != 20 {
+		t.Fatalf("%s should have been selected 20 times, not %d", peers[2].NetAddr, seq)
+	}
+}
+
+func TestLeastRecentPeerSelector(t *testing.T) {
+	_, participants := initPeers(5)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	ps := NewLeastRecentPeerSelector(participants, local)
+
+	//Every peer should be selected once before any is selected twice
+	for round := 0; round < 3; round++ {
+		selected := map[string]bool{}
+		for i := 0; i < len(peers)-1; i++ {
+			p := ps.Next()
+			if p.NetAddr == local {
+				t.Fatal("Next should not return the local peer")
+			}
+			if selected[p.NetAddr] {
+				t.Fatalf("round %d: %s selected twice", round, p.NetAddr)
+			}
+			selected[p.NetAddr] = true
+			ps.UpdateLast(p.NetAddr)
+		}
+	}
+}
+
+func TestMostEventsPeerSelector(t *testing.T) {
+	_, participants := initPeers(4)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	localKnown := map[int]int{}
+	for _, p := range peers {
+		localKnown[p.ID] = 5
+	}
+
+	ps := NewMostEventsPeerSelector(participants, local)
+	ps.UpdateKnown(local, localKnown)
+
+	//Peers we have not heard from come first
+	ps.UpdateKnown(peers[1].NetAddr, map[int]int{peers[1].ID: 10})
+	ps.UpdateKnown(peers[2].NetAddr, map[int]int{peers[2].ID: 7})
+	if p := ps.Next(); p.NetAddr != peers[3].NetAddr {
+		t.Fatalf("Next should return %s, not %s", peers[3].NetAddr, p.NetAddr)
+	}
+
+	//Then the peer with the most Events we lack
+	ps.UpdateKnown(peers[3].NetAddr, map[int]int{peers[3].ID: 5})
+	if p := ps.Next(); p.NetAddr != peers[1].NetAddr {
+		t.Fatalf("Next should return %s, not %s", peers[1].NetAddr, p.NetAddr)
+	}
+
+	//But not the last peer we synced with
+	ps.UpdateLast(peers[1].NetAddr)
+	if p := ps.Next(); p.NetAddr != peers[2].NetAddr {
+		t.Fatalf("Next should return %s, not %s", peers[2].NetAddr, p.NetAddr)
+	}
+
+	//A peer that did not respond is not selected again
+	ps.UpdateLast(peers[3].NetAddr)
+	if p := ps.Next(); p.NetAddr != peers[1].NetAddr {
+		t.Fatalf("Next should return %s, not %s", peers[1].NetAddr, p.NetAddr)
+	}
+}
+
+func TestLatencyPeerSelector(t *testing.T) {
+	_, participants := initPeers(4)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	ps := NewLatencyPeerSelector(participants, local)
+	ps.UpdateSuccess(peers[1].NetAddr, time.Millisecond)
+	ps.UpdateSuccess(peers[2].NetAddr, time.Second)
+	ps.UpdateSuccess(peers[3].NetAddr, time.Second)
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[ps.Next().NetAddr]++
+	}
+
+	if counts[local] != 0 {
+		t.Fatal("Next should not return the local peer")
+	}
+	if counts[peers[1].NetAddr] < 900 {
+		t.Fatalf("the fastest peer should be selected most of the time: %v", counts)
+	}
+
+	//A slow measurement only moves the average
+	ps.UpdateSuccess(peers[1].NetAddr, 6*time.Millisecond)
+	if rtt := ps.get(peers[1].NetAddr).rtt; rtt != 2*time.Millisecond {
+		t.Fatalf("average latency should be 2ms, not %s", rtt)
+	}
+}
+
+func TestPeerSelectorBackoff(t *testing.T) {
+	_, participants := initPeers(4)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	now := time.Unix(0, 0)
+	ps := NewRandomPeerSelector(participants, local)
+	ps.now = func() time.Time { return now }
+
+	//A failing peer is excluded until its backoff expires
+	ps.UpdateFailure(peers[1].NetAddr)
+	for i := 0; i < 20; i++ {
+		if p := ps.Next(); p.NetAddr == peers[1].NetAddr {
+			t.Fatalf("%s should be backed off", p.NetAddr)
+		}
+	}
+
+	//The backoff doubles with every consecutive failure
+	ps.UpdateFailure(peers[1].NetAddr)
+	ps.UpdateFailure(peers[1].NetAddr)
+	if until := ps.get(peers[1].NetAddr).backoffUntil; !until.Equal(now.Add(4 * minBackoff)) {
+		t.Fatalf("backoff should expire at %s, not %s", now.Add(4*minBackoff), until)
+	}
+
+	//If all peers are failing, the one whose backoff expires first is returned
+	now = now.Add(time.Second)
+	ps.UpdateFailure(peers[2].NetAddr)
+	ps.UpdateFailure(peers[3].NetAddr)
+	ps.UpdateFailure(peers[3].NetAddr)
+	if p := ps.Next(); p.NetAddr != peers[2].NetAddr {
+		t.Fatalf("Next should return %s, not %s", peers[2].NetAddr, p.NetAddr)
+	}
+
+	//A success resets the backoff
+	ps.UpdateSuccess(peers[1].NetAddr, time.Millisecond)
+
+	health := ps.Health()
+	if len(health) != 3 {
+		t.Fatalf("Health should return 3 peers, not %d", len(health))
+	}
+	for _, h := range health {
+		expected := h.NetAddr == peers[1].NetAddr
+		if h.Healthy != expected {
+			t.Fatalf("%s should have Healthy=%v", h.NetAddr, expected)
+		}
+	}
+	if h := health[0]; h.Successes != 1 || h.Failures != 3 || h.ConsecutiveFailures != 0 {
+		t.Fatalf("wrong health for %s: %+v", h.NetAddr, h)
+	}
+}
+
+func TestPeerSelectorNotReady(t *testing.T) {
+	_, participants := initPeers(4)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	now := time.Unix(0, 0)
+	ps := NewRandomPeerSelector(participants, local)
+	ps.now = func() time.Time { return now }
+
+	//A peer that is catching up is left alone, but it is not failing
+	ps.UpdateNotReady(peers[1].NetAddr)
+	for i := 0; i < 20; i++ {
+		if p := ps.Next(); p.NetAddr == peers[1].NetAddr {
+			t.Fatalf("%s should be backed off", p.NetAddr)
+		}
+	}
+	s := ps.get(peers[1].NetAddr)
+	if s.failures != 0 || s.consecutiveFailures != 0 {
+		t.Fatalf("not ready should not count as a failure: %+v", s)
+	}
+
+	//It does not shorten a longer backoff
+	for i := 0; i < 4; i++ {
+		ps.UpdateFailure(peers[2].NetAddr)
+	}
+	ps.UpdateNotReady(peers[2].NetAddr)
+	if until := ps.get(peers[2].NetAddr).backoffUntil; !until.Equal(now.Add(8 * minBackoff)) {
+		t.Fatalf("backoff should expire at %s, not %s", now.Add(8*minBackoff), until)
+	}
+
+	now = now.Add(notReadyBackoff)
+	found := false
+	for i := 0; i < 20 && !found; i++ {
+		found = ps.Next().NetAddr == peers[1].NetAddr
+	}
+	if !found {
+		t.Fatalf("%s should be selectable again", peers[1].NetAddr)
+	}
+}
+
+func TestPeerSelectorBusy(t *testing.T) {
+	_, participants := initPeers(4)
+	peers := participants.ToPeerSlice()
+	local := peers[0].NetAddr
+
+	ps := NewLeastRecentPeerSelector(participants, local)
+
+	//Peers that are already being gossiped with are not returned
+	for i := 0; i < len(peers)-1; i++ {
+		p := ps.Next()
+		if p == nil {
+			t.Fatalf("Next should return a peer after %d selections", i)
+		}
+		ps.SetBusy(p.NetAddr, true)
+	}
+	if p := ps.Next(); p != nil {
+		t.Fatalf("Next should return nil when all peers are busy, not %s", p.NetAddr)
+	}
+
+	ps.SetBusy(peers[2].NetAddr, false)
+	if p := ps.Next(); p == nil || p.NetAddr != peers[2].NetAddr {
+		t.Fatalf("Next should return %s", peers[2].NetAddr)
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"sync/atomic"
 )
 
-// NodeState captures the state of a Babble node: Babbling, CatchingUp or Shutdown
+// NodeState captures the state of a Babble node: Babbling, CatchingUp,
+// Shutdown, Failed or Paused
 type NodeState uint32
 
 const (
@@ -15,6 +16,14 @@ const (
 	CatchingUp
 
 	Shutdown
+
+	// Failed is the state of a node that gave up trying to fast-forward. It
+	// stays there until it is shut down.
+	Failed
+
+	// Paused is the state of a node whose routines and transport were stopped
+	// by Pause, but which keeps its store and can Resume.
+	Paused
 )
 
 func (s NodeState) String() string {
@@ -25,6 +34,10 @@ func (s NodeState) String() string {
 		return "CatchingUp"
 	case Shutdown:
 		return "Shutdown"
+	case Failed:
+		return "Failed"
+	case Paused:
+		return "Paused"
 	default:
 		return "Unknown"
 	}
@@ -34,6 +47,9 @@ type nodeState struct {
 	state    NodeState
 	starting int32
 	wg       sync.WaitGroup
+
+	//onChange, if set, is called with the new state every time it changes
+	onChange func(NodeState)
 }
 
 func (b *nodeState) getState() NodeState {
@@ -43,7 +59,24 @@ func (b *nodeState) getState() NodeState {
 
 func (b *nodeState) setState(s NodeState) {
 	stateAddr := (*uint32)(&b.state)
-	atomic.StoreUint32(stateAddr, uint32(s))
+	old := NodeState(atomic.SwapUint32(stateAddr, uint32(s)))
+	if old != s && b.onChange != nil {
+		b.onChange(s)
+	}
+}
+
+//changeState moves from one state to another in a single step, and fails if
+//the node is not in the from state, for instance because it was paused or shut
+//down in the meantime
+func (b *nodeState) changeState(from, to NodeState) bool {
+	stateAddr := (*uint32)(&b.state)
+	if !atomic.CompareAndSwapUint32(stateAddr, uint32(from), uint32(to)) {
+		return false
+	}
+	if from != to && b.onChange != nil {
+		b.onChange(to)
+	}
+	return true
 }
 
 func (b *nodeState) setStarting(starting bool) {
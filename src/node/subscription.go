@@ -0,0 +1,164 @@
+package node
+
+import (
+	"sync"
+	"sync/atomic"
+
+	hg "github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+//NotificationType identifies what a Notification is about
+type NotificationType int
+
+const (
+	//SelfEventCreated is published when the node creates one of its own
+	//Events. Event is set.
+	SelfEventCreated NotificationType = iota
+	//EventInserted is published for every Event inserted in the hashgraph,
+	//including the node's own. Event is set.
+	EventInserted
+	//RoundDecided is published when a Round attains consensus. Round is set.
+	RoundDecided
+	//BlockCommitted is published once the App has committed a Block. Block is
+	//set, with the StateHash returned by the App.
+	BlockCommitted
+	//AnchorBlockSet is published when a Block collects enough signatures to
+	//become the AnchorBlock. Block is set, with its signatures.
+	AnchorBlockSet
+	//StateChanged is published when the node changes state. State is set.
+	StateChanged
+)
+
+func (t NotificationType) String() string {
+	switch t {
+	case SelfEventCreated:
+		return "SelfEventCreated"
+	case EventInserted:
+		return "EventInserted"
+	case RoundDecided:
+		return "RoundDecided"
+	case BlockCommitted:
+		return "BlockCommitted"
+	case AnchorBlockSet:
+		return "AnchorBlockSet"
+	case StateChanged:
+		return "StateChanged"
+	default:
+		return "Unknown"
+	}
+}
+
+//DecidedRound is a Round that attained consensus, with its famous witnesses
+type DecidedRound struct {
+	Index           int
+	FamousWitnesses []string
+}
+
+//Notification describes something that happened in the node. Only the fields
+//that correspond to the Type are set. They must not be modified.
+type Notification struct {
+	Type  NotificationType
+	Event *hg.Event
+	Round *DecidedRound
+	Block *hg.Block
+	State NodeState
+}
+
+//Subscription delivers Notifications on a buffered channel. A Subscription
+//never slows the node down: when its buffer is full, Notifications are dropped
+//and counted.
+type Subscription struct {
+	ch      chan Notification
+	types   map[NotificationType]bool //all types if empty
+	dropped int64
+	cancel  func()
+}
+
+//Notifications returns the channel on which Notifications are delivered. It is
+//closed by Unsubscribe.
+func (s *Subscription) Notifications() <-chan Notification {
+	return s.ch
+}
+
+//Dropped returns the number of Notifications that were dropped because the
+//buffer was full
+func (s *Subscription) Dropped() int {
+	return int(atomic.LoadInt64(&s.dropped))
+}
+
+//Unsubscribe stops the delivery of Notifications and closes the channel
+func (s *Subscription) Unsubscribe() {
+	s.cancel()
+}
+
+func (s *Subscription) deliver(n Notification) {
+	if len(s.types) > 0 && !s.types[n.Type] {
+		return
+	}
+	select {
+	case s.ch <- n:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+//notifier publishes Notifications to Subscriptions. A nil notifier publishes
+//nothing.
+type notifier struct {
+	sync.RWMutex
+	subscriptions map[*Subscription]bool
+}
+
+func newNotifier() *notifier {
+	return &notifier{
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+func (nt *notifier) subscribe(bufferSize int, types []NotificationType) *Subscription {
+	s := &Subscription{
+		ch:    make(chan Notification, bufferSize),
+		types: make(map[NotificationType]bool),
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+
+	var once sync.Once
+	s.cancel = func() {
+		once.Do(func() {
+			nt.Lock()
+			defer nt.Unlock()
+			delete(nt.subscriptions, s)
+			close(s.ch)
+		})
+	}
+
+	nt.Lock()
+	defer nt.Unlock()
+	nt.subscriptions[s] = true
+
+	return s
+}
+
+func (nt *notifier) publish(n Notification) {
+	if nt == nil {
+		return
+	}
+
+	nt.RLock()
+	defer nt.RUnlock()
+
+	for s := range nt.subscriptions {
+		s.deliver(n)
+	}
+}
+
+//Subscribe returns a Subscription to the Notifications of the given types, or
+//of all types if none is given. Notifications are buffered up to bufferSize;
+//subscribers that fall further behind miss Notifications (cf
+//Subscription.Dropped). It is meant for monitoring and indexing components
+//that embed the node, and which should not have to act as the App.
+func (n *Node) Subscribe(bufferSize int, types ...NotificationType) *Subscription {
+	return n.notifier.subscribe(bufferSize, types)
+}
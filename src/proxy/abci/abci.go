@@ -0,0 +1,215 @@
+package abci
+
+/*
+The types below mirror the ABCI messages of Tendermint, minus the parts that
+have no equivalent in Babble (validator updates, consensus params, evidence).
+They are exchanged as JSON-RPC over TCP, like the messages of the socket proxy,
+so an App can implement the protocol in any language. Go Apps written against
+the Application interface can be served with NewServer.
+*/
+
+//Methods implemented by the App, and called by Babble, as "ABCI.<method>"
+const (
+	MethodInfo               = "ABCI.Info"
+	MethodCheckTx            = "ABCI.CheckTx"
+	MethodBeginBlock         = "ABCI.BeginBlock"
+	MethodDeliverTx          = "ABCI.DeliverTx"
+	MethodEndBlock           = "ABCI.EndBlock"
+	MethodCommit             = "ABCI.Commit"
+	MethodListSnapshots      = "ABCI.ListSnapshots"
+	MethodOfferSnapshot      = "ABCI.OfferSnapshot"
+	MethodLoadSnapshotChunk  = "ABCI.LoadSnapshotChunk"
+	MethodApplySnapshotChunk = "ABCI.ApplySnapshotChunk"
+	MethodPing               = "ABCI.Ping"
+)
+
+//CodeTypeOK is the Code of successful CheckTx and DeliverTx responses
+const CodeTypeOK uint32 = 0
+
+//Results of OfferSnapshot and ApplySnapshotChunk
+const (
+	ResultUnknown uint32 = iota
+	ResultAccept
+	ResultAbort
+	ResultReject
+	ResultRetry
+)
+
+type Event struct {
+	Type       string
+	Attributes []EventAttribute
+}
+
+type EventAttribute struct {
+	Key   string
+	Value string
+}
+
+type RequestInfo struct {
+	Version string
+}
+
+type ResponseInfo struct {
+	Data             string
+	Version          string
+	LastBlockHeight  int64
+	LastBlockAppHash []byte
+}
+
+type RequestCheckTx struct {
+	Tx []byte
+}
+
+type ResponseCheckTx struct {
+	Code   uint32
+	Data   []byte
+	Log    string
+	Events []Event
+}
+
+//Header describes a Block. Heights start at 1, so the Block with index i is at
+//height i+1.
+type Header struct {
+	Height        int64
+	RoundReceived int
+	FrameHash     []byte
+}
+
+type RequestBeginBlock struct {
+	Hash   []byte
+	Header Header
+}
+
+type ResponseBeginBlock struct {
+	Events []Event
+}
+
+type RequestDeliverTx struct {
+	Tx []byte
+}
+
+type ResponseDeliverTx struct {
+	Code   uint32
+	Data   []byte
+	Log    string
+	Events []Event
+}
+
+type RequestEndBlock struct {
+	Height int64
+}
+
+type ResponseEndBlock struct {
+	Events []Event
+}
+
+type RequestCommit struct{}
+
+//ResponseCommit carries the App hash, which becomes the StateHash of the Block
+type ResponseCommit struct {
+	Data []byte
+}
+
+//Snapshot describes a snapshot taken by the App. Chunks are loaded and applied
+//one by one.
+type Snapshot struct {
+	Height   uint64
+	Format   uint32
+	Chunks   uint32
+	Hash     []byte
+	Metadata []byte
+}
+
+type RequestListSnapshots struct{}
+
+type ResponseListSnapshots struct {
+	Snapshots []*Snapshot
+}
+
+type RequestOfferSnapshot struct {
+	Snapshot *Snapshot
+	AppHash  []byte
+}
+
+type ResponseOfferSnapshot struct {
+	Result uint32
+}
+
+type RequestLoadSnapshotChunk struct {
+	Height uint64
+	Format uint32
+	Chunk  uint32
+}
+
+type ResponseLoadSnapshotChunk struct {
+	Chunk []byte
+}
+
+type RequestApplySnapshotChunk struct {
+	Index  uint32
+	Chunk  []byte
+	Sender string
+}
+
+type ResponseApplySnapshotChunk struct {
+	Result uint32
+}
+
+//Application is the interface of ABCI Apps. Its methods are called one at a
+//time.
+type Application interface {
+	Info(RequestInfo) ResponseInfo
+	CheckTx(RequestCheckTx) ResponseCheckTx
+	BeginBlock(RequestBeginBlock) ResponseBeginBlock
+	DeliverTx(RequestDeliverTx) ResponseDeliverTx
+	EndBlock(RequestEndBlock) ResponseEndBlock
+	Commit() ResponseCommit
+	ListSnapshots(RequestListSnapshots) ResponseListSnapshots
+	OfferSnapshot(RequestOfferSnapshot) ResponseOfferSnapshot
+	LoadSnapshotChunk(RequestLoadSnapshotChunk) ResponseLoadSnapshotChunk
+	ApplySnapshotChunk(RequestApplySnapshotChunk) ResponseApplySnapshotChunk
+}
+
+//BaseApplication implements Application with no-ops. Apps can embed it and
+//override the methods they need.
+type BaseApplication struct{}
+
+func (BaseApplication) Info(req RequestInfo) ResponseInfo {
+	return ResponseInfo{}
+}
+
+func (BaseApplication) CheckTx(req RequestCheckTx) ResponseCheckTx {
+	return ResponseCheckTx{Code: CodeTypeOK}
+}
+
+func (BaseApplication) BeginBlock(req RequestBeginBlock) ResponseBeginBlock {
+	return ResponseBeginBlock{}
+}
+
+func (BaseApplication) DeliverTx(req RequestDeliverTx) ResponseDeliverTx {
+	return ResponseDeliverTx{Code: CodeTypeOK}
+}
+
+func (BaseApplication) EndBlock(req RequestEndBlock) ResponseEndBlock {
+	return ResponseEndBlock{}
+}
+
+func (BaseApplication) Commit() ResponseCommit {
+	return ResponseCommit{}
+}
+
+func (BaseApplication) ListSnapshots(req RequestListSnapshots) ResponseListSnapshots {
+	return ResponseListSnapshots{}
+}
+
+func (BaseApplication) OfferSnapshot(req RequestOfferSnapshot) ResponseOfferSnapshot {
+	return ResponseOfferSnapshot{Result: ResultReject}
+}
+
+func (BaseApplication) LoadSnapshotChunk(req RequestLoadSnapshotChunk) ResponseLoadSnapshotChunk {
+	return ResponseLoadSnapshotChunk{}
+}
+
+func (BaseApplication) ApplySnapshotChunk(req RequestApplySnapshotChunk) ResponseApplySnapshotChunk {
+	return ResponseApplySnapshotChunk{Result: ResultAbort}
+}
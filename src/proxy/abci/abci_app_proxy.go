@@ -0,0 +1,434 @@
+package abci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/socket/client"
+	"github.com/sirupsen/logrus"
+)
+
+//ABCIAppProxy is an AppProxy for Apps that implement the ABCI socket protocol.
+//Every Block is delivered as a BeginBlock, DeliverTx*, EndBlock, Commit
+//sequence, and snapshots go through the state-sync calls. Transactions are
+//submitted to bindAddr with Babble.SubmitTx, as with the SocketAppProxy.
+type ABCIAppProxy struct {
+	clientAddress string
+	bindAddress   string
+
+	client   *client.Client
+	listener net.Listener
+	submitCh chan proxy.SubmittedTx
+
+	//lock serialises the calls to the App, which expects one Block at a time
+	lock sync.Mutex
+
+	//recovering is set when a Block fails halfway; before delivering it
+	//again, we check whether the App committed it after all
+	recovering bool
+
+	logger *logrus.Logger
+}
+
+func NewABCIAppProxy(clientAddr string, bindAddr string, timeout time.Duration, logger *logrus.Logger) (*ABCIAppProxy, error) {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	l, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to listen")
+		return nil, err
+	}
+
+	appProxy := &ABCIAppProxy{
+		clientAddress: clientAddr,
+		bindAddress:   bindAddr,
+		client:        client.NewClient(clientAddr, timeout, logger),
+		listener:      l,
+		submitCh:      make(chan proxy.SubmittedTx),
+		logger:        logger,
+	}
+
+	appProxy.client.HealthCheck(MethodPing, client.DefaultPingInterval)
+
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName("Babble", &submitService{appProxy})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				logger.WithField("error", err).Error("Failed to accept")
+				return
+			}
+			go rpcServer.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return appProxy, nil
+}
+
+//submitService answers the Babble.SubmitTx requests of the App
+type submitService struct {
+	p *ABCIAppProxy
+}
+
+func (s *submitService) Ping(nonce int, pong *int) error {
+	*pong = nonce
+	return nil
+}
+
+func (s *submitService) SubmitTx(tx []byte, ack *bool) error {
+	s.p.logger.Debug("SubmitTx")
+
+	err := proxy.Submit(s.p.submitCh, tx)
+
+	*ack = err == nil
+
+	return err
+}
+
+//Close stops the health checks of the App and stops accepting transactions
+func (p *ABCIAppProxy) Close() error {
+	p.client.Close()
+	return p.listener.Close()
+}
+
+//ConnectionStatus reports the state of the connection to the App
+func (p *ABCIAppProxy) ConnectionStatus() proxy.ConnectionStatus {
+	return p.client.Status()
+}
+
+//++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+//Implement AppProxy Interface
+
+func (p *ABCIAppProxy) SubmitCh() chan proxy.SubmittedTx {
+	return p.submitCh
+}
+
+//CheckTx rejects transactions whose ResponseCheckTx has a Code other than
+//CodeTypeOK
+func (p *ABCIAppProxy) CheckTx(tx []byte) error {
+	var res ResponseCheckTx
+	if err := p.client.Call(MethodCheckTx, RequestCheckTx{Tx: tx}, &res); err != nil {
+		return err
+	}
+
+	if res.Code != CodeTypeOK {
+		if res.Log == "" {
+			return fmt.Errorf("CheckTx failed with code %d", res.Code)
+		}
+		return fmt.Errorf("%s", res.Log)
+	}
+
+	return nil
+}
+
+//CommitBlock delivers a Block to the App and commits it. The results of
+//DeliverTx are returned as transaction results, and the App hash becomes the
+//StateHash of the Block.
+func (p *ABCIAppProxy) CommitBlock(block hashgraph.Block) (proxy.CommitResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	height := int64(block.Index() + 1)
+
+	if p.recovering {
+		var info ResponseInfo
+		if err := p.client.Call(MethodInfo, RequestInfo{}, &info); err != nil {
+			return proxy.CommitResponse{}, err
+		}
+		p.recovering = false
+		if info.LastBlockHeight >= height {
+			p.logger.WithField("height", height).Debug("ABCIAppProxy: Block already committed")
+			return proxy.CommitResponse{StateHash: info.LastBlockAppHash}, nil
+		}
+	}
+
+	resp, err := p.deliverBlock(block, height)
+	if err != nil {
+		p.recovering = true
+		return proxy.CommitResponse{}, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      block.Index(),
+		"state_hash": resp.StateHash,
+		"txs":        len(resp.Results),
+	}).Debug("ABCIAppProxy.CommitBlock")
+
+	return resp, nil
+}
+
+func (p *ABCIAppProxy) deliverBlock(block hashgraph.Block, height int64) (proxy.CommitResponse, error) {
+	hash, err := block.Body.Hash()
+	if err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	begin := RequestBeginBlock{
+		Hash: hash,
+		Header: Header{
+			Height:        height,
+			RoundReceived: block.RoundReceived(),
+			FrameHash:     block.FrameHash(),
+		},
+	}
+	if err := p.client.Call(MethodBeginBlock, begin, &ResponseBeginBlock{}); err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	results := make([]hashgraph.TxResult, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		var res ResponseDeliverTx
+		if err := p.client.Call(MethodDeliverTx, RequestDeliverTx{Tx: tx}, &res); err != nil {
+			return proxy.CommitResponse{}, err
+		}
+		results[i] = hashgraph.TxResult{
+			Code:   res.Code,
+			Log:    res.Log,
+			Events: txEvents(res.Events),
+		}
+	}
+
+	if err := p.client.Call(MethodEndBlock, RequestEndBlock{Height: height}, &ResponseEndBlock{}); err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	var commit ResponseCommit
+	if err := p.client.Call(MethodCommit, RequestCommit{}, &commit); err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	return proxy.CommitResponse{StateHash: commit.Data, Results: results}, nil
+}
+
+//GetSnapshot returns the snapshot of a Block, with its description and all its
+//chunks, encoded in JSON
+func (p *ABCIAppProxy) GetSnapshot(blockIndex int) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	snapshot, err := p.findSnapshot(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := snapshotBundle{Snapshot: snapshot}
+	for i := uint32(0); i < snapshot.Chunks; i++ {
+		chunk, err := p.loadChunk(snapshot, i)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Chunks = append(bundle.Chunks, chunk)
+	}
+
+	return json.Marshal(bundle)
+}
+
+//Restore offers a snapshot returned by GetSnapshot to the App, and applies all
+//its chunks
+func (p *ABCIAppProxy) Restore(data []byte) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var bundle snapshotBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, err
+	}
+
+	if err := p.offerSnapshot(bundle.Snapshot); err != nil {
+		return nil, err
+	}
+	for i, chunk := range bundle.Chunks {
+		if err := p.applyChunk(uint32(i), chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.restoredAppHash(int64(bundle.Snapshot.Height))
+}
+
+//GetSnapshotManifest loads the chunks of the snapshot taken by the App at the
+//height of the Block, to hash them. The ABCI description of the snapshot is
+//carried in the Metadata of the manifest.
+func (p *ABCIAppProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	snapshot, err := p.findSnapshot(blockIndex)
+	if err != nil {
+		return proxy.SnapshotManifest{}, err
+	}
+
+	metadata, err := json.Marshal(snapshot)
+	if err != nil {
+		return proxy.SnapshotManifest{}, err
+	}
+
+	manifest := proxy.SnapshotManifest{
+		BlockIndex: blockIndex,
+		Chunks:     [][]byte{},
+		Metadata:   metadata,
+	}
+	for i := uint32(0); i < snapshot.Chunks; i++ {
+		chunk, err := p.loadChunk(snapshot, i)
+		if err != nil {
+			return proxy.SnapshotManifest{}, err
+		}
+		manifest.Size += int64(len(chunk))
+		manifest.Chunks = append(manifest.Chunks, crypto.SHA256(chunk))
+	}
+
+	return manifest, nil
+}
+
+func (p *ABCIAppProxy) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	snapshot, err := p.findSnapshot(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.loadChunk(snapshot, uint32(chunk))
+}
+
+//RestoreChunk offers the snapshot to the App with the first chunk, and applies
+//the chunks in order
+func (p *ABCIAppProxy) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if chunk == 0 {
+		var snapshot Snapshot
+		if err := json.Unmarshal(manifest.Metadata, &snapshot); err != nil {
+			return fmt.Errorf("Invalid ABCI snapshot metadata: %v", err)
+		}
+		if err := p.offerSnapshot(&snapshot); err != nil {
+			return err
+		}
+	}
+
+	return p.applyChunk(uint32(chunk), data)
+}
+
+func (p *ABCIAppProxy) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.restoredAppHash(int64(manifest.BlockIndex + 1))
+}
+
+//++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+//Snapshot helpers
+
+//snapshotBundle is the encoding of the snapshots returned by GetSnapshot
+type snapshotBundle struct {
+	Snapshot *Snapshot
+	Chunks   [][]byte
+}
+
+//findSnapshot returns the snapshot taken by the App at the height of a Block
+func (p *ABCIAppProxy) findSnapshot(blockIndex int) (*Snapshot, error) {
+	var res ResponseListSnapshots
+	if err := p.client.Call(MethodListSnapshots, RequestListSnapshots{}, &res); err != nil {
+		return nil, err
+	}
+
+	for _, s := range res.Snapshots {
+		if s != nil && s.Height == uint64(blockIndex+1) {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No ABCI snapshot at height %d", blockIndex+1)
+}
+
+func (p *ABCIAppProxy) loadChunk(snapshot *Snapshot, chunk uint32) ([]byte, error) {
+	req := RequestLoadSnapshotChunk{
+		Height: snapshot.Height,
+		Format: snapshot.Format,
+		Chunk:  chunk,
+	}
+
+	var res ResponseLoadSnapshotChunk
+	if err := p.client.Call(MethodLoadSnapshotChunk, req, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Chunk, nil
+}
+
+func (p *ABCIAppProxy) offerSnapshot(snapshot *Snapshot) error {
+	var res ResponseOfferSnapshot
+	if err := p.client.Call(MethodOfferSnapshot, RequestOfferSnapshot{Snapshot: snapshot}, &res); err != nil {
+		return err
+	}
+
+	if res.Result != ResultAccept {
+		return fmt.Errorf("App did not accept snapshot at height %d (result %d)", snapshot.Height, res.Result)
+	}
+
+	return nil
+}
+
+func (p *ABCIAppProxy) applyChunk(index uint32, chunk []byte) error {
+	var res ResponseApplySnapshotChunk
+	if err := p.client.Call(MethodApplySnapshotChunk, RequestApplySnapshotChunk{Index: index, Chunk: chunk}, &res); err != nil {
+		return err
+	}
+
+	if res.Result != ResultAccept {
+		return fmt.Errorf("App did not accept snapshot chunk %d (result %d)", index, res.Result)
+	}
+
+	return nil
+}
+
+//restoredAppHash asks the App for its hash once a snapshot has been restored,
+//and checks that it is at the expected height
+func (p *ABCIAppProxy) restoredAppHash(height int64) ([]byte, error) {
+	var info ResponseInfo
+	if err := p.client.Call(MethodInfo, RequestInfo{}, &info); err != nil {
+		return nil, err
+	}
+
+	if info.LastBlockHeight != height {
+		return nil, fmt.Errorf("App restored height %d, not %d", info.LastBlockHeight, height)
+	}
+
+	return info.LastBlockAppHash, nil
+}
+
+//txEvents converts ABCI Events into the Events of transaction results. If an
+//attribute appears twice, the last value wins.
+func txEvents(events []Event) []hashgraph.TxEvent {
+	if len(events) == 0 {
+		return nil
+	}
+
+	res := make([]hashgraph.TxEvent, len(events))
+	for i, e := range events {
+		res[i] = hashgraph.TxEvent{
+			Type:       e.Type,
+			Attributes: make(map[string]string, len(e.Attributes)),
+		}
+		for _, a := range e.Attributes {
+			res[i].Attributes[a.Key] = a.Value
+		}
+	}
+
+	return res
+}
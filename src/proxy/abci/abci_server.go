@@ -0,0 +1,141 @@
+package abci
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+//Server serves an Application over the ABCI socket protocol. It is the App
+//side of the ABCIAppProxy, for Apps written in Go.
+type Server struct {
+	app      Application
+	listener net.Listener
+	logger   *logrus.Logger
+
+	//ABCI Apps are not expected to handle concurrent calls
+	lock sync.Mutex
+}
+
+func NewServer(bindAddr string, app Application, logger *logrus.Logger) (*Server, error) {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	l, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		app:      app,
+		listener: l,
+		logger:   logger,
+	}
+
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName("ABCI", &abciService{server})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				logger.WithField("error", err).Debug("ABCI Server stopped accepting connections")
+				return
+			}
+			go rpcServer.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return server, nil
+}
+
+//Close stops accepting connections
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+//abciService exposes the methods of the Application to net/rpc, which wants
+//them in the (args, *reply) error form
+type abciService struct {
+	s *Server
+}
+
+func (a *abciService) Ping(nonce int, pong *int) error {
+	*pong = nonce
+	return nil
+}
+
+func (a *abciService) Info(req RequestInfo, res *ResponseInfo) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.Info(req)
+	return nil
+}
+
+func (a *abciService) CheckTx(req RequestCheckTx, res *ResponseCheckTx) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.CheckTx(req)
+	return nil
+}
+
+func (a *abciService) BeginBlock(req RequestBeginBlock, res *ResponseBeginBlock) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.BeginBlock(req)
+	return nil
+}
+
+func (a *abciService) DeliverTx(req RequestDeliverTx, res *ResponseDeliverTx) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.DeliverTx(req)
+	return nil
+}
+
+func (a *abciService) EndBlock(req RequestEndBlock, res *ResponseEndBlock) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.EndBlock(req)
+	return nil
+}
+
+func (a *abciService) Commit(req RequestCommit, res *ResponseCommit) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.Commit()
+	return nil
+}
+
+func (a *abciService) ListSnapshots(req RequestListSnapshots, res *ResponseListSnapshots) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.ListSnapshots(req)
+	return nil
+}
+
+func (a *abciService) OfferSnapshot(req RequestOfferSnapshot, res *ResponseOfferSnapshot) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.OfferSnapshot(req)
+	return nil
+}
+
+func (a *abciService) LoadSnapshotChunk(req RequestLoadSnapshotChunk, res *ResponseLoadSnapshotChunk) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.LoadSnapshotChunk(req)
+	return nil
+}
+
+func (a *abciService) ApplySnapshotChunk(req RequestApplySnapshotChunk, res *ResponseApplySnapshotChunk) error {
+	a.s.lock.Lock()
+	defer a.s.lock.Unlock()
+	*res = a.s.app.ApplySnapshotChunk(req)
+	return nil
+}
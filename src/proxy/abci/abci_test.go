@@ -0,0 +1,243 @@
+package abci
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy/socket/client"
+)
+
+//kvApp is a stand-in ABCI App: a key-value store fed with "key=value"
+//transactions, which takes a snapshot, in two chunks, at every height
+type kvApp struct {
+	BaseApplication
+
+	state     map[string]string
+	height    int64
+	appHash   []byte
+	snapshots map[uint64][][]byte
+
+	restoring *Snapshot
+	chunks    [][]byte
+}
+
+func newKVApp() *kvApp {
+	return &kvApp{
+		state:     map[string]string{},
+		snapshots: map[uint64][][]byte{},
+	}
+}
+
+func (a *kvApp) parse(tx []byte) (string, string, bool) {
+	kv := strings.SplitN(string(tx), "=", 2)
+	if len(kv) != 2 {
+		return "", "", false
+	}
+	return kv[0], kv[1], true
+}
+
+func (a *kvApp) Info(req RequestInfo) ResponseInfo {
+	return ResponseInfo{LastBlockHeight: a.height, LastBlockAppHash: a.appHash}
+}
+
+func (a *kvApp) CheckTx(req RequestCheckTx) ResponseCheckTx {
+	if _, _, ok := a.parse(req.Tx); !ok {
+		return ResponseCheckTx{Code: 1, Log: "invalid tx"}
+	}
+	return ResponseCheckTx{Code: CodeTypeOK}
+}
+
+func (a *kvApp) DeliverTx(req RequestDeliverTx) ResponseDeliverTx {
+	k, v, ok := a.parse(req.Tx)
+	if !ok {
+		return ResponseDeliverTx{Code: 1, Log: "invalid tx"}
+	}
+	a.state[k] = v
+	return ResponseDeliverTx{
+		Code:   CodeTypeOK,
+		Events: []Event{Event{Type: "set", Attributes: []EventAttribute{EventAttribute{Key: "key", Value: k}}}},
+	}
+}
+
+func (a *kvApp) Commit() ResponseCommit {
+	a.height++
+	data, _ := json.Marshal(a.state)
+	a.appHash = crypto.SHA256(data)
+	a.snapshots[uint64(a.height)] = [][]byte{data[:len(data)/2], data[len(data)/2:]}
+	return ResponseCommit{Data: a.appHash}
+}
+
+func (a *kvApp) ListSnapshots(req RequestListSnapshots) ResponseListSnapshots {
+	res := ResponseListSnapshots{}
+	for height, chunks := range a.snapshots {
+		res.Snapshots = append(res.Snapshots, &Snapshot{Height: height, Format: 1, Chunks: uint32(len(chunks))})
+	}
+	return res
+}
+
+func (a *kvApp) LoadSnapshotChunk(req RequestLoadSnapshotChunk) ResponseLoadSnapshotChunk {
+	return ResponseLoadSnapshotChunk{Chunk: a.snapshots[req.Height][req.Chunk]}
+}
+
+func (a *kvApp) OfferSnapshot(req RequestOfferSnapshot) ResponseOfferSnapshot {
+	if req.Snapshot.Format != 1 {
+		return ResponseOfferSnapshot{Result: ResultReject}
+	}
+	a.restoring = req.Snapshot
+	a.chunks = [][]byte{}
+	return ResponseOfferSnapshot{Result: ResultAccept}
+}
+
+func (a *kvApp) ApplySnapshotChunk(req RequestApplySnapshotChunk) ResponseApplySnapshotChunk {
+	if a.restoring == nil || int(req.Index) != len(a.chunks) {
+		return ResponseApplySnapshotChunk{Result: ResultAbort}
+	}
+	a.chunks = append(a.chunks, req.Chunk)
+	if len(a.chunks) == int(a.restoring.Chunks) {
+		data := []byte{}
+		for _, c := range a.chunks {
+			data = append(data, c...)
+		}
+		state := map[string]string{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return ResponseApplySnapshotChunk{Result: ResultAbort}
+		}
+		a.state = state
+		a.height = int64(a.restoring.Height)
+		a.appHash = crypto.SHA256(data)
+		a.restoring = nil
+	}
+	return ResponseApplySnapshotChunk{Result: ResultAccept}
+}
+
+//newTestProxy serves app at appAddr, and connects an ABCIAppProxy to it. The
+//returned function closes both.
+func newTestProxy(appAddr, bindAddr string, app Application, t *testing.T) (*ABCIAppProxy, func()) {
+	logger := common.NewTestLogger(t)
+
+	server, err := NewServer(appAddr, app, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appProxy, err := NewABCIAppProxy(appAddr, bindAddr, time.Second, logger)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return appProxy, func() {
+		appProxy.Close()
+		server.Close()
+	}
+}
+
+func TestABCIAppProxy(t *testing.T) {
+	appA := newKVApp()
+	proxyA, closeA := newTestProxy("127.0.0.1:9960", "127.0.0.1:9961", appA, t)
+	defer closeA()
+
+	//The App submits transactions like with the SocketAppProxy
+	go func() {
+		st := <-proxyA.SubmitCh()
+		if string(st.Tx) != "a=1" {
+			t.Errorf("tx mismatch: %s", st.Tx)
+		}
+		st.Respond(nil)
+	}()
+	submitter := client.NewClient("127.0.0.1:9961", time.Second, common.NewTestLogger(t))
+	defer submitter.Close()
+	var ack bool
+	if err := submitter.Call("Babble.SubmitTx", []byte("a=1"), &ack); err != nil || !ack {
+		t.Fatalf("SubmitTx should succeed: %v", err)
+	}
+
+	if err := proxyA.CheckTx([]byte("a=1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := proxyA.CheckTx([]byte("bad")); err == nil || err.Error() != "invalid tx" {
+		t.Fatalf("CheckTx should reject the transaction, not return %v", err)
+	}
+
+	block := hashgraph.NewBlock(0, 1, []byte{}, [][]byte{[]byte("a=1"), []byte("bad"), []byte("b=2")})
+
+	resp, err := proxyA.CommitBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.StateHash, appA.appHash) || appA.height != 1 {
+		t.Fatalf("StateHash should be the App hash %X, not %X", appA.appHash, resp.StateHash)
+	}
+	expectedResults := []hashgraph.TxResult{
+		hashgraph.TxResult{Code: 0, Events: []hashgraph.TxEvent{hashgraph.TxEvent{Type: "set", Attributes: map[string]string{"key": "a"}}}},
+		hashgraph.TxResult{Code: 1, Log: "invalid tx"},
+		hashgraph.TxResult{Code: 0, Events: []hashgraph.TxEvent{hashgraph.TxEvent{Type: "set", Attributes: map[string]string{"key": "b"}}}},
+	}
+	if !reflect.DeepEqual(resp.Results, expectedResults) {
+		t.Fatalf("Results should be %+v, not %+v", expectedResults, resp.Results)
+	}
+
+	//Restore the snapshot into another App, chunk by chunk
+	appB := newKVApp()
+	proxyB, closeB := newTestProxy("127.0.0.1:9962", "127.0.0.1:9963", appB, t)
+	defer closeB()
+
+	manifest, err := proxyA.GetSnapshotManifest(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 2 {
+		t.Fatalf("manifest should have 2 chunks, not %d", len(manifest.Chunks))
+	}
+	for i := range manifest.Chunks {
+		data, err := proxyA.GetSnapshotChunk(0, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.CheckChunk(i, data); err != nil {
+			t.Fatal(err)
+		}
+		if err := proxyB.RestoreChunk(manifest, i, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stateHash, err := proxyB.RestoreChunks(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateHash, resp.StateHash) || !reflect.DeepEqual(appB.state, appA.state) {
+		t.Fatalf("App B should have restored the state of App A")
+	}
+
+	//Restore the snapshot into a third App, in one piece
+	appC := newKVApp()
+	proxyC, closeC := newTestProxy("127.0.0.1:9964", "127.0.0.1:9965", appC, t)
+	defer closeC()
+
+	snapshot, err := proxyA.GetSnapshot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateHash, err = proxyC.Restore(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateHash, resp.StateHash) || !reflect.DeepEqual(appC.state, appA.state) {
+		t.Fatalf("App C should have restored the state of App A")
+	}
+
+	//The next Block builds on the restored state
+	resp, err = proxyB.CommitBlock(hashgraph.NewBlock(1, 2, []byte{}, [][]byte{[]byte("c=3")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appB.height != 2 || appB.state["c"] != "3" {
+		t.Fatalf("App B should be at height 2 with c=3")
+	}
+}
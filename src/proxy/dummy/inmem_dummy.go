@@ -30,8 +30,8 @@ func NewInmemDummyClient(logger *logrus.Logger) *InmemDummyClient {
 }
 
 //SubmitTx sends a transaction to the Babble node via the InmemProxy
-func (c *InmemDummyClient) SubmitTx(tx []byte) {
-	c.InmemProxy.SubmitTx(tx)
+func (c *InmemDummyClient) SubmitTx(tx []byte) error {
+	return c.InmemProxy.SubmitTx(tx)
 }
 
 //GetCommittedTransactions returns the state's list of transactions
@@ -22,16 +22,19 @@ func TestInmemDummyAppSide(t *testing.T) {
 		select {
 		case st := <-dummy.SubmitCh():
 			// Verify the command
-			if !reflect.DeepEqual(st, tx) {
-				t.Fatalf("tx mismatch: %#v %#v", tx, st)
+			if !reflect.DeepEqual(st.Tx, tx) {
+				t.Fatalf("tx mismatch: %#v %#v", tx, st.Tx)
 			}
+			st.Respond(nil)
 
 		case <-time.After(200 * time.Millisecond):
 			t.Fatalf("timeout")
 		}
 	}()
 
-	dummy.SubmitTx(tx)
+	if err := dummy.SubmitTx(tx); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestInmemDummyServerSide(t *testing.T) {
@@ -47,7 +50,8 @@ func TestInmemDummyServerSide(t *testing.T) {
 	}
 
 	//commit first block and check that the client's statehash is correct
-	stateHash, err := dummy.CommitBlock(blocks[0])
+	resp, err := dummy.CommitBlock(blocks[0])
+	stateHash := resp.StateHash
 
 	if err != nil {
 		t.Fatal(err)
@@ -84,12 +88,16 @@ func TestInmemDummyServerSide(t *testing.T) {
 		}
 	}
 
-	err = dummy.Restore(snapshot)
+	stateHash, err = dummy.Restore(snapshot)
 
 	if err != nil {
 		t.Fatalf("Error restoring snapshot: %v", err)
 	}
 
+	if !reflect.DeepEqual(stateHash, expectedStateHash) {
+		t.Fatalf("Restore should return StateHash %v, not %v", expectedStateHash, stateHash)
+	}
+
 	if !reflect.DeepEqual(dummy.state.stateHash, expectedStateHash) {
 		t.Fatalf("Restore StateHash should be %v, not %v", expectedStateHash, dummy.state.stateHash)
 	}
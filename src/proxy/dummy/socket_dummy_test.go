@@ -31,9 +31,10 @@ func TestSocketProxyServer(t *testing.T) {
 		select {
 		case st := <-submitCh:
 			// Verify the command
-			if !reflect.DeepEqual(st, tx) {
-				t.Fatalf("tx mismatch: %#v %#v", tx, st)
+			if !reflect.DeepEqual(st.Tx, tx) {
+				t.Fatalf("tx mismatch: %#v %#v", tx, st.Tx)
 			}
+			st.Respond(nil)
 		case <-time.After(200 * time.Millisecond):
 			t.Fatalf("timeout")
 		}
@@ -82,7 +83,8 @@ func TestSocketProxyClient(t *testing.T) {
 	}
 
 	//commit first block and check that the client's statehash is correct
-	stateHash, err := proxy.CommitBlock(blocks[0])
+	resp, err := proxy.CommitBlock(blocks[0])
+	stateHash := resp.StateHash
 
 	if err != nil {
 		t.Fatal(err)
@@ -100,6 +102,11 @@ func TestSocketProxyClient(t *testing.T) {
 		t.Fatalf("StateHash should be %v, not %v", expectedStateHash, stateHash)
 	}
 
+	//transaction results travel over the socket
+	if len(resp.Results) != 1 || !resp.Results[0].OK() {
+		t.Fatalf("Results should contain one OK result, not %v", resp.Results)
+	}
+
 	snapshot, err := proxy.GetSnapshot(blocks[0].Index())
 
 	if err != nil {
@@ -119,10 +126,14 @@ func TestSocketProxyClient(t *testing.T) {
 		}
 	}
 
-	err = proxy.Restore(snapshot)
+	stateHash, err = proxy.Restore(snapshot)
 
 	if err != nil {
 		t.Fatalf("Error restoring snapshot: %v", err)
 	}
 
+	if !reflect.DeepEqual(stateHash, expectedStateHash) {
+		t.Fatalf("Restore should return StateHash %v, not %v", expectedStateHash, stateHash)
+	}
+
 }
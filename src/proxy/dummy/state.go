@@ -5,6 +5,7 @@ import (
 
 	"github.com/mosaicnetworks/babble/src/crypto"
 	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
 	"github.com/sirupsen/logrus"
 )
 
@@ -37,6 +38,15 @@ func NewState(logger *logrus.Logger) *State {
 	return state
 }
 
+//CheckTxHandler rejects empty transactions, which carry nothing to commit
+func (a *State) CheckTxHandler(tx []byte) error {
+	if len(tx) == 0 {
+		return fmt.Errorf("Empty transaction")
+	}
+
+	return nil
+}
+
 func (a *State) CommitHandler(block hashgraph.Block) ([]byte, error) {
 	a.logger.WithField("block", block).Debug("CommitBlock")
 
@@ -49,6 +59,24 @@ func (a *State) CommitHandler(block hashgraph.Block) ([]byte, error) {
 	return a.stateHash, nil
 }
 
+//CommitResultsHandler reports every transaction as applied, because the dummy
+//state has no way of failing one
+func (a *State) CommitResultsHandler(block hashgraph.Block) (proxy.CommitResponse, error) {
+	stateHash, err := a.CommitHandler(block)
+
+	if err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	results := make([]hashgraph.TxResult, len(block.Transactions()))
+
+	for i := range results {
+		results[i] = hashgraph.TxResult{Code: hashgraph.TxCodeOK}
+	}
+
+	return proxy.CommitResponse{StateHash: stateHash, Results: results}, nil
+}
+
 func (a *State) SnapshotHandler(blockIndex int) ([]byte, error) {
 	a.logger.WithField("block", blockIndex).Debug("GetSnapshot")
 
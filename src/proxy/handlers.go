@@ -1,6 +1,10 @@
 package proxy
 
-import "github.com/mosaicnetworks/babble/src/hashgraph"
+import (
+	"io"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+)
 
 /*
 These types are exported and need to be implemented and used by the calling
@@ -21,3 +25,86 @@ type ProxyHandler interface {
 	//state
 	RestoreHandler(snapshot []byte) (stateHash []byte, err error)
 }
+
+//ChunkedProxyHandler may be implemented by applications whose snapshots are too
+//big to be held in a single []byte. Snapshots are then transferred chunk by
+//chunk, possibly from different peers. Applications that only implement
+//ProxyHandler have their snapshots split by Babble (cf NewChunkedHandler).
+type ChunkedProxyHandler interface {
+	ProxyHandler
+
+	//SnapshotManifestHandler is called by Babble to retrieve the manifest of the
+	//snapshot corresponding to a particular block
+	SnapshotManifestHandler(blockIndex int) (manifest SnapshotManifest, err error)
+
+	//SnapshotChunkHandler is called by Babble to retrieve one chunk of the
+	//snapshot corresponding to a particular block
+	SnapshotChunkHandler(blockIndex int, chunk int) (data []byte, err error)
+
+	//RestoreChunkHandler is called by Babble with every chunk of a snapshot, in
+	//order, once the chunk has been verified against the manifest. Chunk 0 marks
+	//the beginning of a new restore. After an interruption, chunks are delivered
+	//again from the first one that was not accepted.
+	RestoreChunkHandler(manifest SnapshotManifest, chunk int, data []byte) error
+
+	//RestoreChunksHandler is called by Babble once all the chunks of a snapshot
+	//have been delivered, to restore the application to the corresponding state
+	RestoreChunksHandler(manifest SnapshotManifest) (stateHash []byte, err error)
+}
+
+//StreamingProxyHandler may be implemented by applications that only implement
+//ProxyHandler, but can restore a snapshot as it is read. The chunks of the
+//snapshot are then passed on as they arrive, instead of being reassembled in
+//memory first (cf NewChunkedHandler).
+type StreamingProxyHandler interface {
+	ProxyHandler
+
+	//RestoreStreamHandler is called by Babble, instead of RestoreHandler, to
+	//restore the application from a snapshot that is read from r. r returns
+	//an error if the transfer of the snapshot is interrupted.
+	RestoreStreamHandler(r io.Reader) (stateHash []byte, err error)
+}
+
+//TxResultsProxyHandler may be implemented by applications that report the
+//result of every transaction in a Block: a code, a log, and the events it
+//emitted. Babble stores them as Receipts alongside the Block, so that clients
+//can tell whether their transaction was applied, not only ordered.
+type TxResultsProxyHandler interface {
+	ProxyHandler
+
+	//CommitResultsHandler is called instead of CommitHandler. It returns the
+	//state hash and one result per transaction, in the order of the Block.
+	CommitResultsHandler(block hashgraph.Block) (CommitResponse, error)
+}
+
+//Commit calls the CommitResultsHandler of handler if it implements
+//TxResultsProxyHandler, and its CommitHandler otherwise
+func Commit(handler ProxyHandler, block hashgraph.Block) (CommitResponse, error) {
+	if h, ok := handler.(TxResultsProxyHandler); ok {
+		return h.CommitResultsHandler(block)
+	}
+	stateHash, err := handler.CommitHandler(block)
+	return CommitResponse{StateHash: stateHash}, err
+}
+
+//TxCheckingProxyHandler may be implemented by applications that validate
+//transactions before Babble admits them to its transaction pool, like ABCI's
+//CheckTx. Rejected transactions never make it into Events, and the error is
+//returned to the submitter. CheckTxHandler is called while the submitter waits,
+//so it should be cheap and must not submit transactions itself.
+type TxCheckingProxyHandler interface {
+	ProxyHandler
+
+	//CheckTxHandler returns nil if the transaction is valid, or the reason why
+	//it is not
+	CheckTxHandler(tx []byte) error
+}
+
+//CheckTx calls the CheckTxHandler of handler, if it implements
+//TxCheckingProxyHandler. Otherwise, all transactions are valid.
+func CheckTx(handler ProxyHandler, tx []byte) error {
+	if checker, ok := handler.(TxCheckingProxyHandler); ok {
+		return checker.CheckTxHandler(tx)
+	}
+	return nil
+}
@@ -8,8 +8,8 @@ import (
 
 //InmemProxy implements the AppProxy interface natively
 type InmemProxy struct {
-	handler  proxy.ProxyHandler
-	submitCh chan []byte
+	handler  proxy.ChunkedProxyHandler
+	submitCh chan proxy.SubmittedTx
 	logger   *logrus.Logger
 }
 
@@ -25,8 +25,8 @@ func NewInmemProxy(handler proxy.ProxyHandler,
 	}
 
 	return &InmemProxy{
-		handler:  handler,
-		submitCh: make(chan []byte),
+		handler:  proxy.NewChunkedHandler(handler, proxy.DefaultChunkSize),
+		submitCh: make(chan proxy.SubmittedTx),
 		logger:   logger,
 	}
 }
@@ -35,38 +35,53 @@ func NewInmemProxy(handler proxy.ProxyHandler,
 * SubmitTx                                                                     *
 *******************************************************************************/
 
-//SubmitTx is called by the App to submit a transaction to Babble
-func (p *InmemProxy) SubmitTx(tx []byte) {
+//SubmitTx is called by the App to submit a transaction to Babble. It returns
+//the reason why the transaction was rejected, if it was.
+func (p *InmemProxy) SubmitTx(tx []byte) error {
 	//have to make a copy, or the tx will be garbage collected and weird stuff
 	//happens in transaction pool
 	t := make([]byte, len(tx), len(tx))
 
 	copy(t, tx)
 
-	p.submitCh <- t
+	return proxy.Submit(p.submitCh, t)
 }
 
 /*******************************************************************************
 * Implement AppProxy Interface                                                 *
 *******************************************************************************/
 
-//SubmitCh returns the channel of raw transactions
-func (p *InmemProxy) SubmitCh() chan []byte {
+//SubmitCh returns the channel of submitted transactions
+func (p *InmemProxy) SubmitCh() chan proxy.SubmittedTx {
 	return p.submitCh
 }
 
-//CommitBlock calls the commitHandler
-func (p *InmemProxy) CommitBlock(block hg.Block) ([]byte, error) {
-	stateHash, err := p.handler.CommitHandler(block)
+//CheckTx calls the checkTxHandler, if there is one
+func (p *InmemProxy) CheckTx(tx []byte) error {
+	err := proxy.CheckTx(p.handler, tx)
+
+	p.logger.WithFields(logrus.Fields{
+		"size": len(tx),
+		"err":  err,
+	}).Debug("InmemProxy.CheckTx")
+
+	return err
+}
+
+//CommitBlock calls the commitHandler, or the commitResultsHandler if there is
+//one
+func (p *InmemProxy) CommitBlock(block hg.Block) (proxy.CommitResponse, error) {
+	resp, err := proxy.Commit(p.handler, block)
 
 	p.logger.WithFields(logrus.Fields{
 		"round_received": block.RoundReceived(),
 		"txs":            len(block.Transactions()),
-		"state_hash":     stateHash,
+		"state_hash":     resp.StateHash,
+		"results":        len(resp.Results),
 		"err":            err,
 	}).Debug("InmemProxy.CommitBlock")
 
-	return stateHash, err
+	return resp, err
 }
 
 //GetSnapshot calls the snapshotHandler
@@ -83,7 +98,7 @@ func (p *InmemProxy) GetSnapshot(blockIndex int) ([]byte, error) {
 }
 
 //Restore calls the restoreHandler
-func (p *InmemProxy) Restore(snapshot []byte) error {
+func (p *InmemProxy) Restore(snapshot []byte) ([]byte, error) {
 	stateHash, err := p.handler.RestoreHandler(snapshot)
 
 	p.logger.WithFields(logrus.Fields{
@@ -91,5 +106,59 @@ func (p *InmemProxy) Restore(snapshot []byte) error {
 		"err":        err,
 	}).Debug("InmemProxy.Restore")
 
+	return stateHash, err
+}
+
+//GetSnapshotManifest calls the snapshotManifestHandler
+func (p *InmemProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	manifest, err := p.handler.SnapshotManifestHandler(blockIndex)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":  blockIndex,
+		"size":   manifest.Size,
+		"chunks": len(manifest.Chunks),
+		"err":    err,
+	}).Debug("InmemProxy.GetSnapshotManifest")
+
+	return manifest, err
+}
+
+//GetSnapshotChunk calls the snapshotChunkHandler
+func (p *InmemProxy) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	data, err := p.handler.SnapshotChunkHandler(blockIndex, chunk)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": blockIndex,
+		"chunk": chunk,
+		"size":  len(data),
+		"err":   err,
+	}).Debug("InmemProxy.GetSnapshotChunk")
+
+	return data, err
+}
+
+//RestoreChunk calls the restoreChunkHandler
+func (p *InmemProxy) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	err := p.handler.RestoreChunkHandler(manifest, chunk, data)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": manifest.BlockIndex,
+		"chunk": chunk,
+		"err":   err,
+	}).Debug("InmemProxy.RestoreChunk")
+
 	return err
 }
+
+//RestoreChunks calls the restoreChunksHandler
+func (p *InmemProxy) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	stateHash, err := p.handler.RestoreChunksHandler(manifest)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      manifest.BlockIndex,
+		"state_hash": stateHash,
+		"err":        err,
+	}).Debug("InmemProxy.RestoreChunks")
+
+	return stateHash, err
+}
@@ -61,16 +61,19 @@ func TestInmemProxyAppSide(t *testing.T) {
 		select {
 		case st := <-submitCh:
 			// Verify the command
-			if !reflect.DeepEqual(st, tx) {
-				t.Fatalf("tx mismatch: %#v %#v", tx, st)
+			if !reflect.DeepEqual(st.Tx, tx) {
+				t.Fatalf("tx mismatch: %#v %#v", tx, st.Tx)
 			}
+			st.Respond(nil)
 
 		case <-time.After(200 * time.Millisecond):
 			t.Fatalf("timeout")
 		}
 	}()
 
-	proxy.SubmitTx(tx)
+	if err := proxy.SubmitTx(tx); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestInmemProxyBabbleSide(t *testing.T) {
@@ -87,7 +90,8 @@ func TestInmemProxyBabbleSide(t *testing.T) {
 	/***************************************************************************
 	Commit
 	***************************************************************************/
-	stateHash, err := proxy.CommitBlock(block)
+	resp, err := proxy.CommitBlock(block)
+	stateHash := resp.StateHash
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -118,7 +122,7 @@ func TestInmemProxyBabbleSide(t *testing.T) {
 	Restore
 	***************************************************************************/
 
-	err = proxy.Restore(snapshot)
+	_, err = proxy.Restore(snapshot)
 	if err != nil {
 		t.Fatalf("Error restoring snapshot: %v", err)
 	}
@@ -0,0 +1,262 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+)
+
+//Version is the only version of the JSON-RPC protocol that is spoken
+const Version = "2.0"
+
+//Error codes defined by the JSON-RPC 2.0 specification. CodeServerError is
+//used for errors returned by the App or by Babble when handling a request.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+//Methods implemented by the App and called by Babble
+const (
+	MethodCommitBlock         = "commitBlock"
+	MethodGetSnapshot         = "getSnapshot"
+	MethodRestore             = "restore"
+	MethodGetSnapshotManifest = "getSnapshotManifest"
+	MethodGetSnapshotChunk    = "getSnapshotChunk"
+	MethodRestoreChunk        = "restoreChunk"
+	MethodRestoreChunks       = "restoreChunks"
+	MethodCheckTx             = "checkTx"
+)
+
+//MethodSubmitTx is implemented by Babble and called by the App
+const MethodSubmitTx = "submitTx"
+
+//Request is a JSON-RPC 2.0 request. Requests without an ID are notifications,
+//which are not answered.
+type Request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+}
+
+//Response is a JSON-RPC 2.0 response. It has either a Result or an Error.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+//Error is a JSON-RPC 2.0 error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+/*******************************************************************************
+Params and results. Their fields are encoded like those of Blocks and
+SnapshotManifests: with their Go names, and []byte as base64 strings.
+*******************************************************************************/
+
+//BlockParams are the params of commitBlock
+type BlockParams struct {
+	Block hashgraph.Block
+}
+
+//BlockIndexParams are the params of getSnapshot and getSnapshotManifest
+type BlockIndexParams struct {
+	BlockIndex int
+}
+
+//SnapshotParams are the params of restore
+type SnapshotParams struct {
+	Snapshot []byte
+}
+
+//ManifestParams are the params of restoreChunks
+type ManifestParams struct {
+	Manifest proxy.SnapshotManifest
+}
+
+//TxParams are the params of submitTx and checkTx
+type TxParams struct {
+	Tx []byte
+}
+
+//StateHashResult is the result of restore and restoreChunks. The result of
+//commitBlock is a proxy.CommitResponse, whose Results are optional.
+type StateHashResult struct {
+	StateHash []byte
+}
+
+//SnapshotResult is the result of getSnapshot
+type SnapshotResult struct {
+	Snapshot []byte
+}
+
+//ChunkResult is the result of getSnapshotChunk
+type ChunkResult struct {
+	Data []byte
+}
+
+/*******************************************************************************
+Addresses
+*******************************************************************************/
+
+//parseAddr splits an address into a network and an address for net.Dial and
+//net.Listen. Addresses are "host:port", "http://host:port", or
+//"unix:///path/to/socket".
+func parseAddr(addr string) (network string, address string) {
+	if strings.HasPrefix(addr, "unix://") {
+		return "unix", strings.TrimPrefix(addr, "unix://")
+	}
+	addr = strings.TrimPrefix(addr, "http://")
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr = addr[:i]
+	}
+	return "tcp", addr
+}
+
+//listen creates a Listener for an address accepted by parseAddr
+func listen(addr string) (net.Listener, error) {
+	return net.Listen(parseAddr(addr))
+}
+
+/*******************************************************************************
+Server side
+*******************************************************************************/
+
+//methodFunc handles the params of a request and returns its result
+type methodFunc func(params json.RawMessage) (interface{}, error)
+
+//decodeParams decodes the params of a request into v
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+//serveHTTP answers a JSON-RPC 2.0 request, or batch of requests, POSTed over
+//HTTP. If there is nothing to answer, because all the requests were
+//notifications, it responds with 204 No Content.
+func serveHTTP(w http.ResponseWriter, r *http.Request, methods map[string]methodFunc) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	var res interface{}
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			res = errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()})
+		} else if len(batch) == 0 {
+			res = errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"})
+		} else {
+			resps := []*Response{}
+			for _, raw := range batch {
+				if resp := handleRequest(raw, methods); resp != nil {
+					resps = append(resps, resp)
+				}
+			}
+			if len(resps) > 0 {
+				res = resps
+			}
+		}
+	} else if resp := handleRequest(body, methods); resp != nil {
+		res = resp
+	}
+
+	if res == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+//handleRequest calls the method of a single request. It returns nil for
+//notifications.
+func handleRequest(raw json.RawMessage, methods map[string]methodFunc) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		code := CodeInvalidRequest
+		if _, ok := err.(*json.SyntaxError); ok {
+			code = CodeParseError
+		}
+		return errorResponse(nil, &Error{Code: code, Message: err.Error()})
+	}
+	if req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+	}
+
+	method, ok := methods[req.Method]
+	if !ok {
+		if req.ID == nil {
+			return nil
+		}
+		return errorResponse(req.ID, &Error{Code: CodeMethodNotFound, Message: req.Method})
+	}
+
+	result, err := method(req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		return errorResponse(req.ID, rpcErr)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &Error{Code: CodeInternalError, Message: err.Error()})
+	}
+	return &Response{
+		JSONRPC: Version,
+		Result:  data,
+		ID:      *req.ID,
+	}
+}
+
+func errorResponse(id *json.RawMessage, err *Error) *Response {
+	resp := &Response{
+		JSONRPC: Version,
+		Error:   err,
+		ID:      json.RawMessage("null"),
+	}
+	if id != nil {
+		resp.ID = *id
+	}
+	return resp
+}
@@ -0,0 +1,208 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+//JSONRPCAppProxy is an AppProxy that speaks JSON-RPC 2.0 over HTTP, or over
+//HTTP on Unix sockets. Babble calls the App's methods at clientAddr, and the
+//App calls submitTx at bindAddr. Unlike the SocketAppProxy, it needs no
+//persistent connection, so Apps can be written with any HTTP library.
+type JSONRPCAppProxy struct {
+	clientAddress string
+	bindAddress   string
+
+	client   *Client
+	listener net.Listener
+	submitCh chan proxy.SubmittedTx
+
+	logger *logrus.Logger
+}
+
+func NewJSONRPCAppProxy(clientAddr string, bindAddr string, timeout time.Duration, logger *logrus.Logger) (*JSONRPCAppProxy, error) {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	l, err := listen(bindAddr)
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to listen")
+		return nil, err
+	}
+
+	appProxy := &JSONRPCAppProxy{
+		clientAddress: clientAddr,
+		bindAddress:   bindAddr,
+		client:        NewClient(clientAddr, timeout),
+		listener:      l,
+		submitCh:      make(chan proxy.SubmittedTx),
+		logger:        logger,
+	}
+
+	go http.Serve(l, appProxy)
+
+	return appProxy, nil
+}
+
+//ServeHTTP answers the App's submitTx requests
+func (p *JSONRPCAppProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveHTTP(w, r, map[string]methodFunc{
+		MethodSubmitTx: p.submitTx,
+	})
+}
+
+func (p *JSONRPCAppProxy) submitTx(params json.RawMessage) (interface{}, error) {
+	var args TxParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	p.logger.Debug("SubmitTx")
+
+	if err := proxy.Submit(p.submitCh, args.Tx); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+//++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+//Implement AppProxy Interface
+
+func (p *JSONRPCAppProxy) SubmitCh() chan proxy.SubmittedTx {
+	return p.submitCh
+}
+
+//CheckTx calls checkTx. Apps that do not implement it accept all transactions.
+func (p *JSONRPCAppProxy) CheckTx(tx []byte) error {
+	var ack bool
+
+	err := p.client.Call(MethodCheckTx, TxParams{Tx: tx}, &ack)
+	if rpcErr, ok := err.(*Error); ok && rpcErr.Code == CodeMethodNotFound {
+		err = nil
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"size": len(tx),
+		"err":  err,
+	}).Debug("JSONRPCAppProxy.CheckTx")
+
+	return err
+}
+
+func (p *JSONRPCAppProxy) CommitBlock(block hashgraph.Block) (proxy.CommitResponse, error) {
+	var res proxy.CommitResponse
+
+	if err := p.client.Call(MethodCommitBlock, BlockParams{Block: block}, &res); err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      block.Index(),
+		"state_hash": res.StateHash,
+		"results":    len(res.Results),
+	}).Debug("JSONRPCAppProxy.CommitBlock")
+
+	return res, nil
+}
+
+func (p *JSONRPCAppProxy) GetSnapshot(blockIndex int) ([]byte, error) {
+	var res SnapshotResult
+
+	if err := p.client.Call(MethodGetSnapshot, BlockIndexParams{BlockIndex: blockIndex}, &res); err != nil {
+		return []byte{}, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block": blockIndex,
+		"size":  len(res.Snapshot),
+	}).Debug("JSONRPCAppProxy.GetSnapshot")
+
+	return res.Snapshot, nil
+}
+
+func (p *JSONRPCAppProxy) Restore(snapshot []byte) ([]byte, error) {
+	var res StateHashResult
+
+	if err := p.client.Call(MethodRestore, SnapshotParams{Snapshot: snapshot}, &res); err != nil {
+		return nil, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"state_hash": res.StateHash,
+	}).Debug("JSONRPCAppProxy.Restore")
+
+	return res.StateHash, nil
+}
+
+func (p *JSONRPCAppProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	var manifest proxy.SnapshotManifest
+
+	if err := p.client.Call(MethodGetSnapshotManifest, BlockIndexParams{BlockIndex: blockIndex}, &manifest); err != nil {
+		return proxy.SnapshotManifest{}, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":  blockIndex,
+		"size":   manifest.Size,
+		"chunks": len(manifest.Chunks),
+	}).Debug("JSONRPCAppProxy.GetSnapshotManifest")
+
+	return manifest, nil
+}
+
+func (p *JSONRPCAppProxy) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	var res ChunkResult
+
+	args := proxy.ChunkRequest{BlockIndex: blockIndex, Chunk: chunk}
+	if err := p.client.Call(MethodGetSnapshotChunk, args, &res); err != nil {
+		return nil, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block": blockIndex,
+		"chunk": chunk,
+		"size":  len(res.Data),
+	}).Debug("JSONRPCAppProxy.GetSnapshotChunk")
+
+	return res.Data, nil
+}
+
+func (p *JSONRPCAppProxy) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	var ack bool
+
+	args := proxy.RestoreChunkRequest{Manifest: manifest, Chunk: chunk, Data: data}
+	if err := p.client.Call(MethodRestoreChunk, args, &ack); err != nil {
+		return err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block": manifest.BlockIndex,
+		"chunk": chunk,
+	}).Debug("JSONRPCAppProxy.RestoreChunk")
+
+	return nil
+}
+
+func (p *JSONRPCAppProxy) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	var res StateHashResult
+
+	if err := p.client.Call(MethodRestoreChunks, ManifestParams{Manifest: manifest}, &res); err != nil {
+		return nil, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      manifest.BlockIndex,
+		"state_hash": res.StateHash,
+	}).Debug("JSONRPCAppProxy.RestoreChunks")
+
+	return res.StateHash, nil
+}
@@ -0,0 +1,209 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+//JSONRPCBabbleProxy is the App side of the JSON-RPC 2.0 interface, for Apps
+//written in Go. It serves the methods called by Babble with a ProxyHandler, and
+//submits transactions to Babble. Apps in other languages implement the same
+//methods with their own HTTP server.
+type JSONRPCBabbleProxy struct {
+	nodeAddress string
+	bindAddress string
+
+	handler proxy.ChunkedProxyHandler
+	client  *Client
+
+	logger *logrus.Logger
+}
+
+func NewJSONRPCBabbleProxy(nodeAddr string,
+	bindAddr string,
+	handler proxy.ProxyHandler,
+	timeout time.Duration,
+	logger *logrus.Logger) (*JSONRPCBabbleProxy, error) {
+
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	l, err := listen(bindAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	babbleProxy := &JSONRPCBabbleProxy{
+		nodeAddress: nodeAddr,
+		bindAddress: bindAddr,
+		handler:     proxy.NewChunkedHandler(handler, proxy.DefaultChunkSize),
+		client:      NewClient(nodeAddr, timeout),
+		logger:      logger,
+	}
+
+	go http.Serve(l, babbleProxy)
+
+	return babbleProxy, nil
+}
+
+//SubmitTx sends a transaction to Babble. It returns the reason why the
+//transaction was rejected, if it was.
+func (p *JSONRPCBabbleProxy) SubmitTx(tx []byte) error {
+	var ack bool
+	return p.client.Call(MethodSubmitTx, TxParams{Tx: tx}, &ack)
+}
+
+//ServeHTTP answers the requests made by Babble
+func (p *JSONRPCBabbleProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveHTTP(w, r, map[string]methodFunc{
+		MethodCommitBlock:         p.commitBlock,
+		MethodGetSnapshot:         p.getSnapshot,
+		MethodRestore:             p.restore,
+		MethodGetSnapshotManifest: p.getSnapshotManifest,
+		MethodGetSnapshotChunk:    p.getSnapshotChunk,
+		MethodRestoreChunk:        p.restoreChunk,
+		MethodRestoreChunks:       p.restoreChunks,
+		MethodCheckTx:             p.checkTx,
+	})
+}
+
+func (p *JSONRPCBabbleProxy) checkTx(params json.RawMessage) (interface{}, error) {
+	var args TxParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	err := proxy.CheckTx(p.handler, args.Tx)
+
+	p.logger.WithFields(logrus.Fields{
+		"size": len(args.Tx),
+		"err":  err,
+	}).Debug("JSONRPCBabbleProxy.CheckTx")
+
+	return err == nil, err
+}
+
+func (p *JSONRPCBabbleProxy) commitBlock(params json.RawMessage) (interface{}, error) {
+	var args BlockParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	resp, err := proxy.Commit(p.handler, args.Block)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      args.Block.Index(),
+		"state_hash": resp.StateHash,
+		"results":    len(resp.Results),
+		"err":        err,
+	}).Debug("JSONRPCBabbleProxy.CommitBlock")
+
+	return resp, err
+}
+
+func (p *JSONRPCBabbleProxy) getSnapshot(params json.RawMessage) (interface{}, error) {
+	var args BlockIndexParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := p.handler.SnapshotHandler(args.BlockIndex)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": args.BlockIndex,
+		"size":  len(snapshot),
+		"err":   err,
+	}).Debug("JSONRPCBabbleProxy.GetSnapshot")
+
+	return SnapshotResult{Snapshot: snapshot}, err
+}
+
+func (p *JSONRPCBabbleProxy) restore(params json.RawMessage) (interface{}, error) {
+	var args SnapshotParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	stateHash, err := p.handler.RestoreHandler(args.Snapshot)
+
+	p.logger.WithFields(logrus.Fields{
+		"state_hash": stateHash,
+		"err":        err,
+	}).Debug("JSONRPCBabbleProxy.Restore")
+
+	return StateHashResult{StateHash: stateHash}, err
+}
+
+func (p *JSONRPCBabbleProxy) getSnapshotManifest(params json.RawMessage) (interface{}, error) {
+	var args BlockIndexParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	manifest, err := p.handler.SnapshotManifestHandler(args.BlockIndex)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":  args.BlockIndex,
+		"chunks": len(manifest.Chunks),
+		"err":    err,
+	}).Debug("JSONRPCBabbleProxy.GetSnapshotManifest")
+
+	return manifest, err
+}
+
+func (p *JSONRPCBabbleProxy) getSnapshotChunk(params json.RawMessage) (interface{}, error) {
+	var args proxy.ChunkRequest
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	data, err := p.handler.SnapshotChunkHandler(args.BlockIndex, args.Chunk)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": args.BlockIndex,
+		"chunk": args.Chunk,
+		"err":   err,
+	}).Debug("JSONRPCBabbleProxy.GetSnapshotChunk")
+
+	return ChunkResult{Data: data}, err
+}
+
+func (p *JSONRPCBabbleProxy) restoreChunk(params json.RawMessage) (interface{}, error) {
+	var args proxy.RestoreChunkRequest
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	err := p.handler.RestoreChunkHandler(args.Manifest, args.Chunk, args.Data)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": args.Manifest.BlockIndex,
+		"chunk": args.Chunk,
+		"err":   err,
+	}).Debug("JSONRPCBabbleProxy.RestoreChunk")
+
+	return err == nil, err
+}
+
+func (p *JSONRPCBabbleProxy) restoreChunks(params json.RawMessage) (interface{}, error) {
+	var args ManifestParams
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+
+	stateHash, err := p.handler.RestoreChunksHandler(args.Manifest)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      args.Manifest.BlockIndex,
+		"state_hash": stateHash,
+		"err":        err,
+	}).Debug("JSONRPCBabbleProxy.RestoreChunks")
+
+	return StateHashResult{StateHash: stateHash}, err
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//Client makes JSON-RPC 2.0 calls over HTTP. It is used by Babble to call the
+//App, and can be used by Go Apps to call Babble.
+type Client struct {
+	url    string
+	http   *http.Client
+	nextID uint64
+}
+
+//NewClient creates a Client for an address accepted by the servers:
+//"host:port", "http://host:port/path", or "unix:///path/to/socket"
+func NewClient(addr string, timeout time.Duration) *Client {
+	client := &Client{
+		http: &http.Client{Timeout: timeout},
+	}
+
+	network, address := parseAddr(addr)
+	if network == "unix" {
+		dialer := &net.Dialer{Timeout: timeout}
+		client.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+		}
+		client.url = "http://unix/"
+	} else if strings.HasPrefix(addr, "http://") {
+		client.url = addr
+	} else {
+		client.url = "http://" + address + "/"
+	}
+
+	return client
+}
+
+//Call calls a method and decodes its result into result. Errors returned by
+//the server are of type *Error.
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := json.RawMessage(strconv.FormatUint(atomic.AddUint64(&c.nextID, 1), 10))
+
+	body, err := json.Marshal(Request{
+		JSONRPC: Version,
+		Method:  method,
+		Params:  p,
+		ID:      &id,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpResp, err := c.http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %s", method, httpResp.Status)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return json.Unmarshal(resp.Result, result)
+}
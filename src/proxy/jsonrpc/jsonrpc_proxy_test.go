@@ -0,0 +1,305 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/sirupsen/logrus"
+)
+
+type TestHandler struct {
+	blocks     []hashgraph.Block
+	blockIndex int
+	snapshot   []byte
+	logger     *logrus.Logger
+}
+
+func (p *TestHandler) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	p.logger.Debug("CommitBlock")
+
+	if len(block.Transactions()) == 0 {
+		return nil, fmt.Errorf("empty block")
+	}
+
+	p.blocks = append(p.blocks, block)
+
+	return []byte("statehash"), nil
+}
+
+func (p *TestHandler) CheckTxHandler(tx []byte) error {
+	if string(tx) == "invalid" {
+		return fmt.Errorf("invalid transaction")
+	}
+	return nil
+}
+
+func (p *TestHandler) SnapshotHandler(blockIndex int) ([]byte, error) {
+	p.logger.Debug("GetSnapshot")
+
+	p.blockIndex = blockIndex
+
+	return []byte("snapshot"), nil
+}
+
+func (p *TestHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	p.logger.Debug("RestoreSnapshot")
+
+	p.snapshot = snapshot
+
+	return []byte("statehash"), nil
+}
+
+func NewTestHandler(t *testing.T) *TestHandler {
+	logger := common.NewTestLogger(t)
+
+	return &TestHandler{
+		blocks:     []hashgraph.Block{},
+		blockIndex: 0,
+		snapshot:   []byte{},
+		logger:     logger,
+	}
+}
+
+func TestJSONRPCProxySubmitTx(t *testing.T) {
+	clientAddr := "127.0.0.1:9980"
+	proxyAddr := "127.0.0.1:9981"
+
+	appProxy, err := NewJSONRPCAppProxy(clientAddr, proxyAddr, 1*time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatalf("Cannot create JSONRPCAppProxy: %s", err)
+	}
+
+	submitCh := appProxy.SubmitCh()
+
+	tx := []byte("the test transaction")
+
+	// Listen for a request
+	go func() {
+		select {
+		case st := <-submitCh:
+			// Verify the command
+			if !reflect.DeepEqual(st.Tx, tx) {
+				t.Fatalf("tx mismatch: %#v %#v", tx, st.Tx)
+			}
+			st.Respond(nil)
+
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("timeout")
+		}
+	}()
+
+	babbleProxy, err := NewJSONRPCBabbleProxy(proxyAddr, clientAddr, NewTestHandler(t), 1*time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := babbleProxy.SubmitTx(tx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONRPCProxyClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "babble-jsonrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//Babble calls the App over a Unix socket
+	clientAddr := "unix://" + filepath.Join(dir, "app.sock")
+	proxyAddr := "127.0.0.1:9983"
+
+	logger := common.NewTestLogger(t)
+
+	appProxy, err := NewJSONRPCAppProxy(clientAddr, proxyAddr, 1*time.Second, logger)
+	if err != nil {
+		t.Fatalf("Cannot create JSONRPCAppProxy: %s", err)
+	}
+
+	handler := NewTestHandler(t)
+
+	_, err = NewJSONRPCBabbleProxy(proxyAddr, clientAddr, handler, 1*time.Second, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := [][]byte{
+		[]byte("tx 1"),
+		[]byte("tx 2"),
+		[]byte("tx 3"),
+	}
+
+	block := hashgraph.NewBlock(0, 1, []byte{}, transactions)
+	expectedStateHash := []byte("statehash")
+	expectedSnapshot := []byte("snapshot")
+
+	if err := appProxy.CheckTx([]byte("tx 1")); err != nil {
+		t.Fatal(err)
+	}
+
+	err = appProxy.CheckTx([]byte("invalid"))
+	if rpcErr, ok := err.(*Error); !ok || rpcErr.Message != "invalid transaction" {
+		t.Fatalf("CheckTx should reject the transaction, not return %v", err)
+	}
+
+	resp, err := appProxy.CommitBlock(block)
+	stateHash := resp.StateHash
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(block, handler.blocks[0]) {
+		t.Fatalf("block should be %v, not %v", block, handler.blocks[0])
+	}
+
+	if !reflect.DeepEqual(stateHash, expectedStateHash) {
+		t.Fatalf("StateHash should be %v, not %v", expectedStateHash, stateHash)
+	}
+
+	//Errors returned by the App are JSON-RPC errors
+	_, err = appProxy.CommitBlock(hashgraph.NewBlock(1, 2, []byte{}, [][]byte{}))
+	if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != CodeServerError || rpcErr.Message != "empty block" {
+		t.Fatalf("CommitBlock should return a server error, not %v", err)
+	}
+
+	snapshot, err := appProxy.GetSnapshot(block.Index())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(snapshot, expectedSnapshot) {
+		t.Fatalf("Snapshot should be %v, not %v", expectedSnapshot, snapshot)
+	}
+
+	_, err = appProxy.Restore(snapshot)
+	if err != nil {
+		t.Fatalf("Error restoring snapshot: %v", err)
+	}
+
+	if !reflect.DeepEqual(expectedSnapshot, handler.snapshot) {
+		t.Fatalf("snapshot should be %v, not %v", expectedSnapshot, handler.snapshot)
+	}
+
+	//chunked snapshots
+	handler.snapshot = []byte{}
+
+	manifest, err := appProxy.GetSnapshotManifest(block.Index())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunk, err := appProxy.GetSnapshotChunk(block.Index(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manifest.CheckChunk(0, chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appProxy.RestoreChunk(manifest, 0, chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	stateHash, err = appProxy.RestoreChunks(manifest)
+	if err != nil {
+		t.Fatalf("Error restoring chunks: %v", err)
+	}
+
+	if !reflect.DeepEqual(stateHash, expectedStateHash) {
+		t.Fatalf("StateHash should be %v, not %v", expectedStateHash, stateHash)
+	}
+
+	if !reflect.DeepEqual(expectedSnapshot, handler.snapshot) {
+		t.Fatalf("snapshot should be %v, not %v", expectedSnapshot, handler.snapshot)
+	}
+}
+
+func TestJSONRPCServer(t *testing.T) {
+	proxyAddr := "127.0.0.1:9985"
+
+	appProxy, err := NewJSONRPCAppProxy("127.0.0.1:9984", proxyAddr, 1*time.Second, common.NewTestLogger(t))
+	if err != nil {
+		t.Fatalf("Cannot create JSONRPCAppProxy: %s", err)
+	}
+	go func() {
+		for st := range appProxy.SubmitCh() {
+			st.Respond(nil)
+		}
+	}()
+
+	post := func(body string) (int, string) {
+		resp, err := http.Post("http://"+proxyAddr, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(bytes.TrimSpace(data))
+	}
+
+	errorCode := func(data string) int {
+		var resp Response
+		if err := json.Unmarshal([]byte(data), &resp); err != nil || resp.Error == nil {
+			t.Fatalf("%s should be an error response", data)
+		}
+		return resp.Error.Code
+	}
+
+	testCases := []struct {
+		body string
+		code int
+	}{
+		{`{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":"dHg="},"id":"a"`, CodeParseError},
+		{`{"method":"submitTx","params":{"Tx":"dHg="},"id":1}`, CodeInvalidRequest},
+		{`{"jsonrpc":"2.0","method":"commitBlock","params":{},"id":1}`, CodeMethodNotFound},
+		{`{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":1},"id":1}`, CodeInvalidParams},
+		{`[]`, CodeInvalidRequest},
+	}
+	for _, tc := range testCases {
+		_, data := post(tc.body)
+		if code := errorCode(data); code != tc.code {
+			t.Fatalf("%s should fail with code %d, not %d", tc.body, tc.code, code)
+		}
+	}
+
+	//The id is echoed back
+	_, data := post(`{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":"dHg="},"id":"a"}`)
+	if data != `{"jsonrpc":"2.0","result":true,"id":"a"}` {
+		t.Fatalf("unexpected response %s", data)
+	}
+
+	//Notifications are not answered
+	status, _ := post(`{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":"dHg="}}`)
+	if status != http.StatusNoContent {
+		t.Fatalf("a notification should not be answered, got HTTP %d", status)
+	}
+
+	//Batches are answered with one response per request that is not a
+	//notification
+	_, data = post(`[
+		{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":"dHg="},"id":1},
+		{"jsonrpc":"2.0","method":"submitTx","params":{"Tx":"dHg="}},
+		{"jsonrpc":"2.0","method":"unknown","id":2}
+	]`)
+	var batch []Response
+	if err := json.Unmarshal([]byte(data), &batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || batch[0].Error != nil || batch[1].Error.Code != CodeMethodNotFound {
+		t.Fatalf("unexpected batch response %s", data)
+	}
+}
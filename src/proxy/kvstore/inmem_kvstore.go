@@ -0,0 +1,105 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/mosaicnetworks/babble/src/proxy/inmem"
+	"github.com/sirupsen/logrus"
+)
+
+//QueryPath is the URL path under which InmemKVStore serves the store over HTTP
+const QueryPath = "/kv/"
+
+//InmemKVStore runs the kvstore App in the same process as Babble. It
+//implements the AppProxy interface, and can be passed in the Babble constructor
+//directly.
+type InmemKVStore struct {
+	*inmem.InmemProxy
+	state  *State
+	logger *logrus.Logger
+}
+
+//NewInmemKVStore instantiates an InmemKVStore with an empty store
+func NewInmemKVStore(logger *logrus.Logger) *InmemKVStore {
+	state := NewState(logger)
+
+	return &InmemKVStore{
+		InmemProxy: inmem.NewInmemProxy(state, logger),
+		state:      state,
+		logger:     logger,
+	}
+}
+
+//State returns the State of the App
+func (c *InmemKVStore) State() *State {
+	return c.state
+}
+
+//Set submits a transaction which sets key to value
+func (c *InmemKVStore) Set(key, value string) error {
+	return c.SubmitTx(NewSetTx(key, value))
+}
+
+//Delete submits a transaction which deletes key
+func (c *InmemKVStore) Delete(key string) error {
+	return c.SubmitTx(NewDeleteTx(key))
+}
+
+//Get returns the committed value of key
+func (c *InmemKVStore) Get(key string) (string, bool) {
+	return c.state.Get(key)
+}
+
+//ServeHTTP exposes the store under QueryPath:
+//
+//	GET    /kv/        all the entries
+//	GET    /kv/{key}   the value of key
+//	PUT    /kv/{key}   submit a transaction which sets key to the request body
+//	DELETE /kv/{key}   submit a transaction which deletes key
+//
+//Writes return as soon as Babble accepts the transaction; the new value is
+//visible once the transaction is committed.
+func (c *InmemKVStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, QueryPath)
+
+	switch r.Method {
+	case http.MethodGet:
+		if key == "" {
+			writeJSON(w, c.state.Entries())
+			return
+		}
+		value, ok := c.state.Get(key)
+		if !ok {
+			http.Error(w, "Key not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, value)
+	case http.MethodPut, http.MethodPost:
+		value, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := c.Set(key, string(value)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodDelete:
+		if err := c.Delete(key); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
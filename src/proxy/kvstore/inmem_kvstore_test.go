@@ -0,0 +1,172 @@
+package kvstore
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+func TestInmemKVStoreCommitAndRestore(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	kv := NewInmemKVStore(logger)
+
+	blocks := []hashgraph.Block{
+		hashgraph.NewBlock(0, 1, []byte{}, [][]byte{
+			NewSetTx("a", "1"),
+			NewSetTx("b", "2"),
+		}),
+		hashgraph.NewBlock(1, 2, []byte{}, [][]byte{
+			NewDeleteTx("a"),
+			NewDeleteTx("c"),
+			[]byte("garbage"),
+			NewSetTx("b", "3"),
+		}),
+	}
+
+	resp0, err := kv.CommitBlock(blocks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp1, err := kv.CommitBlock(blocks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCodes := []uint32{hashgraph.TxCodeOK, CodeUnknownKey, CodeInvalidTx, hashgraph.TxCodeOK}
+	if len(resp1.Results) != len(expectedCodes) {
+		t.Fatalf("Block 1 should have %d results, not %d", len(expectedCodes), len(resp1.Results))
+	}
+	for i, r := range resp1.Results {
+		if r.Code != expectedCodes[i] {
+			t.Fatalf("Result %d should have Code %d, not %d (%s)", i, expectedCodes[i], r.Code, r.Log)
+		}
+	}
+
+	if !reflect.DeepEqual(kv.State().Entries(), map[string]string{"b": "3"}) {
+		t.Fatalf("Unexpected store %v", kv.State().Entries())
+	}
+
+	//restore the state of block 0 in a fresh App
+	snapshot, err := kv.GetSnapshot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewInmemKVStore(logger)
+
+	stateHash, err := other.Restore(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stateHash, resp0.StateHash) {
+		t.Fatalf("Restore should return StateHash %X, not %X", resp0.StateHash, stateHash)
+	}
+
+	if !reflect.DeepEqual(other.State().Entries(), map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("Unexpected restored store %v", other.State().Entries())
+	}
+
+	//the restored snapshot can be served to the next peer
+	served, err := other.GetSnapshot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(served, snapshot) {
+		t.Fatalf("Served snapshot should be %s, not %s", snapshot, served)
+	}
+
+	//replaying block 1 on top of the snapshot converges on the same state
+	resp, err := other.CommitBlock(blocks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resp.StateHash, resp1.StateHash) {
+		t.Fatalf("StateHash should be %X, not %X", resp1.StateHash, resp.StateHash)
+	}
+}
+
+func TestStatePrunesSnapshots(t *testing.T) {
+	state := NewState(common.NewTestLogger(t))
+
+	for i := 0; i <= KeptSnapshots; i++ {
+		block := hashgraph.NewBlock(i, i+1, []byte{}, [][]byte{
+			NewSetTx("a", fmt.Sprint(i)),
+		})
+		if _, err := state.CommitHandler(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := state.SnapshotHandler(0); err == nil {
+		t.Fatalf("Snapshot 0 should have been pruned")
+	}
+
+	for i := 1; i <= KeptSnapshots; i++ {
+		if _, err := state.SnapshotHandler(i); err != nil {
+			t.Fatalf("Snapshot %d should be kept: %v", i, err)
+		}
+	}
+}
+
+func TestInmemKVStoreHTTP(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	kv := NewInmemKVStore(logger)
+
+	_, err := kv.CommitBlock(hashgraph.NewBlock(0, 1, []byte{}, [][]byte{NewSetTx("a", "1")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(kv)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + QueryPath + "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET a should return %d, not %d", http.StatusOK, resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + QueryPath + "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("GET b should return %d, not %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	//PUT submits a transaction to Babble
+	go func() {
+		select {
+		case st := <-kv.SubmitCh():
+			st.Respond(kv.CheckTx(st.Tx))
+		case <-time.After(time.Second):
+		}
+	}()
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+QueryPath+"b", strings.NewReader("2"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("PUT b should return %d, not %d", http.StatusAccepted, resp.StatusCode)
+	}
+}
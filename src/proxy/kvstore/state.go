@@ -0,0 +1,266 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+* The kvstore App is a deterministic key-value store, meant as a template for
+* real Babble applications. Transactions set or delete keys. After every block,
+* the whole store is serialized into a snapshot, along with the index of the
+* block, and the state hash is the hash of that snapshot. Restoring a snapshot
+* rebuilds the store, so that a node which fast-forwards ends up with exactly
+* the same state as its peers, and can serve the snapshot in turn.
+ */
+
+//KeptSnapshots is the number of snapshots of recent blocks that the State
+//keeps. Peers fast-forward to the AnchorBlock, which lags a few blocks behind
+//the last committed one while it collects signatures.
+const KeptSnapshots = 10
+
+const (
+	//OpSet sets Key to Value
+	OpSet = "set"
+	//OpDelete removes Key
+	OpDelete = "delete"
+)
+
+const (
+	//CodeInvalidTx is the result Code of a transaction that cannot be decoded
+	CodeInvalidTx uint32 = 1
+	//CodeUnknownKey is the result Code of a delete on a Key that is not set
+	CodeUnknownKey uint32 = 2
+)
+
+//Tx is a transaction of the kvstore App
+type Tx struct {
+	Op    string
+	Key   string
+	Value string `json:",omitempty"`
+}
+
+//NewSetTx returns an encoded transaction which sets key to value
+func NewSetTx(key, value string) []byte {
+	tx, _ := json.Marshal(Tx{Op: OpSet, Key: key, Value: value})
+	return tx
+}
+
+//NewDeleteTx returns an encoded transaction which deletes key
+func NewDeleteTx(key string) []byte {
+	tx, _ := json.Marshal(Tx{Op: OpDelete, Key: key})
+	return tx
+}
+
+//DecodeTx decodes and validates a transaction
+func DecodeTx(data []byte) (Tx, error) {
+	var tx Tx
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return Tx{}, fmt.Errorf("Invalid transaction: %v", err)
+	}
+	if tx.Key == "" {
+		return Tx{}, fmt.Errorf("Invalid transaction: empty key")
+	}
+	if tx.Op != OpSet && tx.Op != OpDelete {
+		return Tx{}, fmt.Errorf("Invalid transaction: unknown op %q", tx.Op)
+	}
+	return tx, nil
+}
+
+//State is the state of the kvstore App. It implements ProxyHandler,
+//TxCheckingProxyHandler and TxResultsProxyHandler. It is safe to query it while
+//Babble commits blocks.
+type State struct {
+	sync.RWMutex
+	store     map[string]string
+	stateHash []byte
+	snapshots map[int][]byte
+	logger    *logrus.Logger
+}
+
+//NewState creates an empty kvstore State
+func NewState(logger *logrus.Logger) *State {
+	state := &State{
+		store:     make(map[string]string),
+		stateHash: []byte{},
+		snapshots: make(map[int][]byte),
+		logger:    logger,
+	}
+
+	logger.Info("Init KVStore State")
+
+	return state
+}
+
+//Get returns the value of key, and whether it is set
+func (s *State) Get(key string) (string, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	value, ok := s.store[key]
+
+	return value, ok
+}
+
+//Entries returns a copy of the whole store
+func (s *State) Entries() map[string]string {
+	s.RLock()
+	defer s.RUnlock()
+
+	entries := make(map[string]string, len(s.store))
+	for k, v := range s.store {
+		entries[k] = v
+	}
+
+	return entries
+}
+
+//StateHash returns the hash of the last committed or restored snapshot
+func (s *State) StateHash() []byte {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.stateHash
+}
+
+//CheckTxHandler rejects transactions that cannot be decoded
+func (s *State) CheckTxHandler(tx []byte) error {
+	_, err := DecodeTx(tx)
+	return err
+}
+
+//CommitHandler applies the transactions of a block and returns the new state
+//hash
+func (s *State) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	resp, err := s.CommitResultsHandler(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.StateHash, nil
+}
+
+//CommitResultsHandler applies the transactions of a block in order, and reports
+//the result of each one. A transaction that fails does not fail the block; it
+//is skipped deterministically on every node.
+func (s *State) CommitResultsHandler(block hashgraph.Block) (proxy.CommitResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.logger.WithField("block", block.Index()).Debug("CommitBlock")
+
+	results := make([]hashgraph.TxResult, len(block.Transactions()))
+
+	for i, data := range block.Transactions() {
+		results[i] = s.apply(data)
+	}
+
+	snapshot, err := encodeSnapshot(block.Index(), s.store)
+	if err != nil {
+		return proxy.CommitResponse{}, err
+	}
+
+	s.setSnapshot(block.Index(), snapshot)
+	s.stateHash = crypto.SHA256(snapshot)
+
+	return proxy.CommitResponse{StateHash: s.stateHash, Results: results}, nil
+}
+
+//SnapshotHandler returns the serialized store as it was after the given block
+func (s *State) SnapshotHandler(blockIndex int) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	s.logger.WithField("block", blockIndex).Debug("GetSnapshot")
+
+	snapshot, ok := s.snapshots[blockIndex]
+	if !ok {
+		return nil, fmt.Errorf("Snapshot %d not found", blockIndex)
+	}
+
+	return snapshot, nil
+}
+
+//RestoreHandler rebuilds the store from a snapshot and returns the
+//corresponding state hash. The snapshot is kept, so that it can be served to
+//other peers.
+func (s *State) RestoreHandler(snapshot []byte) ([]byte, error) {
+	var decoded storeSnapshot
+	if err := json.Unmarshal(snapshot, &decoded); err != nil {
+		return nil, fmt.Errorf("Invalid snapshot: %v", err)
+	}
+	if decoded.Store == nil {
+		decoded.Store = make(map[string]string)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.store = decoded.Store
+	s.setSnapshot(decoded.Block, snapshot)
+	s.stateHash = crypto.SHA256(snapshot)
+
+	s.logger.WithFields(logrus.Fields{
+		"block": decoded.Block,
+		"keys":  len(decoded.Store),
+	}).Debug("Restore")
+
+	return s.stateHash, nil
+}
+
+func (s *State) apply(data []byte) hashgraph.TxResult {
+	tx, err := DecodeTx(data)
+	if err != nil {
+		return hashgraph.TxResult{Code: CodeInvalidTx, Log: err.Error()}
+	}
+
+	switch tx.Op {
+	case OpSet:
+		s.store[tx.Key] = tx.Value
+	case OpDelete:
+		if _, ok := s.store[tx.Key]; !ok {
+			return hashgraph.TxResult{
+				Code: CodeUnknownKey,
+				Log:  fmt.Sprintf("Key %q is not set", tx.Key),
+			}
+		}
+		delete(s.store, tx.Key)
+	}
+
+	return hashgraph.TxResult{
+		Code: hashgraph.TxCodeOK,
+		Events: []hashgraph.TxEvent{
+			{Type: tx.Op, Attributes: map[string]string{"key": tx.Key}},
+		},
+	}
+}
+
+//setSnapshot records the snapshot of a block, and drops those that are more
+//than KeptSnapshots blocks older
+func (s *State) setSnapshot(blockIndex int, snapshot []byte) {
+	s.snapshots[blockIndex] = snapshot
+
+	for i := range s.snapshots {
+		if i <= blockIndex-KeptSnapshots {
+			delete(s.snapshots, i)
+		}
+	}
+}
+
+//storeSnapshot is the serialized form of the store after a block
+type storeSnapshot struct {
+	Block int
+	Store map[string]string
+}
+
+//encodeSnapshot serializes the store after a block. encoding/json sorts map
+//keys, so equal stores always produce identical snapshots.
+func encodeSnapshot(blockIndex int, store map[string]string) ([]byte, error) {
+	return json.Marshal(storeSnapshot{Block: blockIndex, Store: store})
+}
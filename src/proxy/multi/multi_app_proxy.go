@@ -0,0 +1,284 @@
+package multi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/inmem"
+	"github.com/sirupsen/logrus"
+)
+
+//CodeUnknownNamespace is the result Code of a committed transaction whose
+//namespace does not belong to any application
+const CodeUnknownNamespace uint32 = 1
+
+//MultiAppProxy runs several independent applications on a single Babble node.
+//Every application has its own AppProxy, and its transactions are prefixed
+//with its namespace. Each application only receives its own transactions from
+//committed Blocks, and the StateHash of a Block folds together the state
+//hashes of all the namespaces (cf FoldStateHashes).
+//
+//MultiAppProxy is itself an AppProxy, which is passed to the node in place of
+//a single application.
+type MultiAppProxy struct {
+	*inmem.InmemProxy
+	handler *multiHandler
+}
+
+//NewMultiAppProxy creates a MultiAppProxy from AppProxies indexed by namespace,
+//and starts forwarding the transactions submitted by the applications
+func NewMultiAppProxy(apps map[string]proxy.AppProxy, logger *logrus.Logger) (*MultiAppProxy, error) {
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("No application")
+	}
+
+	namespaces := []string{}
+	for ns := range apps {
+		if ns == "" || len(ns) > MaxNamespaceLength {
+			return nil, fmt.Errorf("Invalid namespace %q", ns)
+		}
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	handler := &multiHandler{
+		apps:        apps,
+		namespaces:  namespaces,
+		stateHashes: make(map[string][]byte),
+		logger:      logger,
+	}
+
+	p := &MultiAppProxy{
+		InmemProxy: inmem.NewInmemProxy(handler, logger),
+		handler:    handler,
+	}
+
+	for _, ns := range namespaces {
+		go p.forward(ns, apps[ns])
+	}
+
+	return p, nil
+}
+
+//StateHashes returns the state hash of every namespace after the last Block
+//that was committed or restored
+func (p *MultiAppProxy) StateHashes() map[string][]byte {
+	return p.handler.getStateHashes()
+}
+
+//forward relays the transactions submitted by an application to Babble, and
+//the responses of Babble back to the application
+func (p *MultiAppProxy) forward(namespace string, app proxy.AppProxy) {
+	for st := range app.SubmitCh() {
+		st.Respond(p.SubmitTx(EncodeTx(namespace, st.Tx)))
+	}
+}
+
+/*******************************************************************************
+multiHandler
+*******************************************************************************/
+
+type multiHandler struct {
+	apps       map[string]proxy.AppProxy
+	namespaces []string //sorted
+
+	sync.Mutex
+	stateHashes map[string][]byte
+	progress    *commitProgress
+
+	logger *logrus.Logger
+}
+
+//commitProgress records the responses of the namespaces that committed a
+//Block, until all of them have
+type commitProgress struct {
+	index     int
+	responses map[string]proxy.CommitResponse
+}
+
+//CheckTxHandler rejects transactions without a known namespace, and lets the
+//application of the namespace check the others
+func (h *multiHandler) CheckTxHandler(data []byte) error {
+	ns, tx, err := DecodeTx(data)
+	if err != nil {
+		return err
+	}
+
+	app, ok := h.apps[ns]
+	if !ok {
+		return fmt.Errorf("Unknown namespace %q", ns)
+	}
+
+	return app.CheckTx(tx)
+}
+
+func (h *multiHandler) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	resp, err := h.CommitResultsHandler(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.StateHash, nil
+}
+
+//CommitResultsHandler splits the Block by namespace and commits every part to
+//its application, even if it is empty, so that every application has a state
+//for every Block. Results are reported in the order of the original Block if
+//at least one application reports them; transactions of applications that do
+//not report results get an empty result.
+//
+//The node retries a Block whose commit failed. The namespaces that already
+//committed it are not given it again; their previous responses are reused.
+func (h *multiHandler) CommitResultsHandler(block hashgraph.Block) (proxy.CommitResponse, error) {
+	txs := make(map[string][][]byte)
+	positions := make(map[string][]int)
+
+	results := make([]hashgraph.TxResult, len(block.Transactions()))
+	reported := false
+
+	for i, data := range block.Transactions() {
+		ns, tx, err := DecodeTx(data)
+		if err == nil {
+			if _, ok := h.apps[ns]; !ok {
+				err = fmt.Errorf("Unknown namespace %q", ns)
+			}
+		}
+		if err != nil {
+			results[i] = hashgraph.TxResult{Code: CodeUnknownNamespace, Log: err.Error()}
+			reported = true
+			continue
+		}
+		txs[ns] = append(txs[ns], tx)
+		positions[ns] = append(positions[ns], i)
+	}
+
+	progress := h.getProgress(block.Index())
+	stateHashes := make(map[string][]byte)
+
+	for _, ns := range h.namespaces {
+		resp, ok := progress.responses[ns]
+		if !ok {
+			nsBlock := hashgraph.NewBlock(block.Index(),
+				block.RoundReceived(),
+				block.FrameHash(),
+				txs[ns])
+
+			var err error
+			resp, err = h.apps[ns].CommitBlock(nsBlock)
+			if err != nil {
+				return proxy.CommitResponse{}, fmt.Errorf("Namespace %q: %v", ns, err)
+			}
+			progress.responses[ns] = resp
+		}
+
+		if len(resp.Results) > 0 {
+			if len(resp.Results) != len(txs[ns]) {
+				return proxy.CommitResponse{}, fmt.Errorf("Namespace %q: %d results for %d transactions",
+					ns, len(resp.Results), len(txs[ns]))
+			}
+			for j, r := range resp.Results {
+				results[positions[ns][j]] = r
+			}
+			reported = true
+		}
+
+		stateHashes[ns] = resp.StateHash
+	}
+
+	h.setStateHashes(stateHashes)
+	h.resetProgress()
+
+	resp := proxy.CommitResponse{StateHash: FoldStateHashes(stateHashes)}
+	if reported {
+		resp.Results = results
+	}
+
+	return resp, nil
+}
+
+//SnapshotHandler bundles the snapshots of all the applications
+func (h *multiHandler) SnapshotHandler(blockIndex int) ([]byte, error) {
+	snapshots := make(map[string][]byte)
+
+	for _, ns := range h.namespaces {
+		snapshot, err := h.apps[ns].GetSnapshot(blockIndex)
+		if err != nil {
+			return nil, fmt.Errorf("Namespace %q: %v", ns, err)
+		}
+		snapshots[ns] = snapshot
+	}
+
+	return json.Marshal(snapshots)
+}
+
+//RestoreHandler restores every application from its part of the bundle, and
+//returns the folded state hash
+func (h *multiHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	snapshots := make(map[string][]byte)
+	if err := json.Unmarshal(snapshot, &snapshots); err != nil {
+		return nil, fmt.Errorf("Invalid snapshot: %v", err)
+	}
+
+	stateHashes := make(map[string][]byte)
+
+	for _, ns := range h.namespaces {
+		nsSnapshot, ok := snapshots[ns]
+		if !ok {
+			return nil, fmt.Errorf("Snapshot has no namespace %q", ns)
+		}
+
+		stateHash, err := h.apps[ns].Restore(nsSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("Namespace %q: %v", ns, err)
+		}
+
+		stateHashes[ns] = stateHash
+	}
+
+	h.setStateHashes(stateHashes)
+	h.resetProgress()
+
+	return FoldStateHashes(stateHashes), nil
+}
+
+func (h *multiHandler) setStateHashes(stateHashes map[string][]byte) {
+	h.Lock()
+	defer h.Unlock()
+	h.stateHashes = stateHashes
+}
+
+//getProgress returns the progress of the commit of Block index, and starts
+//over if the last failed commit was for another Block
+func (h *multiHandler) getProgress(index int) *commitProgress {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.progress == nil || h.progress.index != index {
+		h.progress = &commitProgress{
+			index:     index,
+			responses: make(map[string]proxy.CommitResponse),
+		}
+	}
+	return h.progress
+}
+
+func (h *multiHandler) resetProgress() {
+	h.Lock()
+	defer h.Unlock()
+	h.progress = nil
+}
+
+func (h *multiHandler) getStateHashes() map[string][]byte {
+	h.Lock()
+	defer h.Unlock()
+
+	res := make(map[string][]byte, len(h.stateHashes))
+	for ns, hash := range h.stateHashes {
+		res[ns] = hash
+	}
+	return res
+}
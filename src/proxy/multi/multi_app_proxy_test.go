@@ -0,0 +1,185 @@
+package multi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/dummy"
+	"github.com/mosaicnetworks/babble/src/proxy/inmem"
+	"github.com/mosaicnetworks/babble/src/proxy/kvstore"
+)
+
+func TestEncodeDecodeTx(t *testing.T) {
+	ns, tx, err := DecodeTx(EncodeTx("kv", []byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns != "kv" || string(tx) != "payload" {
+		t.Fatalf("Decoded %q %q", ns, tx)
+	}
+
+	for _, data := range [][]byte{{}, {0, 'a'}, {5, 'a'}} {
+		if _, _, err := DecodeTx(data); err == nil {
+			t.Fatalf("DecodeTx(%v) should fail", data)
+		}
+	}
+}
+
+func TestMultiAppProxy(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	kv := kvstore.NewInmemKVStore(logger)
+	chat := dummy.NewInmemDummyClient(logger)
+
+	p, err := NewMultiAppProxy(map[string]proxy.AppProxy{
+		"kv":   kv,
+		"chat": chat,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//transactions submitted by an application reach Babble with their
+	//namespace
+	go func() {
+		select {
+		case st := <-p.SubmitCh():
+			st.Respond(p.CheckTx(st.Tx))
+		case <-time.After(time.Second):
+		}
+	}()
+
+	if err := chat.SubmitTx([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.CheckTx(EncodeTx("other", []byte("hello"))); err == nil {
+		t.Fatal("CheckTx should reject unknown namespaces")
+	}
+	if err := p.CheckTx(EncodeTx("kv", []byte("garbage"))); err == nil {
+		t.Fatal("CheckTx should let the application reject its transactions")
+	}
+
+	block := hashgraph.NewBlock(0, 1, []byte("frame"), [][]byte{
+		EncodeTx("kv", kvstore.NewSetTx("a", "1")),
+		EncodeTx("chat", []byte("hello")),
+		EncodeTx("other", []byte("lost")),
+		EncodeTx("kv", kvstore.NewSetTx("b", "2")),
+	})
+
+	resp, err := p.CommitBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//every application only sees its own transactions
+	if txs := chat.GetCommittedTransactions(); !reflect.DeepEqual(txs, [][]byte{[]byte("hello")}) {
+		t.Fatalf("chat should have committed [hello], not %q", txs)
+	}
+	if entries := kv.State().Entries(); !reflect.DeepEqual(entries, map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("Unexpected kv store %v", entries)
+	}
+
+	expectedCodes := []uint32{hashgraph.TxCodeOK, hashgraph.TxCodeOK, CodeUnknownNamespace, hashgraph.TxCodeOK}
+	if len(resp.Results) != len(expectedCodes) {
+		t.Fatalf("There should be %d results, not %d", len(expectedCodes), len(resp.Results))
+	}
+	for i, r := range resp.Results {
+		if r.Code != expectedCodes[i] {
+			t.Fatalf("Result %d should have Code %d, not %d", i, expectedCodes[i], r.Code)
+		}
+	}
+
+	stateHashes := p.StateHashes()
+	if !reflect.DeepEqual(stateHashes["kv"], kv.State().StateHash()) {
+		t.Fatalf("Wrong state hash for namespace kv")
+	}
+	if !reflect.DeepEqual(resp.StateHash, FoldStateHashes(stateHashes)) {
+		t.Fatalf("StateHash should fold the state hashes of the namespaces")
+	}
+
+	//restore the bundle of snapshots in a fresh set of applications
+	snapshot, err := p.GetSnapshot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv2 := kvstore.NewInmemKVStore(logger)
+	p2, err := NewMultiAppProxy(map[string]proxy.AppProxy{
+		"kv":   kv2,
+		"chat": dummy.NewInmemDummyClient(logger),
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateHash, err := p2.Restore(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateHash, resp.StateHash) {
+		t.Fatalf("Restore should return StateHash %X, not %X", resp.StateHash, stateHash)
+	}
+	if entries := kv2.State().Entries(); !reflect.DeepEqual(entries, kv.State().Entries()) {
+		t.Fatalf("Restored kv store should be %v, not %v", kv.State().Entries(), entries)
+	}
+}
+
+//flakyHandler fails to commit the first Block it is given
+type flakyHandler struct {
+	failed bool
+}
+
+func (h *flakyHandler) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	if !h.failed {
+		h.failed = true
+		return nil, fmt.Errorf("flaky")
+	}
+	return []byte("flaky"), nil
+}
+
+func (h *flakyHandler) SnapshotHandler(blockIndex int) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (h *flakyHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	return []byte("flaky"), nil
+}
+
+func TestMultiAppProxyRetry(t *testing.T) {
+	logger := common.NewTestLogger(t)
+
+	chat := dummy.NewInmemDummyClient(logger)
+
+	p, err := NewMultiAppProxy(map[string]proxy.AppProxy{
+		"chat":  chat,
+		"flaky": inmem.NewInmemProxy(&flakyHandler{}, logger),
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := hashgraph.NewBlock(0, 1, []byte("frame"), [][]byte{
+		EncodeTx("chat", []byte("hello")),
+		EncodeTx("flaky", []byte("tx")),
+	})
+
+	if _, err := p.CommitBlock(block); err == nil {
+		t.Fatal("The first commit should fail")
+	}
+
+	if _, err := p.CommitBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	//chat committed the Block before flaky failed; the retry must not give
+	//it the Block again
+	if txs := chat.GetCommittedTransactions(); !reflect.DeepEqual(txs, [][]byte{[]byte("hello")}) {
+		t.Fatalf("chat should have committed [hello] once, not %q", txs)
+	}
+}
@@ -0,0 +1,57 @@
+package multi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+)
+
+//MaxNamespaceLength is the maximum length of a namespace, which is encoded on a
+//single byte in front of every transaction
+const MaxNamespaceLength = 255
+
+//EncodeTx prefixes a transaction with its namespace
+func EncodeTx(namespace string, tx []byte) []byte {
+	data := make([]byte, 0, 1+len(namespace)+len(tx))
+	data = append(data, byte(len(namespace)))
+	data = append(data, namespace...)
+	return append(data, tx...)
+}
+
+//DecodeTx splits a transaction encoded by EncodeTx into its namespace and the
+//transaction of the application
+func DecodeTx(data []byte) (string, []byte, error) {
+	if len(data) == 0 {
+		return "", nil, fmt.Errorf("Empty transaction")
+	}
+	end := 1 + int(data[0])
+	if data[0] == 0 || end > len(data) {
+		return "", nil, fmt.Errorf("Transaction has no valid namespace")
+	}
+	return string(data[1:end]), data[end:], nil
+}
+
+//FoldStateHashes combines the state hashes of several namespaces into the
+//single state hash of a Block. Every namespace contributes the hash of its name
+//and of its state hash, and the contributions are merkleized in the order of
+//the namespaces.
+func FoldStateHashes(stateHashes map[string][]byte) []byte {
+	namespaces := sortedNamespaces(stateHashes)
+
+	leaves := make([][]byte, len(namespaces))
+	for i, ns := range namespaces {
+		leaves[i] = crypto.SimpleHashFromTwoHashes(crypto.SHA256([]byte(ns)), stateHashes[ns])
+	}
+
+	return crypto.SimpleHashFromHashes(leaves)
+}
+
+func sortedNamespaces(m map[string][]byte) []string {
+	namespaces := make([]string, 0, len(m))
+	for ns := range m {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
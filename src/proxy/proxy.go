@@ -1,12 +1,93 @@
 package proxy
 
 import (
+	"encoding/json"
+
 	"github.com/mosaicnetworks/babble/src/hashgraph"
 )
 
 type AppProxy interface {
-	SubmitCh() chan []byte
-	CommitBlock(block hashgraph.Block) ([]byte, error)
+	SubmitCh() chan SubmittedTx
+	//CheckTx asks the App whether a transaction may be admitted to the
+	//transaction pool. Apps that do not validate transactions accept them all.
+	CheckTx(tx []byte) error
+	CommitBlock(block hashgraph.Block) (CommitResponse, error)
 	GetSnapshot(blockIndex int) ([]byte, error)
-	Restore(snapshot []byte) error
+	Restore(snapshot []byte) (stateHash []byte, err error)
+
+	//Chunked snapshots
+	GetSnapshotManifest(blockIndex int) (SnapshotManifest, error)
+	GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error)
+	RestoreChunk(manifest SnapshotManifest, chunk int, data []byte) error
+	RestoreChunks(manifest SnapshotManifest) (stateHash []byte, err error)
+}
+
+//ConnectionStatus describes the connection between an AppProxy and a remote
+//App
+type ConnectionStatus struct {
+	Connected  bool
+	Reconnects int
+	LastError  string
+}
+
+//ConnectedAppProxy is implemented by AppProxies that hold a connection to the
+//App, and report its status in the node's stats
+type ConnectedAppProxy interface {
+	AppProxy
+	ConnectionStatus() ConnectionStatus
+}
+
+//CommitResponse is the response of the App to a Block. Results has one entry
+//per transaction in the Block, or none if the App does not report them.
+type CommitResponse struct {
+	StateHash []byte
+	Results   []hashgraph.TxResult
+}
+
+//UnmarshalJSON also accepts the bare state hash returned by Apps that predate
+//transaction results
+func (r *CommitResponse) UnmarshalJSON(data []byte) error {
+	var stateHash []byte
+	if err := json.Unmarshal(data, &stateHash); err == nil {
+		*r = CommitResponse{StateHash: stateHash}
+		return nil
+	}
+
+	type response CommitResponse
+	return json.Unmarshal(data, (*response)(r))
+}
+
+//SubmittedTx is a transaction submitted by the App. Babble responds once the
+//transaction is in its pool, or with the reason why it was rejected.
+type SubmittedTx struct {
+	Tx       []byte
+	resultCh chan error
+}
+
+//NewSubmittedTx creates a SubmittedTx whose response can be awaited with Wait
+func NewSubmittedTx(tx []byte) SubmittedTx {
+	return SubmittedTx{
+		Tx:       tx,
+		resultCh: make(chan error, 1),
+	}
+}
+
+//Respond is used by Babble to accept the transaction, with a nil error, or to
+//reject it
+func (s SubmittedTx) Respond(err error) {
+	if s.resultCh != nil {
+		s.resultCh <- err
+	}
+}
+
+//Wait returns the response of Babble
+func (s SubmittedTx) Wait() error {
+	return <-s.resultCh
+}
+
+//Submit sends a transaction on submitCh and waits for the response of Babble
+func Submit(submitCh chan SubmittedTx, tx []byte) error {
+	s := NewSubmittedTx(tx)
+	submitCh <- s
+	return s.Wait()
 }
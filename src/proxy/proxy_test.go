@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+func TestCommitResponseUnmarshal(t *testing.T) {
+	//Apps that predate transaction results reply with a bare state hash
+	var legacy CommitResponse
+	if err := json.Unmarshal([]byte(`"c3RhdGVoYXNo"`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if string(legacy.StateHash) != "statehash" || legacy.Results != nil {
+		t.Fatalf("wrong response: %+v", legacy)
+	}
+
+	resp := CommitResponse{
+		StateHash: []byte("statehash"),
+		Results: []hashgraph.TxResult{
+			hashgraph.TxResult{Code: hashgraph.TxCodeOK},
+			hashgraph.TxResult{Code: 1, Log: "failed"},
+		},
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CommitResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, resp) {
+		t.Fatalf("decoded response should be %+v, not %+v", resp, decoded)
+	}
+}
@@ -0,0 +1,304 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+//DefaultChunkSize is the size of the chunks into which snapshots are split when
+//the application does not implement ChunkedProxyHandler
+const DefaultChunkSize = 1 << 20
+
+//SnapshotManifest describes a snapshot that is transferred in chunks. Every
+//chunk is verified against its hash before it is handed to the application.
+type SnapshotManifest struct {
+	BlockIndex int
+	Size       int64    //total size in bytes
+	Chunks     [][]byte //SHA256 hash of every chunk
+
+	//Metadata is an optional, App-specific description of the snapshot, which
+	//the App needs to restore it
+	Metadata []byte `json:",omitempty"`
+}
+
+//NewSnapshotManifest splits a snapshot into chunks of chunkSize bytes and
+//returns the corresponding manifest
+func NewSnapshotManifest(blockIndex int, snapshot []byte, chunkSize int) SnapshotManifest {
+	return newChunksManifest(blockIndex, SplitSnapshot(snapshot, chunkSize))
+}
+
+func newChunksManifest(blockIndex int, chunks [][]byte) SnapshotManifest {
+	manifest := SnapshotManifest{
+		BlockIndex: blockIndex,
+		Chunks:     [][]byte{},
+	}
+	for _, chunk := range chunks {
+		manifest.Size += int64(len(chunk))
+		manifest.Chunks = append(manifest.Chunks, crypto.SHA256(chunk))
+	}
+	return manifest
+}
+
+//SplitSnapshot splits a snapshot into chunks of chunkSize bytes; the last one
+//may be shorter
+func SplitSnapshot(snapshot []byte, chunkSize int) [][]byte {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	chunks := [][]byte{}
+	for start := 0; start < len(snapshot); start += chunkSize {
+		end := start + chunkSize
+		if end > len(snapshot) {
+			end = len(snapshot)
+		}
+		chunks = append(chunks, snapshot[start:end])
+	}
+	return chunks
+}
+
+//Hash identifies the manifest, and hence the snapshot
+func (m *SnapshotManifest) Hash() ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.SHA256(data), nil
+}
+
+//CheckChunk verifies that data is the chunk at the given position
+func (m *SnapshotManifest) CheckChunk(chunk int, data []byte) error {
+	if chunk < 0 || chunk >= len(m.Chunks) {
+		return fmt.Errorf("Snapshot has no chunk %d", chunk)
+	}
+	if !bytes.Equal(crypto.SHA256(data), m.Chunks[chunk]) {
+		return fmt.Errorf("Invalid hash for snapshot chunk %d", chunk)
+	}
+	return nil
+}
+
+//ChunkRequest designates one chunk of the snapshot of a Block
+type ChunkRequest struct {
+	BlockIndex int
+	Chunk      int
+}
+
+//RestoreChunkRequest carries one chunk of a snapshot being restored
+type RestoreChunkRequest struct {
+	Manifest SnapshotManifest
+	Chunk    int
+	Data     []byte
+}
+
+/*******************************************************************************
+chunkedHandler
+*******************************************************************************/
+
+//NewChunkedHandler returns handler itself if it implements ChunkedProxyHandler.
+//Otherwise, it returns an adapter that splits the snapshots of handler into
+//chunks of chunkSize bytes. Restored chunks are streamed to handler if it
+//implements StreamingProxyHandler, and reassembled in memory before calling
+//RestoreHandler otherwise.
+func NewChunkedHandler(handler ProxyHandler, chunkSize int) ChunkedProxyHandler {
+	if chunked, ok := handler.(ChunkedProxyHandler); ok {
+		return chunked
+	}
+	return &chunkedHandler{
+		ProxyHandler: handler,
+		chunkSize:    chunkSize,
+	}
+}
+
+type chunkedHandler struct {
+	ProxyHandler
+	chunkSize int
+
+	//the last snapshot that was served, which is fetched chunk by chunk
+	snapshotLock  sync.Mutex
+	snapshotIndex int
+	snapshot      [][]byte
+
+	restoreLock sync.Mutex
+	restoring   *restore
+}
+
+//CommitResultsHandler forwards to the wrapped handler, which may not report
+//transaction results
+func (h *chunkedHandler) CommitResultsHandler(block hashgraph.Block) (CommitResponse, error) {
+	return Commit(h.ProxyHandler, block)
+}
+
+//CheckTxHandler forwards to the wrapped handler, which may not check
+//transactions
+func (h *chunkedHandler) CheckTxHandler(tx []byte) error {
+	return CheckTx(h.ProxyHandler, tx)
+}
+
+func (h *chunkedHandler) SnapshotManifestHandler(blockIndex int) (SnapshotManifest, error) {
+	chunks, err := h.getSnapshot(blockIndex)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	return newChunksManifest(blockIndex, chunks), nil
+}
+
+func (h *chunkedHandler) SnapshotChunkHandler(blockIndex int, chunk int) ([]byte, error) {
+	chunks, err := h.getSnapshot(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+	if chunk < 0 || chunk >= len(chunks) {
+		return nil, fmt.Errorf("Snapshot %d has no chunk %d", blockIndex, chunk)
+	}
+	return chunks[chunk], nil
+}
+
+//getSnapshot returns the chunks of the snapshot of a Block. The last snapshot
+//is kept, so that serving all its chunks only calls SnapshotHandler once.
+func (h *chunkedHandler) getSnapshot(blockIndex int) ([][]byte, error) {
+	h.snapshotLock.Lock()
+	defer h.snapshotLock.Unlock()
+
+	if h.snapshot != nil && h.snapshotIndex == blockIndex {
+		return h.snapshot, nil
+	}
+
+	snapshot, err := h.SnapshotHandler(blockIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	h.snapshotIndex = blockIndex
+	h.snapshot = SplitSnapshot(snapshot, h.chunkSize)
+
+	return h.snapshot, nil
+}
+
+//RestoreChunkHandler passes the chunk on to the stream of a
+//StreamingProxyHandler, or appends it to the snapshot being reassembled. The
+//manifest comes from a peer, so the snapshot only grows as chunks arrive, and
+//never past the announced Size.
+func (h *chunkedHandler) RestoreChunkHandler(manifest SnapshotManifest, chunk int, data []byte) error {
+	h.restoreLock.Lock()
+	defer h.restoreLock.Unlock()
+
+	if chunk == 0 {
+		h.resetRestore()
+		h.restoring = &restore{}
+		if streaming, ok := h.ProxyHandler.(StreamingProxyHandler); ok {
+			h.restoring.stream = newSnapshotStream(streaming)
+		}
+	}
+
+	if h.restoring == nil {
+		return fmt.Errorf("Snapshot chunk %d delivered before chunk 0", chunk)
+	}
+	if h.restoring.size+int64(len(data)) > manifest.Size {
+		return fmt.Errorf("Snapshot chunks exceed the size of %d bytes", manifest.Size)
+	}
+	h.restoring.size += int64(len(data))
+
+	if h.restoring.stream != nil {
+		return h.restoring.stream.write(data)
+	}
+
+	h.restoring.snapshot = append(h.restoring.snapshot, data...)
+	return nil
+}
+
+func (h *chunkedHandler) RestoreChunksHandler(manifest SnapshotManifest) ([]byte, error) {
+	h.restoreLock.Lock()
+	defer h.restoreLock.Unlock()
+
+	r := h.restoring
+	h.restoring = nil
+
+	if r == nil || r.size != manifest.Size {
+		var size int64
+		if r != nil {
+			size = r.size
+			r.abort(fmt.Errorf("Incomplete snapshot"))
+		}
+		return nil, fmt.Errorf("Restored %d bytes of snapshot, expected %d", size, manifest.Size)
+	}
+
+	if r.stream != nil {
+		return r.stream.close(nil)
+	}
+	return h.RestoreHandler(r.snapshot)
+}
+
+//resetRestore abandons the restore in progress, if any
+func (h *chunkedHandler) resetRestore() {
+	if h.restoring != nil {
+		h.restoring.abort(fmt.Errorf("Snapshot restore restarted"))
+	}
+	h.restoring = nil
+}
+
+//restore is a snapshot being restored, which is either streamed to the
+//application or reassembled in memory
+type restore struct {
+	size     int64
+	stream   *snapshotStream
+	snapshot []byte
+}
+
+func (r *restore) abort(err error) {
+	if r.stream != nil {
+		r.stream.close(err)
+	}
+}
+
+//snapshotStream pipes the chunks of a snapshot to the RestoreStreamHandler of
+//an application, which runs in its own goroutine
+type snapshotStream struct {
+	writer *io.PipeWriter
+	done   chan struct{}
+
+	stateHash []byte
+	err       error
+}
+
+func newSnapshotStream(handler StreamingProxyHandler) *snapshotStream {
+	reader, writer := io.Pipe()
+
+	s := &snapshotStream{
+		writer: writer,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		s.stateHash, s.err = handler.RestoreStreamHandler(reader)
+		//unblock the writer if the application stopped reading early
+		reader.CloseWithError(fmt.Errorf("Snapshot restore ended"))
+	}()
+
+	return s
+}
+
+//write blocks until the application has read the chunk
+func (s *snapshotStream) write(data []byte) error {
+	if _, err := s.writer.Write(data); err != nil {
+		<-s.done
+		if s.err != nil {
+			return s.err
+		}
+		return err
+	}
+	return nil
+}
+
+//close ends the snapshot, with an error if it is incomplete, and waits for the
+//application to return
+func (s *snapshotStream) close(err error) ([]byte, error) {
+	s.writer.CloseWithError(err)
+	<-s.done
+	return s.stateHash, s.err
+}
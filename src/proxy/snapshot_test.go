@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	"github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+type testHandler struct {
+	snapshot  []byte
+	restored  []byte
+	snapshots int //number of calls to SnapshotHandler
+}
+
+func (h *testHandler) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	return nil, nil
+}
+
+func (h *testHandler) SnapshotHandler(blockIndex int) ([]byte, error) {
+	h.snapshots++
+	return h.snapshot, nil
+}
+
+func (h *testHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	h.restored = snapshot
+	return []byte("statehash"), nil
+}
+
+func TestChunkedHandler(t *testing.T) {
+	handler := &testHandler{snapshot: []byte("the application state")}
+	chunked := NewChunkedHandler(handler, 4)
+
+	manifest, err := chunked.SnapshotManifestHandler(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.BlockIndex != 3 || manifest.Size != 21 || len(manifest.Chunks) != 6 {
+		t.Fatalf("wrong manifest: %+v", manifest)
+	}
+
+	//Interrupt the restore after a few chunks, then start over
+	for _, chunks := range []int{3, len(manifest.Chunks)} {
+		for i := 0; i < chunks; i++ {
+			data, err := chunked.SnapshotChunkHandler(3, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := manifest.CheckChunk(i, data); err != nil {
+				t.Fatal(err)
+			}
+			if err := chunked.RestoreChunkHandler(manifest, i, data); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := chunked.RestoreChunksHandler(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(handler.restored, handler.snapshot) {
+		t.Fatalf("restored %q, not %q", handler.restored, handler.snapshot)
+	}
+
+	//The snapshot is only taken once for the manifest and all the chunks
+	if handler.snapshots != 1 {
+		t.Fatalf("SnapshotHandler should be called once, not %d times", handler.snapshots)
+	}
+
+	//Chunks are checked against the manifest
+	if err := manifest.CheckChunk(1, []byte("evil")); err == nil {
+		t.Fatal("CheckChunk should fail with the wrong data")
+	}
+	if err := manifest.CheckChunk(6, nil); err == nil {
+		t.Fatal("CheckChunk should fail with an unknown chunk")
+	}
+
+	//Handlers that implement ChunkedProxyHandler are used as is
+	if NewChunkedHandler(chunked, 10) != chunked {
+		t.Fatal("NewChunkedHandler should not wrap a ChunkedProxyHandler")
+	}
+}
+
+type testStreamingHandler struct {
+	testHandler
+	read chan []byte //receives every read of the stream
+}
+
+func (h *testStreamingHandler) RestoreStreamHandler(r io.Reader) ([]byte, error) {
+	restored := []byte{}
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			restored = append(restored, buf[:n]...)
+			h.read <- append([]byte{}, buf[:n]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.restored = restored
+	return []byte("statehash"), nil
+}
+
+func TestChunkedHandlerStreaming(t *testing.T) {
+	handler := &testStreamingHandler{
+		testHandler: testHandler{snapshot: []byte("the application state")},
+		read:        make(chan []byte, 10),
+	}
+	chunked := NewChunkedHandler(handler, 4)
+
+	manifest, err := chunked.SnapshotManifestHandler(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Every chunk reaches the application as soon as it is delivered
+	for i := range manifest.Chunks {
+		data, err := chunked.SnapshotChunkHandler(3, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chunked.RestoreChunkHandler(manifest, i, data); err != nil {
+			t.Fatal(err)
+		}
+		if read := <-handler.read; !bytes.Equal(read, data) {
+			t.Fatalf("application read %q, not %q", read, data)
+		}
+	}
+
+	stateHash, err := chunked.RestoreChunksHandler(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stateHash) != "statehash" {
+		t.Fatalf("wrong state hash %q", stateHash)
+	}
+	if !bytes.Equal(handler.restored, handler.snapshot) {
+		t.Fatalf("restored %q, not %q", handler.restored, handler.snapshot)
+	}
+}
+
+func TestChunkedHandlerSize(t *testing.T) {
+	handler := &testHandler{}
+	chunked := NewChunkedHandler(handler, 4)
+
+	data := []byte("evil")
+	manifest := SnapshotManifest{
+		Size:   4,
+		Chunks: [][]byte{crypto.SHA256(data), crypto.SHA256(data)},
+	}
+
+	//The size announced by the manifest is not allocated upfront, but it
+	//bounds the snapshot
+	if err := chunked.RestoreChunkHandler(manifest, 0, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := chunked.RestoreChunkHandler(manifest, 1, data); err == nil {
+		t.Fatal("RestoreChunkHandler should reject chunks beyond the manifest Size")
+	}
+
+	manifest.Size = -1
+	if err := chunked.RestoreChunkHandler(manifest, 0, nil); err == nil {
+		t.Fatal("RestoreChunkHandler should reject an invalid Size")
+	}
+
+	//Chunks must start from the first one
+	manifest.Size = 8
+	other := NewChunkedHandler(&testHandler{}, 4)
+	if err := other.RestoreChunkHandler(manifest, 1, data); err == nil {
+		t.Fatal("RestoreChunkHandler should reject chunks before chunk 0")
+	}
+}
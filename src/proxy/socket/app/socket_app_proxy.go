@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
 	"github.com/sirupsen/logrus"
 )
 
@@ -47,11 +48,25 @@ func NewSocketAppProxy(clientAddr string, bindAddr string, timeout time.Duration
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 //Implement AppProxy Interface
 
-func (p *SocketAppProxy) SubmitCh() chan []byte {
+func (p *SocketAppProxy) SubmitCh() chan proxy.SubmittedTx {
 	return p.server.submitCh
 }
 
-func (p *SocketAppProxy) CommitBlock(block hashgraph.Block) ([]byte, error) {
+//ConnectionStatus reports the state of the connection to the App
+func (p *SocketAppProxy) ConnectionStatus() proxy.ConnectionStatus {
+	return p.client.Status()
+}
+
+//Close stops the health checks of the App
+func (p *SocketAppProxy) Close() error {
+	return p.client.Close()
+}
+
+func (p *SocketAppProxy) CheckTx(tx []byte) error {
+	return p.client.CheckTx(tx)
+}
+
+func (p *SocketAppProxy) CommitBlock(block hashgraph.Block) (proxy.CommitResponse, error) {
 	return p.client.CommitBlock(block)
 }
 
@@ -59,6 +74,22 @@ func (p *SocketAppProxy) GetSnapshot(blockIndex int) ([]byte, error) {
 	return p.client.GetSnapshot(blockIndex)
 }
 
-func (p *SocketAppProxy) Restore(snapshot []byte) error {
+func (p *SocketAppProxy) Restore(snapshot []byte) ([]byte, error) {
 	return p.client.Restore(snapshot)
 }
+
+func (p *SocketAppProxy) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	return p.client.GetSnapshotManifest(blockIndex)
+}
+
+func (p *SocketAppProxy) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	return p.client.GetSnapshotChunk(blockIndex, chunk)
+}
+
+func (p *SocketAppProxy) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	return p.client.RestoreChunk(manifest, chunk, data)
+}
+
+func (p *SocketAppProxy) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	return p.client.RestoreChunks(manifest)
+}
@@ -1,12 +1,13 @@
 package app
 
 import (
-	"net"
 	"net/rpc"
-	"net/rpc/jsonrpc"
+	"strings"
 	"time"
 
 	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/socket/client"
 	"github.com/sirupsen/logrus"
 )
 
@@ -14,55 +15,106 @@ type SocketAppProxyClient struct {
 	clientAddr string
 	timeout    time.Duration
 	logger     *logrus.Logger
-	rpc        *rpc.Client
+
+	//rpc reconnects to the App when it restarts, and pings it every
+	//DefaultPingInterval
+	rpc *client.Client
+
+	//legacy serves and restores chunked snapshots for Apps that only
+	//implement State.GetSnapshot and State.Restore
+	legacy proxy.ChunkedProxyHandler
 }
 
 func NewSocketAppProxyClient(clientAddr string, timeout time.Duration, logger *logrus.Logger) *SocketAppProxyClient {
-	return &SocketAppProxyClient{
+	rpcClient := client.NewClient(clientAddr, timeout, logger)
+	rpcClient.HealthCheck("State.Ping", client.DefaultPingInterval)
+
+	p := &SocketAppProxyClient{
 		clientAddr: clientAddr,
 		timeout:    timeout,
 		logger:     logger,
+		rpc:        rpcClient,
 	}
+	p.legacy = proxy.NewChunkedHandler(legacyHandler{p}, proxy.DefaultChunkSize)
+
+	return p
 }
 
-func (p *SocketAppProxyClient) getConnection() error {
-	if p.rpc == nil {
-		conn, err := net.DialTimeout("tcp", p.clientAddr, p.timeout)
+//legacyHandler is the ProxyHandler of an App that does not implement the
+//chunked snapshot methods. It is wrapped by NewChunkedHandler, which splits
+//the snapshots returned by State.GetSnapshot and reassembles the chunks passed
+//to State.Restore.
+type legacyHandler struct {
+	p *SocketAppProxyClient
+}
 
-		if err != nil {
-			return err
-		}
+func (h legacyHandler) CommitHandler(block hashgraph.Block) ([]byte, error) {
+	resp, err := h.p.CommitBlock(block)
+	return resp.StateHash, err
+}
 
-		p.rpc = jsonrpc.NewClient(conn)
-	}
+func (h legacyHandler) SnapshotHandler(blockIndex int) ([]byte, error) {
+	return h.p.GetSnapshot(blockIndex)
+}
 
-	return nil
+func (h legacyHandler) RestoreHandler(snapshot []byte) ([]byte, error) {
+	return h.p.Restore(snapshot)
 }
 
-func (p *SocketAppProxyClient) CommitBlock(block hashgraph.Block) ([]byte, error) {
-	if err := p.getConnection(); err != nil {
-		return []byte{}, err
+//isMissingMethod tells whether err is the error returned by the App when it
+//does not implement the method that was called
+func isMissingMethod(err error) bool {
+	serverErr, ok := err.(rpc.ServerError)
+	return ok && strings.HasPrefix(string(serverErr), "rpc: can't find method")
+}
+
+//Status reports the state of the connection to the App
+func (p *SocketAppProxyClient) Status() proxy.ConnectionStatus {
+	return p.rpc.Status()
+}
+
+//Close stops the health checks and closes the connection to the App
+func (p *SocketAppProxyClient) Close() error {
+	return p.rpc.Close()
+}
+
+//CheckTx calls State.CheckTx. Apps that do not implement it accept all
+//transactions.
+func (p *SocketAppProxyClient) CheckTx(tx []byte) error {
+	var ack bool
+
+	err := p.rpc.Call("State.CheckTx", tx, &ack)
+	if isMissingMethod(err) {
+		err = nil
 	}
 
-	var stateHash []byte
+	p.logger.WithFields(logrus.Fields{
+		"size": len(tx),
+		"err":  err,
+	}).Debug("AppProxyClient.CheckTx")
 
-	if err := p.rpc.Call("State.CommitBlock", block, &stateHash); err != nil {
-		return []byte{}, err
+	return err
+}
+
+//CommitBlock calls State.CommitBlock. Apps may reply with a bare state hash
+//or with a CommitResponse that includes transaction results.
+func (p *SocketAppProxyClient) CommitBlock(block hashgraph.Block) (proxy.CommitResponse, error) {
+	var resp proxy.CommitResponse
+
+	if err := p.rpc.Call("State.CommitBlock", block, &resp); err != nil {
+		return proxy.CommitResponse{}, err
 	}
 
 	p.logger.WithFields(logrus.Fields{
 		"block":      block.Index(),
-		"state_hash": stateHash,
+		"state_hash": resp.StateHash,
+		"results":    len(resp.Results),
 	}).Debug("AppProxyClient.CommitBlock")
 
-	return stateHash, nil
+	return resp, nil
 }
 
 func (p *SocketAppProxyClient) GetSnapshot(blockIndex int) ([]byte, error) {
-	if err := p.getConnection(); err != nil {
-		return []byte{}, err
-	}
-
 	var snapshot []byte
 
 	if err := p.rpc.Call("State.GetSnapshot", blockIndex, &snapshot); err != nil {
@@ -77,20 +129,104 @@ func (p *SocketAppProxyClient) GetSnapshot(blockIndex int) ([]byte, error) {
 	return snapshot, nil
 }
 
-func (p *SocketAppProxyClient) Restore(snapshot []byte) error {
-	if err := p.getConnection(); err != nil {
-		return err
-	}
-
+func (p *SocketAppProxyClient) Restore(snapshot []byte) ([]byte, error) {
 	var stateHash []byte
 
 	if err := p.rpc.Call("State.Restore", snapshot, &stateHash); err != nil {
-		return err
+		return nil, err
 	}
 
 	p.logger.WithFields(logrus.Fields{
 		"state_hash": stateHash,
 	}).Debug("AppProxyClient.Restore")
 
+	return stateHash, nil
+}
+
+//GetSnapshotManifest calls State.GetSnapshotManifest. For Apps that do not
+//implement it, the manifest is computed from State.GetSnapshot.
+func (p *SocketAppProxyClient) GetSnapshotManifest(blockIndex int) (proxy.SnapshotManifest, error) {
+	var manifest proxy.SnapshotManifest
+
+	err := p.rpc.Call("State.GetSnapshotManifest", blockIndex, &manifest)
+	if isMissingMethod(err) {
+		manifest, err = p.legacy.SnapshotManifestHandler(blockIndex)
+	}
+	if err != nil {
+		return proxy.SnapshotManifest{}, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":  blockIndex,
+		"size":   manifest.Size,
+		"chunks": len(manifest.Chunks),
+	}).Debug("AppProxyClient.GetSnapshotManifest")
+
+	return manifest, nil
+}
+
+//GetSnapshotChunk calls State.GetSnapshotChunk. For Apps that do not
+//implement it, the chunk is cut from State.GetSnapshot.
+func (p *SocketAppProxyClient) GetSnapshotChunk(blockIndex int, chunk int) ([]byte, error) {
+	var data []byte
+
+	args := proxy.ChunkRequest{BlockIndex: blockIndex, Chunk: chunk}
+	err := p.rpc.Call("State.GetSnapshotChunk", args, &data)
+	if isMissingMethod(err) {
+		data, err = p.legacy.SnapshotChunkHandler(blockIndex, chunk)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block": blockIndex,
+		"chunk": chunk,
+		"size":  len(data),
+	}).Debug("AppProxyClient.GetSnapshotChunk")
+
+	return data, nil
+}
+
+//RestoreChunk calls State.RestoreChunk. For Apps that do not implement it, the
+//chunks are reassembled here and passed to State.Restore by RestoreChunks.
+func (p *SocketAppProxyClient) RestoreChunk(manifest proxy.SnapshotManifest, chunk int, data []byte) error {
+	var ack bool
+
+	args := proxy.RestoreChunkRequest{Manifest: manifest, Chunk: chunk, Data: data}
+	err := p.rpc.Call("State.RestoreChunk", args, &ack)
+	if isMissingMethod(err) {
+		err = p.legacy.RestoreChunkHandler(manifest, chunk, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block": manifest.BlockIndex,
+		"chunk": chunk,
+	}).Debug("AppProxyClient.RestoreChunk")
+
 	return nil
 }
+
+//RestoreChunks calls State.RestoreChunks. For Apps that do not implement it,
+//the chunks passed to RestoreChunk are restored with State.Restore.
+func (p *SocketAppProxyClient) RestoreChunks(manifest proxy.SnapshotManifest) ([]byte, error) {
+	var stateHash []byte
+
+	err := p.rpc.Call("State.RestoreChunks", manifest, &stateHash)
+	if isMissingMethod(err) {
+		stateHash, err = p.legacy.RestoreChunksHandler(manifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      manifest.BlockIndex,
+		"state_hash": stateHash,
+	}).Debug("AppProxyClient.RestoreChunks")
+
+	return stateHash, nil
+}
@@ -5,19 +5,20 @@ import (
 	"net/rpc"
 	"net/rpc/jsonrpc"
 
+	"github.com/mosaicnetworks/babble/src/proxy"
 	"github.com/sirupsen/logrus"
 )
 
 type SocketAppProxyServer struct {
 	netListener *net.Listener
 	rpcServer   *rpc.Server
-	submitCh    chan []byte
+	submitCh    chan proxy.SubmittedTx
 	logger      *logrus.Logger
 }
 
 func NewSocketAppProxyServer(bindAddress string, logger *logrus.Logger) (*SocketAppProxyServer, error) {
 	server := &SocketAppProxyServer{
-		submitCh: make(chan []byte),
+		submitCh: make(chan proxy.SubmittedTx),
 		logger:   logger,
 	}
 
@@ -60,12 +61,19 @@ func (p *SocketAppProxyServer) listen() {
 	}
 }
 
+//Ping is used by the App to check its connection to Babble
+func (p *SocketAppProxyServer) Ping(nonce int, pong *int) error {
+	*pong = nonce
+
+	return nil
+}
+
 func (p *SocketAppProxyServer) SubmitTx(tx []byte, ack *bool) error {
 	p.logger.Debug("SubmitTx")
 
-	p.submitCh <- tx
+	err := proxy.Submit(p.submitCh, tx)
 
-	*ack = true
+	*ack = err == nil
 
-	return nil
+	return err
 }
@@ -32,7 +32,7 @@ func NewSocketBabbleProxy(
 		logger.Level = logrus.DebugLevel
 	}
 
-	client := NewSocketBabbleProxyClient(nodeAddr, timeout)
+	client := NewSocketBabbleProxyClient(nodeAddr, timeout, logger)
 
 	server, err := NewSocketBabbleProxyServer(bindAddr, handler, timeout, logger)
 
@@ -66,3 +66,13 @@ func (p *SocketBabbleProxy) SubmitTx(tx []byte) error {
 
 	return nil
 }
+
+//ConnectionStatus reports the state of the connection to Babble
+func (p *SocketBabbleProxy) ConnectionStatus() proxy.ConnectionStatus {
+	return p.client.Status()
+}
+
+//Close stops the health checks of Babble
+func (p *SocketBabbleProxy) Close() error {
+	return p.client.Close()
+}
@@ -1,44 +1,34 @@
 package babble
 
 import (
-	"net"
-	"net/rpc"
-	"net/rpc/jsonrpc"
 	"time"
+
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/mosaicnetworks/babble/src/proxy/socket/client"
+	"github.com/sirupsen/logrus"
 )
 
 type SocketBabbleProxyClient struct {
 	nodeAddr string
 	timeout  time.Duration
-	rpc      *rpc.Client
+
+	//rpc reconnects to Babble when it restarts, and pings it every
+	//DefaultPingInterval
+	rpc *client.Client
 }
 
-func NewSocketBabbleProxyClient(nodeAddr string, timeout time.Duration) *SocketBabbleProxyClient {
+func NewSocketBabbleProxyClient(nodeAddr string, timeout time.Duration, logger *logrus.Logger) *SocketBabbleProxyClient {
+	rpcClient := client.NewClient(nodeAddr, timeout, logger)
+	rpcClient.HealthCheck("Babble.Ping", client.DefaultPingInterval)
+
 	return &SocketBabbleProxyClient{
 		nodeAddr: nodeAddr,
 		timeout:  timeout,
+		rpc:      rpcClient,
 	}
 }
 
-func (p *SocketBabbleProxyClient) getConnection() error {
-	if p.rpc == nil {
-		conn, err := net.DialTimeout("tcp", p.nodeAddr, p.timeout)
-
-		if err != nil {
-			return err
-		}
-
-		p.rpc = jsonrpc.NewClient(conn)
-	}
-
-	return nil
-}
-
 func (p *SocketBabbleProxyClient) SubmitTx(tx []byte) (*bool, error) {
-	if err := p.getConnection(); err != nil {
-		return nil, err
-	}
-
 	var ack bool
 
 	err := p.rpc.Call("Babble.SubmitTx", tx, &ack)
@@ -49,3 +39,13 @@ func (p *SocketBabbleProxyClient) SubmitTx(tx []byte) (*bool, error) {
 
 	return &ack, nil
 }
+
+//Status reports the state of the connection to Babble
+func (p *SocketBabbleProxyClient) Status() proxy.ConnectionStatus {
+	return p.rpc.Status()
+}
+
+//Close stops the health checks and closes the connection to Babble
+func (p *SocketBabbleProxyClient) Close() error {
+	return p.rpc.Close()
+}
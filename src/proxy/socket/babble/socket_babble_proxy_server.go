@@ -14,7 +14,7 @@ import (
 type SocketBabbleProxyServer struct {
 	netListener *net.Listener
 	rpcServer   *rpc.Server
-	handler     proxy.ProxyHandler
+	handler     proxy.ChunkedProxyHandler
 	timeout     time.Duration
 	logger      *logrus.Logger
 }
@@ -27,7 +27,7 @@ func NewSocketBabbleProxyServer(
 ) (*SocketBabbleProxyServer, error) {
 
 	server := &SocketBabbleProxyServer{
-		handler: handler,
+		handler: proxy.NewChunkedHandler(handler, proxy.DefaultChunkSize),
 		timeout: timeout,
 		logger:  logger,
 	}
@@ -68,12 +68,32 @@ func (p *SocketBabbleProxyServer) listen() error {
 	}
 }
 
-func (p *SocketBabbleProxyServer) CommitBlock(block hashgraph.Block, stateH *[]byte) (err error) {
-	*stateH, err = p.handler.CommitHandler(block)
+//Ping is used by Babble to check its connection to the App
+func (p *SocketBabbleProxyServer) Ping(nonce int, pong *int) error {
+	*pong = nonce
+
+	return nil
+}
+
+func (p *SocketBabbleProxyServer) CheckTx(tx []byte, ack *bool) (err error) {
+	err = proxy.CheckTx(p.handler, tx)
+	*ack = err == nil
+
+	p.logger.WithFields(logrus.Fields{
+		"size": len(tx),
+		"err":  err,
+	}).Debug("BabbleProxyServer.CheckTx")
+
+	return
+}
+
+func (p *SocketBabbleProxyServer) CommitBlock(block hashgraph.Block, resp *proxy.CommitResponse) (err error) {
+	*resp, err = proxy.Commit(p.handler, block)
 
 	p.logger.WithFields(logrus.Fields{
 		"block":      block.Index(),
-		"state_hash": stateH,
+		"state_hash": resp.StateHash,
+		"results":    len(resp.Results),
 		"err":        err,
 	}).Debug("BabbleProxyServer.CommitBlock")
 
@@ -114,3 +134,52 @@ func (p *SocketBabbleProxyServer) Restore(snapshot []byte, stateHash *[]byte) (e
 
 	return
 }
+
+func (p *SocketBabbleProxyServer) GetSnapshotManifest(blockIndex int, manifest *proxy.SnapshotManifest) (err error) {
+	*manifest, err = p.handler.SnapshotManifestHandler(blockIndex)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":  blockIndex,
+		"chunks": len(manifest.Chunks),
+		"err":    err,
+	}).Debug("BabbleProxyServer.GetSnapshotManifest")
+
+	return
+}
+
+func (p *SocketBabbleProxyServer) GetSnapshotChunk(args proxy.ChunkRequest, data *[]byte) (err error) {
+	*data, err = p.handler.SnapshotChunkHandler(args.BlockIndex, args.Chunk)
+
+	p.logger.WithFields(logrus.Fields{
+		"block": args.BlockIndex,
+		"chunk": args.Chunk,
+		"err":   err,
+	}).Debug("BabbleProxyServer.GetSnapshotChunk")
+
+	return
+}
+
+func (p *SocketBabbleProxyServer) RestoreChunk(args proxy.RestoreChunkRequest, ack *bool) (err error) {
+	err = p.handler.RestoreChunkHandler(args.Manifest, args.Chunk, args.Data)
+	*ack = err == nil
+
+	p.logger.WithFields(logrus.Fields{
+		"block": args.Manifest.BlockIndex,
+		"chunk": args.Chunk,
+		"err":   err,
+	}).Debug("BabbleProxyServer.RestoreChunk")
+
+	return
+}
+
+func (p *SocketBabbleProxyServer) RestoreChunks(manifest proxy.SnapshotManifest, stateHash *[]byte) (err error) {
+	*stateHash, err = p.handler.RestoreChunksHandler(manifest)
+
+	p.logger.WithFields(logrus.Fields{
+		"block":      manifest.BlockIndex,
+		"state_hash": stateHash,
+		"err":        err,
+	}).Debug("BabbleProxyServer.RestoreChunks")
+
+	return
+}
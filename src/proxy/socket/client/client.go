@@ -0,0 +1,230 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//DefaultPingInterval is the interval between health checks
+	DefaultPingInterval = 5 * time.Second
+
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+//Client is a JSON-RPC client over TCP that survives restarts of the other side.
+//It dials lazily, drops its connection when a call fails because the
+//connection is broken, and redials with exponential backoff. A health check
+//routine can ping the other side to detect dead connections, and to reconnect,
+//before the next call.
+type Client struct {
+	addr    string
+	timeout time.Duration
+	logger  *logrus.Logger
+
+	//the lock protects the fields below, not the calls, which can be concurrent
+	lock       sync.Mutex
+	rpc        *rpc.Client
+	connected  bool //set once the first connection is established
+	reconnects int
+	backoff    time.Duration
+	nextDial   time.Time
+	lastErr    error
+
+	shutdownCh chan struct{}
+	closeOnce  sync.Once
+}
+
+func NewClient(addr string, timeout time.Duration, logger *logrus.Logger) *Client {
+	if logger == nil {
+		logger = logrus.New()
+		logger.Level = logrus.DebugLevel
+	}
+
+	return &Client{
+		addr:       addr,
+		timeout:    timeout,
+		logger:     logger,
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+//Call calls a remote method. Errors returned by the remote method (of type
+//rpc.ServerError) leave the connection open; other errors close it, so that the
+//next call redials. If the connection was already closed when the call was
+//made, Call redials and tries once more.
+func (c *Client) Call(method string, args interface{}, reply interface{}) error {
+	for tries := 1; ; tries++ {
+		client, err := c.connection()
+		if err != nil {
+			return err
+		}
+
+		err = client.Call(method, args, reply)
+		if err == nil || isServerError(err) {
+			return err
+		}
+
+		c.drop(client, err)
+
+		if err != rpc.ErrShutdown || tries > 1 {
+			return err
+		}
+	}
+}
+
+//Ping checks that the other side answers a method, within the timeout. The
+//method takes an int and should echo it back. A server that does not
+//implement it still proves that the connection is alive.
+func (c *Client) Ping(method string) error {
+	client, err := c.connection()
+	if err != nil {
+		return err
+	}
+
+	var pong int
+	call := client.Go(method, 1, &pong, make(chan *rpc.Call, 1))
+
+	select {
+	case <-call.Done:
+		err = call.Error
+	case <-time.After(c.timeout):
+		err = fmt.Errorf("%s timed out after %v", method, c.timeout)
+	}
+
+	if err == nil || isServerError(err) {
+		return nil
+	}
+
+	c.drop(client, err)
+
+	return err
+}
+
+//HealthCheck pings the other side every interval, until the Client is closed
+func (c *Client) HealthCheck(method string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Ping(method); err != nil {
+					c.logger.WithFields(logrus.Fields{
+						"addr":  c.addr,
+						"error": err,
+					}).Debug("Health check failed")
+				}
+			case <-c.shutdownCh:
+				return
+			}
+		}
+	}()
+}
+
+//Status reports whether the Client is connected, how many times it had to
+//reconnect, and the last connection error
+func (c *Client) Status() proxy.ConnectionStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	status := proxy.ConnectionStatus{
+		Connected:  c.rpc != nil,
+		Reconnects: c.reconnects,
+	}
+	if c.lastErr != nil {
+		status.LastError = c.lastErr.Error()
+	}
+
+	return status
+}
+
+//Close stops the health check and closes the connection
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.shutdownCh) })
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.rpc == nil {
+		return nil
+	}
+
+	err := c.rpc.Close()
+	c.rpc = nil
+
+	return err
+}
+
+//connection returns the current connection, or dials a new one unless the
+//last attempt failed less than a backoff ago
+func (c *Client) connection() (*rpc.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.rpc != nil {
+		return c.rpc, nil
+	}
+
+	if time.Now().Before(c.nextDial) {
+		return nil, fmt.Errorf("Not connected to %s: %v", c.addr, c.lastErr)
+	}
+
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		c.lastErr = err
+		c.backoff *= 2
+		if c.backoff < minBackoff {
+			c.backoff = minBackoff
+		}
+		if c.backoff > maxBackoff {
+			c.backoff = maxBackoff
+		}
+		c.nextDial = time.Now().Add(c.backoff)
+		return nil, err
+	}
+
+	if c.connected {
+		c.reconnects++
+		c.logger.WithField("addr", c.addr).Info("Reconnected")
+	}
+	c.connected = true
+	c.backoff = 0
+	c.lastErr = nil
+	c.rpc = jsonrpc.NewClient(conn)
+
+	return c.rpc, nil
+}
+
+//drop closes a broken connection, unless it was already replaced
+func (c *Client) drop(client *rpc.Client, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.rpc != client {
+		return
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"addr":  c.addr,
+		"error": err,
+	}).Warn("Connection lost")
+
+	c.rpc.Close()
+	c.rpc = nil
+	c.lastErr = err
+}
+
+func isServerError(err error) bool {
+	_, ok := err.(rpc.ServerError)
+	return ok
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+)
+
+type Echo struct{}
+
+func (e *Echo) Ping(nonce int, pong *int) error {
+	*pong = nonce
+	return nil
+}
+
+func (e *Echo) Say(msg string, reply *string) error {
+	*reply = msg
+	return nil
+}
+
+//testServer serves Echo until it is stopped, which also closes the open
+//connections, as if the process had died
+type testServer struct {
+	listener net.Listener
+	lock     sync.Mutex
+	conns    []net.Conn
+}
+
+func startTestServer(addr string, t *testing.T) *testServer {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := rpc.NewServer()
+	server.RegisterName("Echo", &Echo{})
+
+	s := &testServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.lock.Lock()
+			s.conns = append(s.conns, conn)
+			s.lock.Unlock()
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) stop() {
+	s.listener.Close()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	addr := "127.0.0.1:9970"
+
+	c := NewClient(addr, time.Second, common.NewTestLogger(t))
+	defer c.Close()
+
+	//Nothing is listening yet
+	var reply string
+	if err := c.Call("Echo.Say", "hello", &reply); err == nil {
+		t.Fatalf("Call should fail")
+	}
+	if c.Status().Connected || c.Status().LastError == "" {
+		t.Fatalf("Status should report the dial error: %+v", c.Status())
+	}
+
+	server := startTestServer(addr, t)
+
+	//Wait for the backoff to expire
+	time.Sleep(2 * minBackoff)
+
+	if err := c.Call("Echo.Say", "hello", &reply); err != nil || reply != "hello" {
+		t.Fatalf("Call should succeed, got %q, %v", reply, err)
+	}
+	if status := c.Status(); !status.Connected || status.Reconnects != 0 {
+		t.Fatalf("wrong status %+v", status)
+	}
+
+	//Restart the server. The first call notices the dead connection, redials,
+	//and tries again.
+	server.stop()
+	server = startTestServer(addr, t)
+	defer server.stop()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := c.Call("Echo.Say", "again", &reply); err != nil || reply != "again" {
+		t.Fatalf("Call should succeed after a restart, got %q, %v", reply, err)
+	}
+	if status := c.Status(); !status.Connected || status.Reconnects != 1 {
+		t.Fatalf("wrong status %+v", status)
+	}
+
+	//Methods that do not exist leave the connection open
+	if err := c.Call("Echo.Unknown", "", &reply); err == nil {
+		t.Fatalf("Call should fail")
+	}
+	if !c.Status().Connected {
+		t.Fatalf("a server error should not close the connection")
+	}
+}
+
+func TestClientHealthCheck(t *testing.T) {
+	addr := "127.0.0.1:9971"
+
+	server := startTestServer(addr, t)
+
+	c := NewClient(addr, time.Second, common.NewTestLogger(t))
+	defer c.Close()
+
+	c.HealthCheck("Echo.Ping", 20*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	if !c.Status().Connected {
+		t.Fatalf("the health check should connect")
+	}
+
+	server.stop()
+	time.Sleep(100 * time.Millisecond)
+	if c.Status().Connected {
+		t.Fatalf("the health check should detect the dead connection")
+	}
+
+	//The health check reconnects in the background, without any call
+	server = startTestServer(addr, t)
+	defer server.stop()
+
+	timeout := time.After(2 * time.Second)
+	for !c.Status().Connected {
+		select {
+		case <-timeout:
+			t.Fatalf("the health check should reconnect: %+v", c.Status())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if c.Status().Reconnects != 1 {
+		t.Fatalf("wrong status %+v", c.Status())
+	}
+}
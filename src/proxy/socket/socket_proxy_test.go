@@ -1,12 +1,17 @@
 package socket
 
 import (
+	"bytes"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/mosaicnetworks/babble/src/common"
 	"github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/proxy"
 	aproxy "github.com/mosaicnetworks/babble/src/proxy/socket/app"
 	bproxy "github.com/mosaicnetworks/babble/src/proxy/socket/babble"
 	"github.com/sirupsen/logrus"
@@ -72,9 +77,10 @@ func TestSocketProxyServer(t *testing.T) {
 		select {
 		case st := <-submitCh:
 			// Verify the command
-			if !reflect.DeepEqual(st, tx) {
-				t.Fatalf("tx mismatch: %#v %#v", tx, st)
+			if !reflect.DeepEqual(st.Tx, tx) {
+				t.Fatalf("tx mismatch: %#v %#v", tx, st.Tx)
 			}
+			st.Respond(nil)
 
 		case <-time.After(200 * time.Millisecond):
 			t.Fatalf("timeout")
@@ -123,7 +129,8 @@ func TestSocketProxyClient(t *testing.T) {
 	expectedStateHash := []byte("statehash")
 	expectedSnapshot := []byte("snapshot")
 
-	stateHash, err := appProxy.CommitBlock(block)
+	resp, err := appProxy.CommitBlock(block)
+	stateHash := resp.StateHash
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,7 +156,7 @@ func TestSocketProxyClient(t *testing.T) {
 		t.Fatalf("Snapshot should be %v, not %v", expectedSnapshot, snapshot)
 	}
 
-	err = appProxy.Restore(snapshot)
+	_, err = appProxy.Restore(snapshot)
 	if err != nil {
 		t.Fatalf("Error restoring snapshot: %v", err)
 	}
@@ -157,4 +164,132 @@ func TestSocketProxyClient(t *testing.T) {
 	if !reflect.DeepEqual(expectedSnapshot, handler.snapshot) {
 		t.Fatalf("snapshot should be %v, not %v", expectedSnapshot, handler.snapshot)
 	}
+
+	//chunked snapshots
+	handler.snapshot = []byte{}
+
+	manifest, err := appProxy.GetSnapshotManifest(block.Index())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Chunks) != 1 {
+		t.Fatalf("Manifest should have 1 chunk, not %d", len(manifest.Chunks))
+	}
+
+	chunk, err := appProxy.GetSnapshotChunk(block.Index(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manifest.CheckChunk(0, chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appProxy.RestoreChunk(manifest, 0, chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	stateHash, err = appProxy.RestoreChunks(manifest)
+	if err != nil {
+		t.Fatalf("Error restoring chunks: %v", err)
+	}
+
+	if !reflect.DeepEqual(stateHash, expectedStateHash) {
+		t.Fatalf("StateHash should be %v, not %v", expectedStateHash, stateHash)
+	}
+
+	if !reflect.DeepEqual(expectedSnapshot, handler.snapshot) {
+		t.Fatalf("snapshot should be %v, not %v", expectedSnapshot, handler.snapshot)
+	}
+}
+
+//LegacyState is the RPC service of an App that does not implement the chunked
+//snapshot methods
+type LegacyState struct {
+	snapshot []byte
+	restored []byte
+}
+
+func (s *LegacyState) Ping(nonce int, pong *int) error {
+	*pong = nonce
+	return nil
+}
+
+func (s *LegacyState) GetSnapshot(blockIndex int, snapshot *[]byte) error {
+	*snapshot = s.snapshot
+	return nil
+}
+
+func (s *LegacyState) Restore(snapshot []byte, stateHash *[]byte) error {
+	s.restored = snapshot
+	*stateHash = []byte("statehash")
+	return nil
+}
+
+func TestSocketProxyClientLegacySnapshots(t *testing.T) {
+	clientAddr := "127.0.0.1:9994"
+
+	state := &LegacyState{
+		snapshot: bytes.Repeat([]byte("s"), 2*proxy.DefaultChunkSize+1),
+	}
+
+	server := rpc.NewServer()
+	server.RegisterName("State", state)
+
+	l, err := net.Listen("tcp", clientAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	appProxy := aproxy.NewSocketAppProxyClient(clientAddr, 1*time.Second, common.NewTestLogger(t))
+	defer appProxy.Close()
+
+	manifest, err := appProxy.GetSnapshotManifest(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Chunks) != 3 {
+		t.Fatalf("Manifest should have 3 chunks, not %d", len(manifest.Chunks))
+	}
+
+	for i := range manifest.Chunks {
+		chunk, err := appProxy.GetSnapshotChunk(1, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := manifest.CheckChunk(i, chunk); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := appProxy.RestoreChunk(manifest, i, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stateHash, err := appProxy.RestoreChunks(manifest)
+	if err != nil {
+		t.Fatalf("Error restoring chunks: %v", err)
+	}
+
+	if !reflect.DeepEqual(stateHash, []byte("statehash")) {
+		t.Fatalf("StateHash should be statehash, not %s", stateHash)
+	}
+
+	if !bytes.Equal(state.restored, state.snapshot) {
+		t.Fatalf("Restored snapshot should have %d bytes, not %d", len(state.snapshot), len(state.restored))
+	}
 }
@@ -2,13 +2,19 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/mosaicnetworks/babble/src/common"
 	"github.com/mosaicnetworks/babble/src/node"
 	"github.com/sirupsen/logrus"
 )
 
+//defaultGraphRounds is the number of Rounds returned by the graph endpoint
+//when no lower bound is specified.
+const defaultGraphRounds = 10
+
 type Service struct {
 	bindAddress string
 	node        *node.Node
@@ -29,6 +35,10 @@ func (s *Service) Serve() {
 	s.logger.WithField("bind_address", s.bindAddress).Debug("Service serving")
 	http.HandleFunc("/stats", s.GetStats)
 	http.HandleFunc("/block/", s.GetBlock)
+	http.HandleFunc("/receipts/", s.GetBlockReceipts)
+	http.HandleFunc("/receipt/", s.GetReceipt)
+	http.HandleFunc("/graph", s.GetGraph)
+	http.HandleFunc("/peers", s.GetPeers)
 	err := http.ListenAndServe(s.bindAddress, nil)
 	if err != nil {
 		s.logger.WithField("error", err).Error("Service failed")
@@ -42,6 +52,15 @@ func (s *Service) GetStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+//GetPeers returns the health of every peer: successful and failed requests,
+//average latency, and whether the peer is currently backed off.
+func (s *Service) GetPeers(w http.ResponseWriter, r *http.Request) {
+	peers := s.node.GetPeers()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(peers)
+}
+
 func (s *Service) GetBlock(w http.ResponseWriter, r *http.Request) {
 	param := r.URL.Path[len("/block/"):]
 	blockIndex, err := strconv.Atoi(param)
@@ -61,3 +80,107 @@ func (s *Service) GetBlock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(block)
 }
+
+//GetBlockReceipts returns the Receipts of the transactions in a Block
+func (s *Service) GetBlockReceipts(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Path[len("/receipts/"):]
+	blockIndex, err := strconv.Atoi(param)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Parsing block_index parameter %s", param)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	receipts, err := s.node.GetBlockReceipts(blockIndex)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving receipts of block %d", blockIndex)
+		http.Error(w, err.Error(), receiptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+//GetReceipt returns the Receipt of a transaction, identified by the hex
+//encoding of its SHA256 hash. 404 means that the transaction has not been
+//committed yet, or that the App does not report transaction results.
+func (s *Service) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	txHash := r.URL.Path[len("/receipt/"):]
+
+	receipt, err := s.node.GetReceipt(txHash)
+	if err != nil {
+		s.logger.WithError(err).Debugf("Retrieving receipt of transaction %s", txHash)
+		http.Error(w, err.Error(), receiptErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
+
+func receiptErrorStatus(err error) int {
+	if common.Is(err, common.KeyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+//GetGraph exports the hashgraph between two Rounds, for visualisation
+//purposes. At most node.MaxGraphRounds Rounds are exported. Query parameters:
+//	from:   first Round (default: last Round - 10)
+//	to:     last Round (default: last Round)
+//	format: "dot" for Graphviz or "json" (default)
+func (s *Service) GetGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	toRound := s.node.GetLastRound()
+	if toRound < 0 {
+		toRound = 0
+	}
+	if param := query.Get("to"); param != "" {
+		to, err := strconv.Atoi(param)
+		if err != nil {
+			s.logger.WithError(err).Errorf("Parsing to parameter %s", param)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		toRound = to
+	}
+
+	fromRound := toRound - defaultGraphRounds
+	if fromRound < 0 {
+		fromRound = 0
+	}
+	if param := query.Get("from"); param != "" {
+		from, err := strconv.Atoi(param)
+		if err != nil {
+			s.logger.WithError(err).Errorf("Parsing from parameter %s", param)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fromRound = from
+	}
+
+	graph, err := s.node.GetGraph(fromRound, toRound)
+	if err != nil {
+		s.logger.WithError(err).Errorf("Retrieving graph from round %d to %d", fromRound, toRound)
+		status := http.StatusInternalServerError
+		if err == node.ErrInvalidGraphRange {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	switch format := query.Get("format"); format {
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		graph.WriteDOT(w)
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(graph)
+	default:
+		http.Error(w, fmt.Sprintf("Unknown format %s", format), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,88 @@
+package simulation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	hg "github.com/mosaicnetworks/babble/src/hashgraph"
+)
+
+//app is the ProxyHandler of a simulated node. It computes a state hash by
+//chaining the transactions of each Block, and records the Blocks it commits.
+//Commits are handed to the Simulation, so that they happen in a step of their
+//own instead of racing with the routine that produced the Block.
+type app struct {
+	sync.Mutex
+	sim  *Simulation
+	node *simNode
+
+	stateHash []byte
+	snapshots map[int][]byte
+}
+
+func newApp(sim *Simulation, node *simNode) *app {
+	return &app{
+		sim:       sim,
+		node:      node,
+		snapshots: make(map[int][]byte),
+	}
+}
+
+//CommitHandler implements the ProxyHandler interface
+func (a *app) CommitHandler(block hg.Block) ([]byte, error) {
+	done := make(chan []byte, 1)
+	a.sim.request(a.node.index, fmt.Sprintf("commit %d", block.Index()), func() {
+		a.sim.clock.Schedule(0, func() {
+			done <- a.commit(block)
+		})
+	})
+
+	select {
+	case stateHash := <-done:
+		return stateHash, nil
+	case <-a.sim.done:
+		return nil, fmt.Errorf("simulation over")
+	}
+}
+
+//commit is called by the driver
+func (a *app) commit(block hg.Block) []byte {
+	a.Lock()
+	defer a.Unlock()
+
+	for _, tx := range block.Transactions() {
+		a.stateHash = crypto.SimpleHashFromTwoHashes(a.stateHash, crypto.SHA256(tx))
+	}
+	a.snapshots[block.Index()] = a.stateHash
+
+	block.Body.StateHash = a.stateHash
+	a.node.blocks[block.Index()] = block
+
+	if a.node.behaviour != nil {
+		return a.node.behaviour.commit(a.sim, a.node, block, a.stateHash)
+	}
+	return a.stateHash
+}
+
+//SnapshotHandler implements the ProxyHandler interface. A snapshot is the state
+//hash.
+func (a *app) SnapshotHandler(blockIndex int) ([]byte, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	snapshot, ok := a.snapshots[blockIndex]
+	if !ok {
+		return nil, fmt.Errorf("no snapshot for Block %d", blockIndex)
+	}
+	return snapshot, nil
+}
+
+//RestoreHandler implements the ProxyHandler interface
+func (a *app) RestoreHandler(snapshot []byte) ([]byte, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.stateHash = snapshot
+	return a.stateHash, nil
+}
@@ -0,0 +1,389 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/mosaicnetworks/babble/src/crypto"
+	hg "github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/net"
+	"github.com/mosaicnetworks/babble/src/node"
+	"github.com/sirupsen/logrus"
+)
+
+/*******************************************************************************
+Byzantine behaviours
+*******************************************************************************/
+
+//honest does not alter anything. It is embedded by the other behaviours.
+type honest struct{}
+
+func (honest) request(s *Simulation, n, to *simNode, cmd interface{}) interface{} {
+	return cmd
+}
+func (honest) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	return resp
+}
+func (honest) commit(s *Simulation, n *simNode, block hg.Block, stateHash []byte) []byte {
+	return stateHash
+}
+
+//silent never initiates gossip and withholds all its Events
+type silent struct{ honest }
+
+func (silent) request(s *Simulation, n, to *simNode, cmd interface{}) interface{} {
+	return nil
+}
+func (silent) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	return nil
+}
+
+//selective only gossips with a subset of its peers
+type selective struct {
+	honest
+	friends map[int]bool
+}
+
+func (b selective) request(s *Simulation, n, to *simNode, cmd interface{}) interface{} {
+	if !b.friends[to.index] {
+		return nil
+	}
+	return cmd
+}
+
+func (b selective) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	return b.request(s, n, to, resp)
+}
+
+//malformed regularly replaces the Events it sends with broken ones: bad
+//indexes, bad signatures, or self-parents that do not exist.
+type malformed struct{ honest }
+
+func (b malformed) corrupt(s *Simulation, events []hg.WireEvent) []hg.WireEvent {
+	if len(events) == 0 || s.rng.Intn(2) == 0 {
+		return events
+	}
+
+	res := make([]hg.WireEvent, len(events))
+	copy(res, events)
+	k := s.rng.Intn(len(res))
+	bad := res[k]
+
+	switch s.rng.Intn(4) {
+	case 0:
+		bad.Body.Index += 1 + s.rng.Intn(10)
+	case 1:
+		bad.Body.SelfParentIndex += 1 + s.rng.Intn(10)
+	case 2:
+		bad.Signature = "1|1"
+	case 3:
+		bad.Body.Transactions = append(append([][]byte{}, bad.Body.Transactions...),
+			[]byte("injected"))
+	}
+	res[k] = bad
+
+	return res
+}
+
+func (b malformed) request(s *Simulation, n, to *simNode, cmd interface{}) interface{} {
+	if r, ok := cmd.(*net.EagerSyncRequest); ok {
+		bad := *r
+		bad.Events = b.corrupt(s, r.Events)
+		return &bad
+	}
+	return cmd
+}
+
+func (b malformed) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	if r, ok := resp.(*net.SyncResponse); ok {
+		bad := *r
+		bad.Events = b.corrupt(s, r.Events)
+		return &bad
+	}
+	return resp
+}
+
+//bogusSigner signs Blocks with a state hash that no honest node computes
+type bogusSigner struct{ honest }
+
+func (bogusSigner) commit(s *Simulation, n *simNode, block hg.Block, stateHash []byte) []byte {
+	bogus := make([]byte, len(stateHash))
+	s.rng.Read(bogus)
+	return bogus
+}
+
+//forker equivocates: it creates a second version of one of its Events and
+//sends it to the victims, while the other nodes receive the real one. It waits
+//until it committed forkAfter Blocks, so that there are Blocks to disagree on.
+type forker struct {
+	honest
+	victims   map[int]bool
+	forkAfter int
+	events    *node.Subscription
+	fork      *hg.WireEvent
+	forkIndex int
+	sent      int
+	//forkHeight is the height of the forker when it forked
+	forkHeight int
+}
+
+//prepare forks the latest Event of the node, once it has one with a
+//self-parent
+func (b *forker) prepare(n *simNode) {
+	if b.fork != nil {
+		return
+	}
+	if b.events == nil {
+		b.events = n.node.Subscribe(1000, node.SelfEventCreated)
+	}
+
+	n.app.Lock()
+	height := n.height()
+	n.app.Unlock()
+	if height < b.forkAfter {
+		return
+	}
+	b.forkHeight = height
+
+	var head *hg.Event
+	for len(b.events.Notifications()) > 0 {
+		head = (<-b.events.Notifications()).Event
+	}
+	if head == nil || head.Index() < 1 {
+		return
+	}
+
+	fork := hg.NewEvent([][]byte{[]byte("fork")},
+		nil,
+		[]string{head.SelfParent(), ""},
+		crypto.FromECDSAPub(&n.key.PublicKey),
+		head.Index())
+	if err := fork.SignWith(n.key, signRFC6979); err != nil {
+		return
+	}
+	fork.SetWireInfo(head.Index()-1, -1, -1, n.node.ID())
+
+	we := fork.ToWire()
+	b.fork = &we
+	b.forkIndex = head.Index()
+	b.events.Unsubscribe()
+}
+
+func (b *forker) filter(n, to *simNode, events []hg.WireEvent) []hg.WireEvent {
+	b.prepare(n)
+	if b.fork == nil || !b.victims[to.index] {
+		return events
+	}
+	res := []hg.WireEvent{}
+	for _, e := range events {
+		if e.Body.CreatorID == n.node.ID() && e.Body.Index >= b.forkIndex {
+			if e.Body.Index == b.forkIndex {
+				res = append(res, *b.fork)
+				b.sent++
+			}
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func (b *forker) request(s *Simulation, n, to *simNode, cmd interface{}) interface{} {
+	if r, ok := cmd.(*net.EagerSyncRequest); ok {
+		filtered := *r
+		filtered.Events = b.filter(n, to, r.Events)
+		return &filtered
+	}
+	return cmd
+}
+
+func (b *forker) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	if r, ok := resp.(*net.SyncResponse); ok {
+		filtered := *r
+		filtered.Events = b.filter(n, to, r.Events)
+		return &filtered
+	}
+	return resp
+}
+
+//forger answers FastForwardRequests with Frames that do not match their Block,
+//and with Blocks that only it signed
+type forger struct {
+	honest
+	forged int
+}
+
+func (b *forger) response(s *Simulation, n, to *simNode, resp interface{}) interface{} {
+	r, ok := resp.(*net.FastForwardResponse)
+	if !ok {
+		return resp
+	}
+	forged := *r
+
+	if len(r.Frame.Events) > 0 && s.rng.Intn(2) == 0 {
+		//A Frame that does not match the Block's FrameHash
+		forged.Frame.Events = r.Frame.Events[:len(r.Frame.Events)-1]
+	} else {
+		//A Block that only carries the forger's signature
+		block := hg.NewBlock(r.Block.Index(),
+			r.Block.RoundReceived(),
+			r.Block.FrameHash(),
+			[][]byte{[]byte("forged")})
+		sig, err := block.SignWith(n.key, signRFC6979)
+		if err != nil {
+			return resp
+		}
+		block.SetSignature(sig)
+		forged.Block = block
+	}
+	b.forged++
+
+	return &forged
+}
+
+/*******************************************************************************
+Scenarios
+*******************************************************************************/
+
+type scenario struct {
+	name string
+	//make returns the behaviour of the i-th faulty node in a network of n
+	make func(i, n int) behaviour
+	//stalls describes a known bug which can stop honest nodes from making
+	//progress in the scenario. Only safety is checked then.
+	stalls string
+}
+
+var scenarios = []scenario{
+	{"silent", func(i, n int) behaviour { return silent{} }, ""},
+	{"selective", func(i, n int) behaviour {
+		return selective{friends: map[int]bool{(i + 1) % n: true}}
+	}, ""},
+	{"malformed", func(i, n int) behaviour { return malformed{} }, ""},
+	{"bogus-block-signatures", func(i, n int) behaviour { return bogusSigner{} }, ""},
+	{"fork", func(i, n int) behaviour {
+		victims := map[int]bool{}
+		for v := 0; v < n; v += 2 {
+			victims[v] = true
+		}
+		return &forker{victims: victims, forkAfter: 3}
+	}, "known bug: forks are not handled. The Store keeps one Event per creator " +
+		"and index, and WireEvents refer to parents by index, so honest nodes " +
+		"that accepted different branches reject each other's descendants, and " +
+		"consensus can stall"},
+}
+
+//runByzantine runs a simulation where the last f nodes, with 3f < n, follow a
+//faulty behaviour.
+func runByzantine(sc scenario, n int, seed int64, t *testing.T) *Simulation {
+	conf := DefaultConfig(seed)
+	conf.Logger = common.NewTestLogger(t)
+	conf.Logger.Level = logrus.FatalLevel
+	conf.Nodes = n
+	conf.Duration = 3 * time.Second
+
+	sim, err := NewSimulation(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := (n - 1) / 3
+	for i := n - f; i < n; i++ {
+		sim.nodes[i].behaviour = sc.make(i, n)
+	}
+
+	sim.Run(3 * time.Second)
+
+	for _, fn := range sim.nodes {
+		if b, ok := fn.behaviour.(*forker); ok {
+			if b.sent == 0 {
+				t.Fatalf("node %d never sent its fork (seed %d)", fn.index, seed)
+			}
+			t.Logf("n=%d seed=%d: forked at height %d, honest nodes reached %d",
+				n, seed, b.forkHeight, sim.MinBlocks())
+		}
+	}
+
+	if sc.stalls != "" {
+		if err := sim.CheckSafety(); err != nil {
+			t.Fatalf("%s (replay with seed %d)", err, seed)
+		}
+		return sim
+	}
+
+	if err := sim.Check(); err != nil {
+		t.Fatalf("%s (replay with seed %d)", err, seed)
+	}
+
+	return sim
+}
+
+func TestByzantine(t *testing.T) {
+	seeds := int64(2)
+	if testing.Short() {
+		seeds = 1
+	}
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			if sc.stalls != "" {
+				t.Log(sc.stalls)
+			}
+			for _, n := range []int{4, 7} {
+				for seed := int64(0); seed < seeds; seed++ {
+					sim := runByzantine(sc, n, seed, t)
+					t.Logf("n=%d seed=%d: %d blocks, %+v", n, seed, sim.MinBlocks(), sim.Stats)
+				}
+			}
+		})
+	}
+}
+
+//TestByzantineFastForward checks that a node which fell behind refuses forged
+//Blocks and Frames in response to its FastForwardRequests, and fast-forwards
+//with the help of honest peers. The forger is not always among the peers that
+//the victim asks, so several seeds are tried.
+func TestByzantineFastForward(t *testing.T) {
+	forged := 0
+	for seed := int64(0); seed < 3; seed++ {
+		conf := DefaultConfig(seed)
+		conf.Logger = common.NewTestLogger(t)
+		conf.Logger.Level = logrus.FatalLevel
+		conf.Duration = 4 * time.Second
+		conf.SyncLimit = 50
+
+		sim, err := NewSimulation(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := &forger{}
+		sim.nodes[3].behaviour = b
+
+		//The victim is isolated until the others are more than SyncLimit
+		//Events ahead
+		victim := sim.nodes[0]
+		sim.Network().Partition([]int{1, 2, 3})
+		sim.Clock().Schedule(2*time.Second, func() {
+			sim.Network().Heal()
+		})
+
+		sim.Run(2 * time.Second)
+
+		if err := sim.Check(); err != nil {
+			t.Fatalf("%s (seed %d)", err, seed)
+		}
+
+		blocks := sim.Blocks(victim.index)
+		if len(blocks) == 0 || blocks[0].Index() == 0 {
+			t.Fatalf("the victim should have fast-forwarded (seed %d): %+v", seed, sim.Stats)
+		}
+
+		forged += b.forged
+	}
+
+	if forged == 0 {
+		t.Fatal("the victims should have received forged responses")
+	}
+}
@@ -0,0 +1,131 @@
+package simulation
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+//Clock is a virtual clock. Time only moves forward when the next scheduled
+//callback is executed, so a simulation runs as fast as the CPU allows and its
+//outcome only depends on the order in which callbacks were scheduled. It is
+//safe for concurrent use, but callbacks are only executed by Step.
+type Clock struct {
+	sync.Mutex
+	now   time.Time
+	seq   uint64
+	queue timerQueue
+}
+
+//NewClock creates a Clock starting at the Unix epoch
+func NewClock() *Clock {
+	return &Clock{
+		now: time.Unix(0, 0).UTC(),
+	}
+}
+
+//Now returns the current virtual time
+func (c *Clock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+//Elapsed returns the virtual time elapsed since the Clock was created
+func (c *Clock) Elapsed() time.Duration {
+	return c.Now().Sub(time.Unix(0, 0))
+}
+
+//Schedule registers a callback to be executed after a virtual delay. Callbacks
+//scheduled for the same instant are executed in the order they were scheduled.
+func (c *Clock) Schedule(delay time.Duration, f func()) {
+	if delay < 0 {
+		delay = 0
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.seq++
+	heap.Push(&c.queue, &timer{
+		at:  c.now.Add(delay),
+		seq: c.seq,
+		f:   f,
+	})
+}
+
+//After is the virtual equivalent of time.After. The returned channel is
+//buffered so that firing it never blocks the Clock.
+func (c *Clock) After(delay time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.Schedule(delay, func() {
+		ch <- c.Now()
+	})
+	return ch
+}
+
+//Pending returns the number of callbacks waiting to be executed
+func (c *Clock) Pending() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.queue.Len()
+}
+
+//Step advances the Clock to the next callback scheduled no later than deadline
+//and executes it. It returns false if there was nothing to execute.
+func (c *Clock) Step(deadline time.Time) bool {
+	c.Lock()
+	if c.queue.Len() == 0 || c.queue[0].at.After(deadline) {
+		c.Unlock()
+		return false
+	}
+	t := heap.Pop(&c.queue).(*timer)
+	c.now = t.at
+	c.Unlock()
+
+	t.f()
+	return true
+}
+
+//advance moves the Clock forward to t without executing anything
+func (c *Clock) advance(t time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+/*******************************************************************************
+timerQueue
+*******************************************************************************/
+
+type timer struct {
+	at  time.Time
+	seq uint64
+	f   func()
+}
+
+//timerQueue implements heap.Interface, ordering timers by time and then by
+//insertion order.
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+func (q timerQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q timerQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *timerQueue) Push(x interface{}) {
+	*q = append(*q, x.(*timer))
+}
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return t
+}
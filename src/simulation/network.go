@@ -0,0 +1,117 @@
+package simulation
+
+import (
+	"math/rand"
+	"time"
+)
+
+//NetworkConfig controls how the simulated network misbehaves
+type NetworkConfig struct {
+	MinLatency  time.Duration //minimum delivery delay
+	MaxLatency  time.Duration //maximum delivery delay
+	DropRate    float64       //probability that a message is lost
+	ReorderRate float64       //probability that a message is held back for an extra MaxLatency
+}
+
+//DefaultNetworkConfig returns a reliable network with some latency
+func DefaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		MinLatency: 5 * time.Millisecond,
+		MaxLatency: 50 * time.Millisecond,
+	}
+}
+
+//NetworkStats counts what happened to the messages sent on a Network
+type NetworkStats struct {
+	Sent        int
+	Delivered   int
+	Dropped     int
+	Partitioned int
+}
+
+//Network is an in-memory network where message delivery is scheduled on a
+//virtual Clock. All random decisions are taken from the simulation's seeded
+//source, so a given seed always yields the same sequence of deliveries.
+type Network struct {
+	conf  NetworkConfig
+	clock *Clock
+	rng   *rand.Rand
+
+	//partition maps a node to its group. Nodes in different groups cannot
+	//communicate. A nil map means the network is not partitioned.
+	partition map[int]int
+
+	Stats NetworkStats
+}
+
+//NewNetwork creates a Network on top of a Clock and a random source
+func NewNetwork(conf NetworkConfig, clock *Clock, rng *rand.Rand) *Network {
+	if conf.MaxLatency < conf.MinLatency {
+		conf.MaxLatency = conf.MinLatency
+	}
+	return &Network{
+		conf:  conf,
+		clock: clock,
+		rng:   rng,
+	}
+}
+
+//Partition splits the network into groups of nodes. Nodes which do not appear
+//in any group are isolated from everyone.
+func (n *Network) Partition(groups ...[]int) {
+	n.partition = make(map[int]int)
+	for g, group := range groups {
+		for _, id := range group {
+			n.partition[id] = g
+		}
+	}
+}
+
+//Heal removes all partitions
+func (n *Network) Heal() {
+	n.partition = nil
+}
+
+//Connected returns true if a message can go from a to b
+func (n *Network) Connected(a, b int) bool {
+	if n.partition == nil {
+		return true
+	}
+	ga, oka := n.partition[a]
+	gb, okb := n.partition[b]
+	return oka && okb && ga == gb
+}
+
+//Send schedules the delivery of a message from one node to another. deliver
+//is called upon delivery, unless the message is dropped or the nodes are
+//partitioned at the time of sending or of delivery.
+func (n *Network) Send(from, to int, deliver func()) {
+	n.Stats.Sent++
+
+	if !n.Connected(from, to) {
+		n.Stats.Partitioned++
+		return
+	}
+
+	if n.conf.DropRate > 0 && n.rng.Float64() < n.conf.DropRate {
+		n.Stats.Dropped++
+		return
+	}
+
+	delay := n.conf.MinLatency
+	if spread := n.conf.MaxLatency - n.conf.MinLatency; spread > 0 {
+		delay += time.Duration(n.rng.Int63n(int64(spread)))
+	}
+	if n.conf.ReorderRate > 0 && n.rng.Float64() < n.conf.ReorderRate {
+		delay += n.conf.MaxLatency
+	}
+
+	n.clock.Schedule(delay, func() {
+		if !n.Connected(from, to) {
+			n.Stats.Partitioned++
+			return
+		}
+		n.Stats.Delivered++
+		deliver()
+	})
+}
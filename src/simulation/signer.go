@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+//signRFC6979 computes an ECDSA signature whose nonce is derived from the
+//private key and the hash, as specified by RFC 6979 with HMAC-SHA256. Signing
+//the same hash twice yields the same signature, which makes Events, and the
+//order in which consensus sorts them, reproducible. It is the Signer of the
+//simulated nodes only: it is not constant-time.
+func signRFC6979(priv *ecdsa.PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	params := priv.Curve.Params()
+	n := params.N
+	if n.Sign() == 0 || priv.D == nil || priv.D.Sign() <= 0 || priv.D.Cmp(n) >= 0 {
+		return nil, nil, errors.New("invalid private key")
+	}
+
+	e := bits2int(hash, n.BitLen())
+	nonce := newNonceGenerator(priv.D, hash, n)
+
+	for {
+		k := nonce()
+
+		x, _ := priv.Curve.ScalarBaseMult(int2octets(k, n))
+		r = new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		s = new(big.Int).Mul(r, priv.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		return r, s, nil
+	}
+}
+
+//newNonceGenerator returns the sequence of candidate nonces of RFC 6979,
+//section 3.2. Every call returns the next candidate in [1, n-1].
+func newNonceGenerator(x *big.Int, hash []byte, n *big.Int) func() *big.Int {
+	qlen := n.BitLen()
+	rolen := (qlen + 7) / 8
+
+	mac := func(key []byte, data ...[]byte) []byte {
+		h := hmac.New(sha256.New, key)
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+
+	h1 := new(big.Int).Mod(bits2int(hash, qlen), n)
+	seed := append(int2octets(x, n), int2octets(h1, n)...)
+
+	v := make([]byte, sha256.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+
+	k = mac(k, v, []byte{0x00}, seed)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, seed)
+	v = mac(k, v)
+
+	first := true
+	return func() *big.Int {
+		for {
+			if !first {
+				k = mac(k, v, []byte{0x00})
+				v = mac(k, v)
+			}
+			first = false
+
+			t := []byte{}
+			for len(t) < rolen {
+				v = mac(k, v)
+				t = append(t, v...)
+			}
+
+			candidate := bits2int(t, qlen)
+			if candidate.Sign() > 0 && candidate.Cmp(n) < 0 {
+				return candidate
+			}
+		}
+	}
+}
+
+//bits2int converts a byte string to an integer, keeping its qlen leftmost bits
+func bits2int(b []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - qlen; excess > 0 {
+		v.Rsh(v, uint(excess))
+	}
+	return v
+}
+
+//int2octets encodes an integer, lower than n, on as many bytes as n
+func int2octets(v, n *big.Int) []byte {
+	res := make([]byte, (n.BitLen()+7)/8)
+	b := v.Bytes()
+	copy(res[len(res)-len(b):], b)
+	return res
+}
@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+)
+
+//TestSignRFC6979 checks signRFC6979 against the P-256/SHA-256 test vectors of RFC 6979
+//(A.2.5)
+func TestSignRFC6979(t *testing.T) {
+	d, _ := new(big.Int).SetString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", 16)
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = elliptic.P256()
+	key.PublicKey.X, key.PublicKey.Y = key.PublicKey.Curve.ScalarBaseMult(d.Bytes())
+
+	vectors := []struct {
+		msg, r, s string
+	}{
+		{"sample",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8"},
+		{"test",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083"},
+	}
+
+	for _, v := range vectors {
+		hash := crypto.SHA256([]byte(v.msg))
+		r, s, err := signRFC6979(key, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%064X", r); got != v.r {
+			t.Fatalf("%s: r should be %s, not %s", v.msg, v.r, got)
+		}
+		if got := fmt.Sprintf("%064X", s); got != v.s {
+			t.Fatalf("%s: s should be %s, not %s", v.msg, v.s, got)
+		}
+		if !crypto.Verify(&key.PublicKey, hash, r, s) {
+			t.Fatalf("%s: signature should be valid", v.msg)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+//Package simulation runs several Babble Nodes on top of a virtual clock and a
+//simulated network which can delay, drop, reorder and partition messages. The
+//Nodes are the real thing, with their own routines; only their timers,
+//random decisions, transport and App are simulated. Runs are entirely
+//determined by a seed, so any failing scenario can be replayed exactly:
+//
+//	go test ./src/simulation -run TestRandomSimulations -args -sim.seed=123
+//
+//and many scenarios can be run in CI with -sim.runs.
+//
+//Virtual time only moves forward when every routine of every Node is blocked,
+//waiting for a message, a timer or the App. What the Nodes ask for in the
+//meantime (messages, timers and commits) is collected and scheduled in a
+//canonical order, so that the order in which concurrent routines happened to
+//ask does not matter. Simulations must not run in parallel with other tests.
+package simulation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mosaicnetworks/babble/src/crypto"
+	hg "github.com/mosaicnetworks/babble/src/hashgraph"
+	"github.com/mosaicnetworks/babble/src/node"
+	"github.com/mosaicnetworks/babble/src/peers"
+	"github.com/mosaicnetworks/babble/src/proxy/inmem"
+)
+
+//Config describes a simulation scenario. Two simulations with the same Config
+//commit exactly the same Blocks.
+type Config struct {
+	Seed       int64
+	Nodes      int
+	Heartbeat  time.Duration //base heartbeat of the Nodes
+	Duration   time.Duration //virtual time during which transactions are submitted
+	TxInterval time.Duration //mean time between two transactions
+	CacheSize  int
+	SyncLimit  int
+	Network    NetworkConfig
+	Logger     *logrus.Logger
+}
+
+//DefaultConfig returns a small healthy scenario
+func DefaultConfig(seed int64) Config {
+	logger := logrus.New()
+	logger.Level = logrus.ErrorLevel
+
+	return Config{
+		Seed:       seed,
+		Nodes:      4,
+		Heartbeat:  10 * time.Millisecond,
+		Duration:   5 * time.Second,
+		TxInterval: 50 * time.Millisecond,
+		CacheSize:  10000,
+		SyncLimit:  10000,
+		Network:    DefaultNetworkConfig(),
+		Logger:     logger,
+	}
+}
+
+//Stats summarises a simulation run
+type Stats struct {
+	Transactions int //transactions submitted
+	Requests     int //requests sent
+	Responses    int //responses received in time
+	Timeouts     int //requests that got no response in time
+	Overflows    int //requests dropped because a Node was not reading them
+}
+
+//Simulation runs a set of Nodes, connected by a simulated Network, driven by a
+//virtual Clock. Every random decision is taken from a source seeded with
+//Config.Seed, which makes every run replayable.
+type Simulation struct {
+	conf    Config
+	rng     *rand.Rand
+	clock   *Clock
+	network *Network
+	nodes   []*simNode
+	byAddr  map[string]*simNode
+	timeout time.Duration
+
+	//requests made by the Nodes since the last step (cf settle)
+	requestLock sync.Mutex
+	requests    []request
+
+	//done is closed at the end of the run, to release the routines that are
+	//waiting for the Simulation
+	done  chan struct{}
+	stack []byte
+
+	Stats Stats
+}
+
+//NewSimulation creates the Nodes and wires them to the simulated Network
+func NewSimulation(conf Config) (*Simulation, error) {
+	if conf.Logger == nil {
+		conf.Logger = logrus.New()
+		conf.Logger.Level = logrus.ErrorLevel
+	}
+
+	rng := rand.New(rand.NewSource(conf.Seed))
+	clock := NewClock()
+
+	sim := &Simulation{
+		conf:    conf,
+		rng:     rng,
+		clock:   clock,
+		network: NewNetwork(conf.Network, clock, rng),
+		byAddr:  make(map[string]*simNode),
+		done:    make(chan struct{}),
+		stack:   make([]byte, 1<<16),
+	}
+
+	//Give up on requests that cannot have been answered, which happens when
+	//messages are dropped or partitioned
+	sim.timeout = 4*sim.network.conf.MaxLatency + conf.Heartbeat
+
+	keys := make([]*ecdsa.PrivateKey, conf.Nodes)
+	participants := peers.NewPeers()
+	for i := 0; i < conf.Nodes; i++ {
+		keys[i] = deterministicKey(rng)
+		participants.AddPeer(peers.NewPeer(
+			fmt.Sprintf("0x%X", crypto.FromECDSAPub(&keys[i].PublicKey)),
+			fmt.Sprintf("sim%d", i),
+		))
+	}
+
+	for i := 0; i < conf.Nodes; i++ {
+		n := &simNode{
+			index:  i,
+			addr:   fmt.Sprintf("sim%d", i),
+			key:    keys[i],
+			blocks: make(map[int]hg.Block),
+		}
+		sim.nodes = append(sim.nodes, n)
+		sim.byAddr[n.addr] = n
+	}
+
+	for _, n := range sim.nodes {
+		peer := participants.ByPubKey[fmt.Sprintf("0x%X", crypto.FromECDSAPub(&n.key.PublicKey))]
+
+		nodeConf := node.NewConfig(conf.Heartbeat,
+			sim.timeout,
+			conf.CacheSize,
+			conf.SyncLimit,
+			conf.Logger)
+		nodeConf.Clock = nodeClock{sim: sim, index: n.index}
+		nodeConf.Seed = 1 + rng.Int63n(math.MaxInt64-1)
+		nodeConf.Signer = signRFC6979
+
+		n.trans = newTransport(sim, n)
+		n.app = newApp(sim, n)
+		n.proxy = inmem.NewInmemProxy(n.app, conf.Logger)
+		n.node = node.NewNode(nodeConf,
+			peer.ID,
+			n.key,
+			participants,
+			hg.NewInmemStore(participants, conf.CacheSize),
+			n.trans,
+			n.proxy)
+		if err := n.node.Init(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sim, nil
+}
+
+//Clock returns the virtual Clock, which can be used to schedule faults
+func (s *Simulation) Clock() *Clock {
+	return s.clock
+}
+
+//Network returns the simulated Network
+func (s *Simulation) Network() *Network {
+	return s.network
+}
+
+//Blocks returns the Blocks committed by a node, in order. A node that
+//fast-forwarded has not committed the Blocks that precede its anchor Block.
+func (s *Simulation) Blocks(i int) []hg.Block {
+	n := s.nodes[i]
+	indexes := make([]int, 0, len(n.blocks))
+	for k := range n.blocks {
+		indexes = append(indexes, k)
+	}
+	sort.Ints(indexes)
+
+	res := make([]hg.Block, len(indexes))
+	for k, index := range indexes {
+		res[k] = n.blocks[index]
+	}
+	return res
+}
+
+//Run starts the Nodes, submits transactions for Config.Duration, and then
+//keeps going for settle, so that the last transactions can reach consensus.
+//The Nodes are shut down at the end; a Simulation can only be run once.
+func (s *Simulation) Run(settle time.Duration) {
+	for _, n := range s.nodes {
+		n.node.RunAsync(true)
+	}
+	s.scheduleTransaction()
+
+	s.runFor(s.conf.Duration + settle)
+
+	s.stop()
+}
+
+//Check verifies that every honest node committed Blocks, and that they agree
+//on them (cf CheckSafety)
+func (s *Simulation) Check() error {
+	for _, n := range s.honestNodes() {
+		if len(n.blocks) == 0 {
+			return fmt.Errorf("seed %d: node %d did not commit any block: %+v",
+				s.conf.Seed, n.index, s.Stats)
+		}
+	}
+	return s.CheckSafety()
+}
+
+//CheckSafety verifies that the honest nodes agree on the Blocks they committed.
+//Nodes may be at different heights, but all Blocks with the same index must be
+//identical.
+func (s *Simulation) CheckSafety() error {
+	honest := s.honestNodes()
+	for i := 1; i < len(honest); i++ {
+		a, b := honest[0], honest[i]
+		for k, ba := range a.blocks {
+			bb, ok := b.blocks[k]
+			if !ok {
+				continue
+			}
+			if err := compareBlocks(ba, bb); err != nil {
+				return fmt.Errorf("seed %d: node %d and node %d disagree on block %d: %s",
+					s.conf.Seed, a.index, b.index, k, err)
+			}
+		}
+	}
+	return nil
+}
+
+//MinBlocks returns the smallest height reached by an honest node, ie the index
+//of the last Block it committed plus one
+func (s *Simulation) MinBlocks() int {
+	min := -1
+	for _, n := range s.honestNodes() {
+		if h := n.height(); min < 0 || h < min {
+			min = h
+		}
+	}
+	return min
+}
+
+func (s *Simulation) honestNodes() []*simNode {
+	res := []*simNode{}
+	for _, n := range s.nodes {
+		if n.behaviour == nil {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+func compareBlocks(a, b hg.Block) error {
+	if a.Index() != b.Index() {
+		return fmt.Errorf("index %d != %d", a.Index(), b.Index())
+	}
+	if a.RoundReceived() != b.RoundReceived() {
+		return fmt.Errorf("round received %d != %d", a.RoundReceived(), b.RoundReceived())
+	}
+	if !bytes.Equal(a.FrameHash(), b.FrameHash()) {
+		return fmt.Errorf("frame hash %X != %X", a.FrameHash(), b.FrameHash())
+	}
+	if !bytes.Equal(a.StateHash(), b.StateHash()) {
+		return fmt.Errorf("state hash %X != %X", a.StateHash(), b.StateHash())
+	}
+	return nil
+}
+
+/*******************************************************************************
+Scheduling
+*******************************************************************************/
+
+//request is something that a Node asked for: a message, a timer or a commit.
+//f is called by the driver to schedule it.
+type request struct {
+	node int
+	key  string
+	f    func()
+}
+
+//request records a request from a Node. key identifies the request among
+//those that the Node might make concurrently.
+func (s *Simulation) request(node int, key string, f func()) {
+	s.requestLock.Lock()
+	defer s.requestLock.Unlock()
+	s.requests = append(s.requests, request{node: node, key: key, f: f})
+}
+
+//runFor executes the callbacks scheduled within the given virtual duration,
+//letting the Nodes settle after each one
+func (s *Simulation) runFor(d time.Duration) {
+	deadline := s.clock.Now().Add(d)
+	s.settle()
+	for s.clock.Step(deadline) {
+		s.settle()
+	}
+	s.clock.advance(deadline)
+}
+
+//settle waits until the Nodes are idle, and schedules the requests they made,
+//sorted by Node and key
+func (s *Simulation) settle() {
+	for {
+		s.waitIdle()
+
+		s.requestLock.Lock()
+		requests := s.requests
+		s.requests = nil
+		s.requestLock.Unlock()
+
+		if len(requests) == 0 {
+			return
+		}
+
+		sort.SliceStable(requests, func(i, j int) bool {
+			if requests[i].node != requests[j].node {
+				return requests[i].node < requests[j].node
+			}
+			return requests[i].key < requests[j].key
+		})
+		for _, r := range requests {
+			r.f()
+		}
+	}
+}
+
+//waitIdle returns once all the other goroutines are blocked
+func (s *Simulation) waitIdle() {
+	for {
+		runtime.Gosched()
+		n := runtime.Stack(s.stack, true)
+		if n == len(s.stack) {
+			s.stack = make([]byte, 2*len(s.stack))
+			continue
+		}
+		if idle(s.stack[:n]) {
+			return
+		}
+	}
+}
+
+//busy lists the states of goroutines that make progress on their own
+var busy = map[string]bool{
+	"running":           true,
+	"runnable":          true,
+	"syscall":           true,
+	"preempted":         true,
+	"copystack":         true,
+	"sleep":             true,
+	"GC assist wait":    true,
+	"GC assist marking": true,
+}
+
+//idle parses the output of runtime.Stack and returns true if none of the
+//goroutines, but the first one, which is the caller, is busy
+func idle(stacks []byte) bool {
+	goroutines := bytes.Split(stacks, []byte("\n\n"))
+	for _, g := range goroutines[1:] {
+		header := g
+		if i := bytes.IndexByte(g, '\n'); i >= 0 {
+			header = g[:i]
+		}
+		start, end := bytes.IndexByte(header, '['), bytes.IndexByte(header, ']')
+		if start < 0 || end < start {
+			continue
+		}
+		state := string(header[start+1 : end])
+		if i := bytes.IndexByte([]byte(state), ','); i >= 0 {
+			state = state[:i]
+		}
+		if busy[state] && !bytes.Contains(g, []byte("os/signal.signal_recv")) {
+			return false
+		}
+	}
+	return true
+}
+
+//stop releases the routines that wait for the Simulation, shuts the Nodes
+//down, and waits for their routines to return
+func (s *Simulation) stop() {
+	close(s.done)
+	for _, n := range s.nodes {
+		n.node.Shutdown()
+	}
+	s.waitIdle()
+}
+
+func (s *Simulation) scheduleTransaction() {
+	if s.conf.TxInterval <= 0 {
+		return
+	}
+	delay := time.Duration(s.rng.Int63n(int64(2 * s.conf.TxInterval)))
+	if s.clock.Elapsed()+delay > s.conf.Duration {
+		return
+	}
+	s.clock.Schedule(delay, func() {
+		n := s.nodes[s.rng.Intn(len(s.nodes))]
+		tx := []byte(fmt.Sprintf("node%d tx%d", n.index, s.Stats.Transactions))
+		s.Stats.Transactions++
+		go n.proxy.SubmitTx(tx)
+		s.scheduleTransaction()
+	})
+}
+
+//nodeClock is the node.Clock of a simulated Node. Its timers are requested
+//from the Simulation.
+type nodeClock struct {
+	sim   *Simulation
+	index int
+}
+
+func (c nodeClock) Now() time.Time {
+	return c.sim.clock.Now()
+}
+
+func (c nodeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.sim.request(c.index, fmt.Sprintf("timer %d", d), func() {
+		c.sim.clock.Schedule(d, func() {
+			ch <- c.sim.clock.Now()
+		})
+	})
+	return ch
+}
+
+/*******************************************************************************
+simNode
+*******************************************************************************/
+
+type simNode struct {
+	index     int
+	addr      string
+	key       *ecdsa.PrivateKey
+	node      *node.Node
+	trans     *transport
+	app       *app
+	proxy     *inmem.InmemProxy
+	behaviour behaviour //nil for honest nodes
+
+	//blocks committed by the App, by index
+	blocks map[int]hg.Block
+}
+
+//height returns the index of the last Block committed by the node, plus one
+func (n *simNode) height() int {
+	h := 0
+	for index := range n.blocks {
+		if index+1 > h {
+			h = index + 1
+		}
+	}
+	return h
+}
+
+//behaviour alters what a node sends. It is used by tests to simulate faulty or
+//malicious participants, whose Blocks are then excluded from Check. Its
+//methods are called by the driver.
+type behaviour interface {
+	//request can alter a request, or drop it by returning nil
+	request(s *Simulation, n, to *simNode, cmd interface{}) interface{}
+	//response can alter a response, or drop it by returning nil
+	response(s *Simulation, n, to *simNode, resp interface{}) interface{}
+	//commit can alter the state hash that the App returns for a Block
+	commit(s *Simulation, n *simNode, block hg.Block, stateHash []byte) []byte
+}
+
+//deterministicKey derives an ECDSA key from the simulation's random source so
+//that participant IDs, and therefore the whole run, only depend on the seed.
+func deterministicKey(rng *rand.Rand) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	n := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	d := new(big.Int).Rand(rng, n)
+	d.Add(d, big.NewInt(1))
+
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return key
+}
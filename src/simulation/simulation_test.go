@@ -0,0 +1,154 @@
+package simulation
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mosaicnetworks/babble/src/common"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	seedFlag  = flag.Int64("sim.seed", -1, "replay a single simulation seed")
+	runsFlag  = flag.Int("sim.runs", 20, "number of randomised simulations")
+	nodesFlag = flag.Int("sim.nodes", 4, "max number of nodes per simulation")
+)
+
+//randomConfig derives a scenario from a seed, so that a failing scenario can
+//be replayed with -sim.seed
+func randomConfig(seed int64, t *testing.T) Config {
+	conf := DefaultConfig(seed)
+	conf.Logger = common.NewTestLogger(t)
+	conf.Logger.Level = logrus.ErrorLevel
+
+	r := seed
+	if r < 0 {
+		r = -r
+	}
+	conf.Nodes = 2 + int(r%int64(*nodesFlag-1))
+	conf.Duration = 2 * time.Second
+	conf.Network.DropRate = float64(r%5) / 20        //0 to 20%
+	conf.Network.ReorderRate = float64((r/5)%4) / 10 //0 to 30%
+	conf.Network.MaxLatency = time.Duration(10+r%90) * time.Millisecond
+	return conf
+}
+
+func runSeed(seed int64, t *testing.T) *Simulation {
+	sim, err := NewSimulation(randomConfig(seed, t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim.Run(3 * time.Second)
+
+	if err := sim.Check(); err != nil {
+		t.Fatalf("%s (replay with -sim.seed=%d)", err, seed)
+	}
+
+	return sim
+}
+
+func TestRandomSimulations(t *testing.T) {
+	if *seedFlag >= 0 {
+		sim := runSeed(*seedFlag, t)
+		t.Logf("seed %d: %d blocks, %+v, %+v", *seedFlag, sim.MinBlocks(), sim.Stats, sim.Network().Stats)
+		return
+	}
+
+	runs := *runsFlag
+	if testing.Short() {
+		runs = 5
+	}
+
+	for seed := int64(0); seed < int64(runs); seed++ {
+		runSeed(seed, t)
+	}
+}
+
+func TestSimulationLiveness(t *testing.T) {
+	conf := DefaultConfig(42)
+	conf.Logger = common.NewTestLogger(t)
+	conf.Logger.Level = logrus.ErrorLevel
+
+	sim, err := NewSimulation(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim.Run(2 * time.Second)
+
+	if err := sim.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//blockHashes returns the hashes of the Blocks committed by every node
+func blockHashes(sim *Simulation, t *testing.T) [][]string {
+	res := make([][]string, len(sim.nodes))
+	for i := range sim.nodes {
+		for _, b := range sim.Blocks(i) {
+			hash, err := b.Body.Hash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			res[i] = append(res[i], fmt.Sprintf("%X", hash))
+		}
+	}
+	return res
+}
+
+func TestSimulationDeterminism(t *testing.T) {
+	conf := randomConfig(7, t)
+
+	hashes := [][][]string{}
+	for i := 0; i < 2; i++ {
+		sim, err := NewSimulation(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Run(time.Second)
+		if err := sim.Check(); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, blockHashes(sim, t))
+	}
+
+	if !reflect.DeepEqual(hashes[0], hashes[1]) {
+		t.Fatalf("two runs with the same seed should commit the same blocks:\n%v\n%v",
+			hashes[0], hashes[1])
+	}
+}
+
+func TestSimulationPartition(t *testing.T) {
+	conf := DefaultConfig(3)
+	conf.Logger = common.NewTestLogger(t)
+	conf.Logger.Level = logrus.ErrorLevel
+	conf.Duration = 4 * time.Second
+
+	sim, err := NewSimulation(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Isolate two nodes out of four; neither side has a supermajority so
+	//consensus must stall until the partition heals.
+	sim.Clock().Schedule(time.Second, func() {
+		sim.Network().Partition([]int{0, 1}, []int{2, 3})
+	})
+	sim.Clock().Schedule(3*time.Second, func() {
+		sim.Network().Heal()
+	})
+
+	sim.Run(3 * time.Second)
+
+	if err := sim.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sim.Network().Stats.Partitioned == 0 {
+		t.Fatal("some messages should have been blocked by the partition")
+	}
+}
@@ -0,0 +1,191 @@
+package simulation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mosaicnetworks/babble/src/net"
+)
+
+//errTimeout is returned to a node whose request got no response in time,
+//which happens when messages are dropped or partitioned.
+var errTimeout = fmt.Errorf("simulated request timed out")
+
+//transport implements net.Transport on top of the simulated Network. Requests
+//and responses are handed to the Simulation, which sends them once all the
+//nodes are idle, and delivers them at their virtual arrival time.
+type transport struct {
+	sim  *Simulation
+	node *simNode
+
+	consumer chan net.RPC
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newTransport(sim *Simulation, node *simNode) *transport {
+	return &transport{
+		sim:      sim,
+		node:     node,
+		consumer: make(chan net.RPC, 64),
+		closed:   make(chan struct{}),
+	}
+}
+
+//Consumer implements the Transport interface
+func (t *transport) Consumer() <-chan net.RPC {
+	return t.consumer
+}
+
+//LocalAddr implements the Transport interface
+func (t *transport) LocalAddr() string {
+	return t.node.addr
+}
+
+//Sync implements the Transport interface
+func (t *transport) Sync(target string, args *net.SyncRequest, resp *net.SyncResponse) error {
+	out, err := t.request(target, args)
+	if out != nil {
+		*resp = *out.(*net.SyncResponse)
+	}
+	return err
+}
+
+//EagerSync implements the Transport interface
+func (t *transport) EagerSync(target string, args *net.EagerSyncRequest, resp *net.EagerSyncResponse) error {
+	out, err := t.request(target, args)
+	if out != nil {
+		*resp = *out.(*net.EagerSyncResponse)
+	}
+	return err
+}
+
+//FastForward implements the Transport interface
+func (t *transport) FastForward(target string, args *net.FastForwardRequest, resp *net.FastForwardResponse) error {
+	out, err := t.request(target, args)
+	if out != nil {
+		*resp = *out.(*net.FastForwardResponse)
+	}
+	return err
+}
+
+//SnapshotChunk implements the Transport interface
+func (t *transport) SnapshotChunk(target string, args *net.SnapshotChunkRequest, resp *net.SnapshotChunkResponse) error {
+	out, err := t.request(target, args)
+	if out != nil {
+		*resp = *out.(*net.SnapshotChunkResponse)
+	}
+	return err
+}
+
+//Close implements the Transport interface
+func (t *transport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+	return nil
+}
+
+//pendingRequest is a request that is waiting for its response or its timeout,
+//whichever comes first. It is only accessed by the driver.
+type pendingRequest struct {
+	reply    chan net.RPCResponse
+	answered bool
+}
+
+func (p *pendingRequest) answer(r net.RPCResponse) bool {
+	if p.answered {
+		return false
+	}
+	p.answered = true
+	p.reply <- r
+	return true
+}
+
+//request sends a command and waits for the response, the virtual timeout, or
+//the end of the simulation
+func (t *transport) request(target string, cmd interface{}) (interface{}, error) {
+	to, ok := t.sim.byAddr[target]
+	if !ok {
+		return nil, fmt.Errorf("unknown address %s", target)
+	}
+
+	p := &pendingRequest{reply: make(chan net.RPCResponse, 1)}
+	t.sim.request(t.node.index, fmt.Sprintf("request %d %T", to.index, cmd), func() {
+		t.sim.sendRequest(t.node, to, cmd, p)
+	})
+
+	select {
+	case r := <-p.reply:
+		return r.Response, r.Error
+	case <-t.closed:
+		return nil, fmt.Errorf("transport closed")
+	case <-t.sim.done:
+		return nil, fmt.Errorf("simulation over")
+	}
+}
+
+//sendRequest is called by the driver. The first of the response and the
+//timeout is passed on to the requester.
+func (s *Simulation) sendRequest(from, to *simNode, cmd interface{}, p *pendingRequest) {
+	s.Stats.Requests++
+	s.clock.Schedule(s.timeout, func() {
+		if p.answer(net.RPCResponse{Error: errTimeout}) {
+			s.Stats.Timeouts++
+		}
+	})
+
+	if from.behaviour != nil {
+		if cmd = from.behaviour.request(s, from, to, cmd); cmd == nil {
+			return
+		}
+	}
+
+	s.network.Send(from.index, to.index, func() {
+		s.deliverRequest(from, to, cmd, p)
+	})
+}
+
+//deliverRequest hands a request to the node it was sent to, and forwards the
+//response, once there is one
+func (s *Simulation) deliverRequest(from, to *simNode, cmd interface{}, p *pendingRequest) {
+	select {
+	case <-to.trans.closed:
+		return
+	default:
+	}
+
+	respCh := make(chan net.RPCResponse, 1)
+	select {
+	case to.trans.consumer <- net.RPC{Command: cmd, RespChan: respCh}:
+	default:
+		s.Stats.Overflows++
+		return
+	}
+
+	go func() {
+		select {
+		case r := <-respCh:
+			s.request(to.index, fmt.Sprintf("respond %d %T", from.index, cmd), func() {
+				s.sendResponse(to, from, r, p)
+			})
+		case <-s.done:
+		}
+	}()
+}
+
+//sendResponse is called by the driver
+func (s *Simulation) sendResponse(from, to *simNode, r net.RPCResponse, p *pendingRequest) {
+	if from.behaviour != nil && r.Response != nil {
+		if r.Response = from.behaviour.response(s, from, to, r.Response); r.Response == nil {
+			return
+		}
+	}
+
+	s.network.Send(from.index, to.index, func() {
+		if p.answer(r) {
+			s.Stats.Responses++
+		}
+	})
+}